@@ -0,0 +1,268 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	"github.com/arielireni/example-operator/api/v1beta1"
+	"github.com/arielireni/example-operator/pkg/reporef"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// Annotations keeping the spec values of v1alpha1 that v1beta1 can't represent as they were written, such as a
+// repo given by its url or a time with an offset, so converting back returns them unchanged
+const (
+	RepoAnnotation      = "example.training.redhat.com/v1alpha1-repo"
+	ExpiresAtAnnotation = "example.training.redhat.com/v1alpha1-expires-at"
+)
+
+var _ conversion.Convertible = &GitHubIssue{}
+
+// ConvertTo converts this GitHubIssue to the Hub version (v1beta1)
+func (src *GitHubIssue) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.GitHubIssue)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Annotations = copyAnnotations(src.Annotations)
+
+	spec := src.Spec
+	remote, ok := toRemoteRepository(spec.Repo)
+	if spec.Repo != "" && (!ok || repoString(remote) != spec.Repo) {
+		dst.Annotations[RepoAnnotation] = spec.Repo
+	}
+	expiresAt := toTime(spec.ExpiresAt)
+	if spec.ExpiresAt != "" && fromTime(expiresAt) != spec.ExpiresAt {
+		dst.Annotations[ExpiresAtAnnotation] = spec.ExpiresAt
+	}
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+	dst.Spec = v1beta1.GitHubIssueSpec{
+		Remote:                v1beta1.RemoteReference{RemoteRepository: remote, Provider: spec.Provider},
+		RemoteChangePolicy:    spec.RepoChangePolicy,
+		Title:                 spec.Title,
+		Body:                  spec.Description,
+		Labels:                spec.Labels,
+		Assignees:             spec.Assignees,
+		MilestoneRef:          spec.MilestoneRef,
+		CommentOnEdit:         spec.CommentOnEdit,
+		State:                 spec.State,
+		ExpiresAt:             expiresAt,
+		TTLAfterCreation:      spec.TTLAfterCreation,
+		TTLSecondsAfterClosed: spec.TTLSecondsAfterClosed,
+		CloseWhileBlocked:     spec.CloseWhileBlocked,
+	}
+	if spec.CredentialsRef != nil {
+		dst.Spec.Remote.CredentialsRef = &v1beta1.CredentialsReference{Name: spec.CredentialsRef.Name, Key: spec.CredentialsRef.Key}
+	}
+	if spec.RepositoryRef != nil {
+		dst.Spec.RepositoryRef = &v1beta1.RepositoryReference{Name: spec.RepositoryRef.Name, Namespace: spec.RepositoryRef.Namespace}
+	}
+	for _, blocker := range spec.BlockedBy {
+		dst.Spec.BlockedBy = append(dst.Spec.BlockedBy, v1beta1.IssueReference{Name: blocker.Name, Namespace: blocker.Namespace})
+	}
+	if spec.ParentRef != nil {
+		dst.Spec.ParentRef = &v1beta1.IssueReference{Name: spec.ParentRef.Name, Namespace: spec.ParentRef.Namespace}
+	}
+	if spec.Project != nil {
+		project := v1beta1.ProjectSpec(*spec.Project)
+		dst.Spec.Project = &project
+	}
+
+	status := src.Status
+	dst.Status = v1beta1.GitHubIssueStatus{
+		Number:             status.Number,
+		State:              status.State,
+		LastUpdated:        toTime(status.LastUpdateTimestamp),
+		Conditions:         status.Conditions,
+		Children:           status.Children,
+		Completion:         status.Completion,
+		ProjectItemID:      status.ProjectItemID,
+		PendingEditComment: status.PendingEditComment,
+	}
+	if remote, ok := toRemoteRepository(status.Repo); ok {
+		dst.Status.Remote = &remote
+	}
+	if status.Plan != nil {
+		dst.Status.Plan = &v1beta1.IssuePlan{Action: status.Plan.Action}
+		for _, change := range status.Plan.Changes {
+			dst.Status.Plan.Changes = append(dst.Status.Plan.Changes, v1beta1.FieldChange(change))
+		}
+	}
+	if activity := status.Activity; activity != nil {
+		dst.Status.Activity = &v1beta1.IssueActivity{
+			Author:          activity.Author,
+			CreatedAt:       toTime(activity.CreatedAt),
+			ClosedAt:        toTime(activity.ClosedAt),
+			ClosedBy:        activity.ClosedBy,
+			Comments:        activity.Comments,
+			LastCommenter:   activity.LastCommenter,
+			LastCommentedAt: toTime(activity.LastCommentedAt),
+		}
+		if activity.Reactions != nil {
+			reactions := v1beta1.ReactionCounts(*activity.Reactions)
+			dst.Status.Activity.Reactions = &reactions
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1beta1) to this version
+func (dst *GitHubIssue) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.GitHubIssue)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Annotations = copyAnnotations(src.Annotations)
+	delete(dst.Annotations, RepoAnnotation)
+	delete(dst.Annotations, ExpiresAtAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	spec := src.Spec
+	dst.Spec = GitHubIssueSpec{
+		Repo:                  repoString(spec.Remote.RemoteRepository),
+		Provider:              spec.Remote.Provider,
+		RepoChangePolicy:      spec.RemoteChangePolicy,
+		Title:                 spec.Title,
+		Description:           spec.Body,
+		Labels:                spec.Labels,
+		Assignees:             spec.Assignees,
+		MilestoneRef:          spec.MilestoneRef,
+		CommentOnEdit:         spec.CommentOnEdit,
+		State:                 spec.State,
+		ExpiresAt:             fromTime(spec.ExpiresAt),
+		TTLAfterCreation:      spec.TTLAfterCreation,
+		TTLSecondsAfterClosed: spec.TTLSecondsAfterClosed,
+		CloseWhileBlocked:     spec.CloseWhileBlocked,
+	}
+	// The values kept by ConvertTo are restored as long as v1beta1 clients didn't change them since
+	if repo, ok := src.Annotations[RepoAnnotation]; ok {
+		if remote, _ := toRemoteRepository(repo); sameRepo(remote, spec.Remote.RemoteRepository) {
+			dst.Spec.Repo = repo
+		}
+	}
+	if expiresAt, ok := src.Annotations[ExpiresAtAnnotation]; ok && toTime(expiresAt).Equal(spec.ExpiresAt) {
+		dst.Spec.ExpiresAt = expiresAt
+	}
+	if spec.Remote.CredentialsRef != nil {
+		dst.Spec.CredentialsRef = &CredentialsReference{Name: spec.Remote.CredentialsRef.Name, Key: spec.Remote.CredentialsRef.Key}
+	}
+	if spec.RepositoryRef != nil {
+		dst.Spec.RepositoryRef = &RepositoryReference{Name: spec.RepositoryRef.Name, Namespace: spec.RepositoryRef.Namespace}
+	}
+	for _, blocker := range spec.BlockedBy {
+		dst.Spec.BlockedBy = append(dst.Spec.BlockedBy, IssueReference{Name: blocker.Name, Namespace: blocker.Namespace})
+	}
+	if spec.ParentRef != nil {
+		dst.Spec.ParentRef = &IssueReference{Name: spec.ParentRef.Name, Namespace: spec.ParentRef.Namespace}
+	}
+	if spec.Project != nil {
+		project := ProjectSpec(*spec.Project)
+		dst.Spec.Project = &project
+	}
+
+	status := src.Status
+	dst.Status = GitHubIssueStatus{
+		Number:              status.Number,
+		State:               status.State,
+		LastUpdateTimestamp: fromTime(status.LastUpdated),
+		Conditions:          status.Conditions,
+		Children:            status.Children,
+		Completion:          status.Completion,
+		ProjectItemID:       status.ProjectItemID,
+		PendingEditComment:  status.PendingEditComment,
+	}
+	if status.Remote != nil {
+		dst.Status.Repo = repoString(*status.Remote)
+	}
+	if status.Plan != nil {
+		dst.Status.Plan = &IssuePlan{Action: status.Plan.Action}
+		for _, change := range status.Plan.Changes {
+			dst.Status.Plan.Changes = append(dst.Status.Plan.Changes, FieldChange(change))
+		}
+	}
+	if activity := status.Activity; activity != nil {
+		dst.Status.Activity = &IssueActivity{
+			Author:          activity.Author,
+			CreatedAt:       fromTime(activity.CreatedAt),
+			ClosedAt:        fromTime(activity.ClosedAt),
+			ClosedBy:        activity.ClosedBy,
+			Comments:        activity.Comments,
+			LastCommenter:   activity.LastCommenter,
+			LastCommentedAt: fromTime(activity.LastCommentedAt),
+		}
+		if activity.Reactions != nil {
+			reactions := ReactionCounts(*activity.Reactions)
+			dst.Status.Activity.Reactions = &reactions
+		}
+	}
+	return nil
+}
+
+// toRemoteRepository returns the parts of a repo reference, false if it's malformed
+func toRemoteRepository(repo string) (v1beta1.RemoteRepository, bool) {
+	ref, err := reporef.Parse(repo)
+	if err != nil {
+		return v1beta1.RemoteRepository{}, false
+	}
+	return v1beta1.RemoteRepository{Host: ref.Host, Owner: ref.Owner, Repository: ref.Repo}, true
+}
+
+// repoString returns a repo as owner/repo, or host/owner/repo for an enterprise server
+func repoString(remote v1beta1.RemoteRepository) string {
+	if remote.Owner == "" && remote.Repository == "" {
+		return ""
+	}
+	ref := reporef.Ref{Host: remote.Host, Owner: remote.Owner, Repo: remote.Repository}
+	if ref.Host == "" {
+		return ref.String()
+	}
+	return ref.Host + "/" + ref.String()
+}
+
+// sameRepo returns true if both refer to the same repo, GitHub ignoring the case of its names
+func sameRepo(a, b v1beta1.RemoteRepository) bool {
+	return reporef.Ref{Host: a.Host, Owner: a.Owner, Repo: a.Repository}.Key() ==
+		reporef.Ref{Host: b.Host, Owner: b.Owner, Repo: b.Repository}.Key()
+}
+
+// toTime parses an RFC3339 timestamp, nil if it's empty or malformed
+func toTime(timestamp string) *metav1.Time {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil
+	}
+	converted := metav1.NewTime(t)
+	return &converted
+}
+
+// fromTime formats a time as an RFC3339 timestamp in UTC, the way GitHub does
+func fromTime(t *metav1.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
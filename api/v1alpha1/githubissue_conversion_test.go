@@ -0,0 +1,157 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/arielireni/example-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newConversionTestIssue returns a v1alpha1 GitHubIssue with all its fields set
+func newConversionTestIssue() *GitHubIssue {
+	completion := int32(50)
+	ttl := int64(3600)
+	return &GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "default", Annotations: map[string]string{"owner": "team"}},
+		Spec: GitHubIssueSpec{
+			Repo:                  "github.example.com/arielireni/Issues-Example",
+			Provider:              ProviderGitHub,
+			CredentialsRef:        &CredentialsReference{Name: "team-token", Key: "token"},
+			RepositoryRef:         &RepositoryReference{Name: "issues", Namespace: "platform"},
+			RepoChangePolicy:      "Transfer",
+			Title:                 "title1",
+			Description:           "description",
+			Labels:                []string{"bug"},
+			Assignees:             []string{"octocat"},
+			CommentOnEdit:         true,
+			State:                 "open",
+			ExpiresAt:             "2022-01-01T00:00:00Z",
+			TTLAfterCreation:      &metav1.Duration{Duration: 72 * time.Hour},
+			TTLSecondsAfterClosed: &ttl,
+			BlockedBy:             []IssueReference{{Name: "issue2"}, {Name: "issue3", Namespace: "other"}},
+			CloseWhileBlocked:     true,
+			ParentRef:             &IssueReference{Name: "epic"},
+			Project:               &ProjectSpec{Owner: "arielireni", Number: 1, Status: "Todo", Fields: map[string]string{"Priority": "High"}},
+			MilestoneRef:          &corev1.LocalObjectReference{Name: "v1-0"},
+		},
+		Status: GitHubIssueStatus{
+			State:               "open",
+			LastUpdateTimestamp: "2021-06-01T12:00:00Z",
+			Number:              3,
+			Repo:                "github.example.com/arielireni/Issues-Example",
+			Conditions:          []metav1.Condition{{Type: "Synced", Status: metav1.ConditionTrue, Reason: "Synced"}},
+			Plan:                &IssuePlan{Action: "edit", Changes: []FieldChange{{Field: "body", From: "a", To: "b"}}},
+			Children:            2,
+			Completion:          &completion,
+			ProjectItemID:       "PVTI_1",
+			PendingEditComment:  "The issue was updated",
+			Activity: &IssueActivity{
+				Author:          "octocat",
+				CreatedAt:       "2021-05-01T12:00:00Z",
+				Comments:        4,
+				LastCommenter:   "hubot",
+				LastCommentedAt: "2021-05-02T12:00:00Z",
+				Reactions:       &ReactionCounts{Total: 2, ThumbsUp: 1, Heart: 1},
+			},
+		},
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	issues := map[string]func(*GitHubIssue){
+		"all fields": func(*GitHubIssue) {},
+		"repo url":   func(g *GitHubIssue) { g.Spec.Repo = "git@github.com:arielireni/Issues-Example.git" },
+		"offset":     func(g *GitHubIssue) { g.Spec.ExpiresAt = "2022-01-01T02:00:00+02:00" },
+		"empty":      func(g *GitHubIssue) { *g = GitHubIssue{ObjectMeta: g.ObjectMeta} },
+	}
+	for name, mutate := range issues {
+		original := newConversionTestIssue()
+		mutate(original)
+		hub := &v1beta1.GitHubIssue{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("%s: converting to v1beta1 failed: %v", name, err)
+		}
+		converted := &GitHubIssue{}
+		if err := converted.ConvertFrom(hub); err != nil {
+			t.Fatalf("%s: converting from v1beta1 failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(original, converted) {
+			t.Errorf("%s: expected the round trip to keep\n%+v\nbut got\n%+v", name, original, converted)
+		}
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	hub := &v1beta1.GitHubIssue{}
+	original := newConversionTestIssue()
+	original.Spec.Repo = "https://github.com/arielireni/Issues-Example"
+	if err := original.ConvertTo(hub); err != nil {
+		t.Fatalf("Converting to v1beta1 failed: %v", err)
+	}
+
+	// The repo is structured and the timestamps are times
+	expected := v1beta1.RemoteRepository{Owner: "arielireni", Repository: "Issues-Example"}
+	if hub.Spec.Remote.RemoteRepository != expected || hub.Spec.Body != "description" || hub.Spec.Remote.CredentialsRef.Name != "team-token" {
+		t.Errorf("Expected the remote %+v and the body but got %+v", expected, hub.Spec)
+	}
+	if hub.Spec.ExpiresAt == nil || !hub.Spec.ExpiresAt.Equal(&metav1.Time{Time: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}) {
+		t.Errorf("Expected expiresAt to be parsed but got %v", hub.Spec.ExpiresAt)
+	}
+	if hub.Status.LastUpdated == nil || hub.Status.Remote == nil || hub.Status.Remote.Host != "github.example.com" {
+		t.Errorf("Expected lastUpdated and the remote in the status but got %+v", hub.Status)
+	}
+	// The url is kept for v1alpha1 clients, without touching the annotations of the original
+	if hub.Annotations[RepoAnnotation] != original.Spec.Repo || len(original.Annotations) != 1 {
+		t.Errorf("Expected the url to be kept in %s but got %v, %v", RepoAnnotation, hub.Annotations, original.Annotations)
+	}
+
+	// Once a v1beta1 client moves the issue to another repo the kept url is dropped
+	hub.Spec.Remote.Repository = "other"
+	converted := &GitHubIssue{}
+	if err := converted.ConvertFrom(hub); err != nil {
+		t.Fatalf("Converting from v1beta1 failed: %v", err)
+	}
+	if converted.Spec.Repo != "arielireni/other" || converted.Annotations[RepoAnnotation] != "" {
+		t.Errorf("Expected arielireni/other but got %q, %v", converted.Spec.Repo, converted.Annotations)
+	}
+}
+
+func TestConvertFromRoundTrip(t *testing.T) {
+	expiresAt := metav1.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	hub := &v1beta1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "default"},
+		Spec: v1beta1.GitHubIssueSpec{
+			Remote: v1beta1.RemoteReference{
+				RemoteRepository: v1beta1.RemoteRepository{Host: "github.example.com", Owner: "arielireni", Repository: "Issues-Example"},
+				Provider:         "github",
+			},
+			RemoteChangePolicy: "Recreate",
+			Title:              "title1",
+			Body:               "body",
+			ExpiresAt:          &expiresAt,
+		},
+		Status: v1beta1.GitHubIssueStatus{
+			Remote:      &v1beta1.RemoteRepository{Owner: "arielireni", Repository: "Issues-Example"},
+			Number:      3,
+			LastUpdated: &expiresAt,
+			Activity:    &v1beta1.IssueActivity{Author: "octocat", ClosedAt: &expiresAt, ClosedBy: "hubot"},
+		},
+	}
+	spoke := &GitHubIssue{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("Converting from v1beta1 failed: %v", err)
+	}
+	if spoke.Spec.Repo != "github.example.com/arielireni/Issues-Example" || spoke.Status.LastUpdateTimestamp != "2022-01-01T00:00:00Z" {
+		t.Errorf("Expected the repo and the timestamp as strings but got %+v", spoke)
+	}
+	converted := &v1beta1.GitHubIssue{}
+	if err := spoke.ConvertTo(converted); err != nil {
+		t.Fatalf("Converting to v1beta1 failed: %v", err)
+	}
+	if !reflect.DeepEqual(hub, converted) {
+		t.Errorf("Expected the round trip to keep\n%+v\nbut got\n%+v", hub, converted)
+	}
+}
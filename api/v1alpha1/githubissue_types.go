@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,16 +29,151 @@ type GitHubIssueSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Repo represents a clients repo url
+	// Repo represents a clients repo, as owner/repo, host/owner/repo for an enterprise server, or its https or ssh url.
+	// It defaults to the repo of RepositoryRef, or the default-repo annotation of the namespace
 	// Validation in the CRD level - an attempt to create a CRD with malformed 'repo' will fail
-	// +kubebuilder:validation:Pattern=^[a-zA-Z0-9\_.-]+/[a-zA-Z0-9\_.-]+$
-	Repo string `json:"repo"`
+	// +kubebuilder:validation:Pattern=`^(((https?|ssh|git)://)?([a-zA-Z0-9_.-]+@)?[a-zA-Z0-9.-]+(:[0-9]+)?[/:])?[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+/?$`
+	// +optional
+	Repo string `json:"repo,omitempty"`
+
+	// RepositoryRef represents the GitHubRepository providing the repo, credentials and labels the issue doesn't set
+	// +optional
+	RepositoryRef *RepositoryReference `json:"repositoryRef,omitempty"`
+
+	// Provider represents the issue tracker hosting the repo, only github is supported
+	// +kubebuilder:validation:Enum=github
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// CredentialsRef represents the Secret holding the token the real issue is managed with, instead of the
+	// token of the operator
+	// +optional
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+
+	// RepoChangePolicy represents what happens to the real issue when repo changes: Transfer moves it to the new
+	// repo, Recreate closes it with a link to a new issue created in the new repo
+	// +kubebuilder:validation:Enum=Transfer;Recreate
+	// +kubebuilder:default=Recreate
+	// +optional
+	RepoChangePolicy string `json:"repoChangePolicy,omitempty"`
 
 	// Title represents the title of the issue
 	Title string `json:"title"`
 
 	// Body represents the description of the issue
 	Description string `json:"description,omitempty"`
+
+	// Labels represents the names of the labels of the real issue, left as they are if unset
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// Assignees represents the logins of the users assigned to the real issue, left as they are if unset
+	// +optional
+	Assignees []string `json:"assignees,omitempty"`
+
+	// MilestoneRef represents the GitHubMilestone in the same namespace the real issue is in, left as it is if unset
+	// +optional
+	MilestoneRef *corev1.LocalObjectReference `json:"milestoneRef,omitempty"`
+
+	// CommentOnEdit makes the reconciler comment on the real issue what changed whenever it edits it
+	// +optional
+	CommentOnEdit bool `json:"commentOnEdit,omitempty"`
+
+	// State represents the desired state of the real issue, closed makes the reconciler close it
+	// +kubebuilder:validation:Enum=open;closed
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// ExpiresAt represents the time the real issue is closed at, in RFC3339
+	// +kubebuilder:validation:Format=date-time
+	// +optional
+	ExpiresAt string `json:"expiresAt,omitempty"`
+
+	// TTLAfterCreation represents how long after the object's creation the real issue is closed, e.g. 72h
+	// +optional
+	TTLAfterCreation *metav1.Duration `json:"ttlAfterCreation,omitempty"`
+
+	// TTLSecondsAfterClosed represents how long after the real issue was closed the object is deleted
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterClosed *int64 `json:"ttlSecondsAfterClosed,omitempty"`
+
+	// BlockedBy represents the GitHubIssue objects this issue depends on, rendered as links into its body
+	// +optional
+	BlockedBy []IssueReference `json:"blockedBy,omitempty"`
+
+	// CloseWhileBlocked keeps the real issue closed as not planned while any of BlockedBy is open,
+	// and reopens it once they are all closed
+	// +optional
+	CloseWhileBlocked bool `json:"closeWhileBlocked,omitempty"`
+
+	// ParentRef represents the tracking GitHubIssue of this issue, whose body lists its children as a task list
+	// +optional
+	ParentRef *IssueReference `json:"parentRef,omitempty"`
+
+	// Project represents the GitHub Projects (v2) board the real issue is added to, with its field values
+	// +optional
+	Project *ProjectSpec `json:"project,omitempty"`
+}
+
+// CredentialsReference refers to a key of a Secret in the namespace of the referring object
+type CredentialsReference struct {
+	// Name represents the name of the Secret
+	Name string `json:"name"`
+
+	// Key represents the key of the token in the Secret
+	// +kubebuilder:default=token
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// RepositoryReference refers to a GitHubRepository
+type RepositoryReference struct {
+	// Name represents the name of the GitHubRepository
+	Name string `json:"name"`
+
+	// Namespace represents the namespace of the GitHubRepository, defaults to the namespace of the referring object
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ProjectSpec refers to a GitHub Projects (v2) board, by its node id or by its owner and number
+type ProjectSpec struct {
+	// ID represents the node id of the project, e.g. PVT_kwDOAbc123
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// Owner represents the login of the user or organization owning the project, used with Number
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// Number represents the number of the project in the url of its owner, used with Owner
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Number int `json:"number,omitempty"`
+
+	// Status represents the option of the project's Status field
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// Iteration represents the title of an iteration of the project's iteration field, or @current
+	// for the iteration in progress
+	// +optional
+	Iteration string `json:"iteration,omitempty"`
+
+	// Fields represents the values of other fields by their name, an option for single select fields
+	// +optional
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// IssueReference refers to another GitHubIssue object
+type IssueReference struct {
+	// Name represents the name of the GitHubIssue
+	Name string `json:"name"`
+
+	// Namespace represents the namespace of the GitHubIssue, defaults to the namespace of the referring object
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // GitHubIssueStatus defines the observed state of GitHubIssue
@@ -50,10 +186,106 @@ type GitHubIssueStatus struct {
 
 	// LastUpdateTimestamp represents a timestamp of the last time the state was updated
 	LastUpdateTimestamp string `json:"updated_at,omitempty"`
+
+	// Number represents the number of the real clients issue
+	Number int `json:"number,omitempty"`
+
+	// Repo represents the repo of the real clients issue, a change of spec.repo is detected against it
+	Repo string `json:"repo,omitempty"`
+
+	// Conditions represent the latest observations of the issue's state, such as whether it is synced
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Plan represents what the operator would do to the real issue, set only in dry-run mode
+	Plan *IssuePlan `json:"plan,omitempty"`
+
+	// Children represents the number of GitHubIssue objects whose parentRef is this issue
+	Children int `json:"children,omitempty"`
+
+	// Completion represents the percentage of the children whose real issue is closed, set only for parents
+	Completion *int32 `json:"completion,omitempty"`
+
+	// ProjectItemID represents the node id of the real issue's item on the project of spec.project
+	ProjectItemID string `json:"projectItemId,omitempty"`
+
+	// Activity represents the conversation on the real issue
+	Activity *IssueActivity `json:"activity,omitempty"`
+
+	// PendingEditComment represents the edit comment that failed to be posted after its edit, posted on the next sync
+	PendingEditComment string `json:"pendingEditComment,omitempty"`
+}
+
+// IssueActivity summarizes who opened, closed and discussed the real issue
+type IssueActivity struct {
+	// Author represents the login of the user who opened the issue
+	Author string `json:"author,omitempty"`
+
+	// CreatedAt represents the time the issue was opened
+	CreatedAt string `json:"createdAt,omitempty"`
+
+	// ClosedAt represents the time the issue was closed, set only while it is closed
+	ClosedAt string `json:"closedAt,omitempty"`
+
+	// ClosedBy represents the login of the user who closed the issue, set only while it is closed
+	ClosedBy string `json:"closedBy,omitempty"`
+
+	// Comments represents the number of comments on the issue
+	Comments int `json:"comments,omitempty"`
+
+	// LastCommenter represents the login of the author of the latest comment
+	LastCommenter string `json:"lastCommenter,omitempty"`
+
+	// LastCommentedAt represents the time of the latest comment
+	LastCommentedAt string `json:"lastCommentedAt,omitempty"`
+
+	// Reactions represents the number of reactions to the issue by their content
+	Reactions *ReactionCounts `json:"reactions,omitempty"`
+}
+
+// ReactionCounts counts the reactions to an issue
+type ReactionCounts struct {
+	Total      int `json:"total,omitempty"`
+	ThumbsUp   int `json:"thumbsUp,omitempty"`
+	ThumbsDown int `json:"thumbsDown,omitempty"`
+	Laugh      int `json:"laugh,omitempty"`
+	Hooray     int `json:"hooray,omitempty"`
+	Confused   int `json:"confused,omitempty"`
+	Heart      int `json:"heart,omitempty"`
+	Rocket     int `json:"rocket,omitempty"`
+	Eyes       int `json:"eyes,omitempty"`
+}
+
+// IssuePlan describes the mutation the reconciler would perform on the real issue
+type IssuePlan struct {
+	// Action represents the planned operation
+	// +kubebuilder:validation:Enum=create;edit;close;reopen;no-op
+	Action string `json:"action"`
+
+	// Changes represents the fields that would be changed by the action
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange describes a single field difference between the desired and the real issue
+type FieldChange struct {
+	// Field represents the name of the changed field
+	Field string `json:"field"`
+
+	// From represents the current value of the field in the real issue
+	From string `json:"from,omitempty"`
+
+	// To represents the desired value of the field
+	To string `json:"to,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Repo",type=string,JSONPath=`.spec.repo`
+//+kubebuilder:printcolumn:name="Number",type=integer,JSONPath=`.status.number`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Comments",type=integer,JSONPath=`.status.activity.comments`,priority=1
+//+kubebuilder:printcolumn:name="Completion",type=integer,JSONPath=`.status.completion`,priority=1
+//+kubebuilder:printcolumn:name="Blocked",type=string,JSONPath=`.status.conditions[?(@.type=="Blocked")].status`,priority=1
 
 // GitHubIssue is the Schema for the githubissues API
 type GitHubIssue struct {
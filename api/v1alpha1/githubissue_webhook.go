@@ -0,0 +1,347 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"github.com/arielireni/example-operator/pkg/reporef"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// AllowedReposAnnotation on a Namespace restricts the repos of its GitHubIssue objects to a comma separated list
+// of owner/repo patterns, such as arielireni/* (host/owner/repo for an enterprise server)
+const AllowedReposAnnotation = "example.training.redhat.com/allowed-repos"
+
+// Annotations of a Namespace holding the defaults of the GitHubIssue objects created in it, the labels and
+// assignees are comma separated lists and the credentials the name of a Secret with a token key
+const (
+	DefaultRepoAnnotation        = "example.training.redhat.com/default-repo"
+	DefaultLabelsAnnotation      = "example.training.redhat.com/default-labels"
+	DefaultAssigneesAnnotation   = "example.training.redhat.com/default-assignees"
+	DefaultProviderAnnotation    = "example.training.redhat.com/default-provider"
+	DefaultCredentialsAnnotation = "example.training.redhat.com/default-credentials"
+)
+
+// ProviderGitHub is the provider of the repos on github.com and GitHub Enterprise Server
+const ProviderGitHub = "github"
+
+// Limits of GitHub on the fields of an issue
+const (
+	MaxTitleLength       = 256
+	MaxBodyLength        = 65536
+	MaxLabelLength       = 50
+	MaxAssigneeLength    = 39
+	MaxAssigneesPerIssue = 10
+)
+
+// loginRegexp matches a GitHub login, alphanumeric with single hyphens between the characters
+var loginRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+
+// log is for logging in this package.
+var githubissuelog = logf.Log.WithName("githubissue-resource")
+
+// webhookReader reads the Namespace of a GitHubIssue for its defaults and allowed repos, it's set by
+// SetupWebhookWithManager
+var webhookReader client.Reader
+
+func (r *GitHubIssue) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrepositories,verbs=get
+//+kubebuilder:webhook:path=/mutate-example-training-redhat-com-v1alpha1-githubissue,mutating=true,failurePolicy=fail,sideEffects=None,groups=example.training.redhat.com,resources=githubissues,verbs=create;update,versions=v1alpha1,name=mgithubissue.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &GitHubIssue{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *GitHubIssue) Default() {
+	githubissuelog.Info("default", "name", r.Name)
+	annotations := map[string]string{}
+	if webhookReader != nil {
+		namespace := corev1.Namespace{}
+		if err := webhookReader.Get(context.Background(), types.NamespacedName{Name: r.Namespace}, &namespace); err != nil {
+			githubissuelog.Error(err, "failed to get the defaults of the namespace", "namespace", r.Namespace)
+		}
+		annotations = namespace.Annotations
+	}
+	r.DefaultFrom(annotations)
+}
+
+// DefaultFrom fills the fields missing from the GitHubIssue with the defaults annotated on its Namespace
+func (r *GitHubIssue) DefaultFrom(annotations map[string]string) {
+	// The repo, labels and credentials of an issue referencing a GitHubRepository are its defaults
+	if r.Spec.RepositoryRef == nil {
+		if r.Spec.Repo == "" {
+			r.Spec.Repo = annotations[DefaultRepoAnnotation]
+		}
+		if r.Spec.Labels == nil {
+			r.Spec.Labels = splitList(annotations[DefaultLabelsAnnotation])
+		}
+		if r.Spec.CredentialsRef == nil && annotations[DefaultCredentialsAnnotation] != "" {
+			r.Spec.CredentialsRef = &CredentialsReference{Name: annotations[DefaultCredentialsAnnotation]}
+		}
+	}
+	if r.Spec.Assignees == nil {
+		r.Spec.Assignees = splitList(annotations[DefaultAssigneesAnnotation])
+	}
+	if r.Spec.Provider == "" {
+		r.Spec.Provider = annotations[DefaultProviderAnnotation]
+		if r.Spec.Provider == "" {
+			r.Spec.Provider = ProviderGitHub
+		}
+	}
+	if r.Spec.CredentialsRef != nil && r.Spec.CredentialsRef.Key == "" {
+		r.Spec.CredentialsRef.Key = "token"
+	}
+}
+
+// splitList returns the trimmed items of a comma separated list, nil if it has none
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+//+kubebuilder:webhook:path=/validate-example-training-redhat-com-v1alpha1-githubissue,mutating=false,failurePolicy=fail,sideEffects=None,groups=example.training.redhat.com,resources=githubissues,verbs=create;update,versions=v1alpha1,name=vgithubissue.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &GitHubIssue{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *GitHubIssue) ValidateCreate() error {
+	githubissuelog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *GitHubIssue) ValidateUpdate(old runtime.Object) error {
+	githubissuelog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*GitHubIssue))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *GitHubIssue) ValidateDelete() error {
+	return nil
+}
+
+// validate checks the spec against the limits of GitHub and the repos allowed in the namespace, and that the
+// title isn't changed once the issue is created, as the issue is found by its title
+func (r *GitHubIssue) validate(old *GitHubIssue) error {
+	// Objects being deleted only need their finalizer removed
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	spec := field.NewPath("spec")
+	var allErrs field.ErrorList
+
+	title := strings.TrimSpace(r.Spec.Title)
+	switch {
+	case title == "":
+		allErrs = append(allErrs, field.Required(spec.Child("title"), "the title of the issue can't be empty"))
+	case utf8.RuneCountInString(r.Spec.Title) > MaxTitleLength:
+		allErrs = append(allErrs, field.TooLong(spec.Child("title"), r.Spec.Title, MaxTitleLength))
+	}
+	if n := utf8.RuneCountInString(r.Spec.Description); n > MaxBodyLength {
+		allErrs = append(allErrs, field.Invalid(spec.Child("description"), fmt.Sprintf("<%d characters>", n),
+			fmt.Sprintf("must have at most %d characters, the limit of GitHub", MaxBodyLength)))
+	}
+	allErrs = append(allErrs, validateLabels(spec.Child("labels"), r.Spec.Labels)...)
+	allErrs = append(allErrs, validateAssignees(spec.Child("assignees"), r.Spec.Assignees)...)
+
+	if r.Spec.Repo == "" {
+		if r.Spec.RepositoryRef == nil {
+			allErrs = append(allErrs, field.Required(spec.Child("repo"), "set the repo, the repositoryRef, or the "+DefaultRepoAnnotation+" annotation of the namespace"))
+		} else if old == nil || old.Spec.Repo != "" || !reflect.DeepEqual(old.Spec.RepositoryRef, r.Spec.RepositoryRef) {
+			if err := r.validateAllowedRepository(); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+	} else if ref, err := reporef.Parse(r.Spec.Repo); err != nil {
+		allErrs = append(allErrs, field.Invalid(spec.Child("repo"), r.Spec.Repo, err.Error()))
+	} else if old == nil || old.Spec.Repo != r.Spec.Repo {
+		if err := r.validateAllowedRepo(ref); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	if err := r.validateTransfer(old); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if old != nil && old.Status.Number != 0 && old.Spec.Title != r.Spec.Title {
+		allErrs = append(allErrs, field.Forbidden(spec.Child("title"),
+			fmt.Sprintf("the title can't be changed once issue #%d is created", old.Status.Number)))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: GroupVersion.Group, Kind: "GitHubIssue"}, r.Name, allErrs)
+}
+
+func validateLabels(path *field.Path, labels []string) field.ErrorList {
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+	for i, label := range labels {
+		switch {
+		case strings.TrimSpace(label) == "":
+			allErrs = append(allErrs, field.Required(path.Index(i), "a label name can't be empty"))
+		case utf8.RuneCountInString(label) > MaxLabelLength:
+			allErrs = append(allErrs, field.TooLong(path.Index(i), label, MaxLabelLength))
+		case strings.Contains(label, ","):
+			allErrs = append(allErrs, field.Invalid(path.Index(i), label, "a label name can't contain commas"))
+		case seen[strings.ToLower(label)]:
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), label))
+		}
+		seen[strings.ToLower(label)] = true
+	}
+	return allErrs
+}
+
+func validateAssignees(path *field.Path, assignees []string) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(assignees) > MaxAssigneesPerIssue {
+		allErrs = append(allErrs, field.TooMany(path, len(assignees), MaxAssigneesPerIssue))
+	}
+	seen := map[string]bool{}
+	for i, login := range assignees {
+		switch {
+		case len(login) > MaxAssigneeLength || !loginRegexp.MatchString(login):
+			allErrs = append(allErrs, field.Invalid(path.Index(i), login, "must be a GitHub login"))
+		case seen[strings.ToLower(login)]:
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), login))
+		}
+		seen[strings.ToLower(login)] = true
+	}
+	return allErrs
+}
+
+// validateTransfer checks that a change of repo moving the real issue with the Transfer policy stays with the same
+// owner, GitHub transfers issues between the repos of an owner only
+func (r *GitHubIssue) validateTransfer(old *GitHubIssue) *field.Error {
+	if old == nil || old.Status.Number == 0 || old.Status.Repo == "" || r.Spec.Repo == "" || r.Spec.RepoChangePolicy != "Transfer" {
+		return nil
+	}
+	previous, err := reporef.Parse(old.Status.Repo)
+	if err != nil {
+		return nil
+	}
+	current, err := reporef.Parse(r.Spec.Repo)
+	if err != nil || previous.Key() == current.Key() {
+		return nil
+	}
+	if previous.Host != current.Host || !strings.EqualFold(previous.Owner, current.Owner) {
+		return field.Forbidden(field.NewPath("spec", "repoChangePolicy"), fmt.Sprintf("issue #%d can't be transferred from %s to %s, "+
+			"GitHub transfers issues between the repos of the same owner only, use Recreate", old.Status.Number, previous, current))
+	}
+	return nil
+}
+
+// validateAllowedRepo checks the repo against the AllowedReposAnnotation of the namespace, if it has any
+func (r *GitHubIssue) validateAllowedRepo(ref reporef.Ref) *field.Error {
+	repoPath := field.NewPath("spec", "repo")
+	allowed, ok, err := r.allowedRepos()
+	if err != nil {
+		return field.InternalError(repoPath, err)
+	}
+	if !ok || repoAllowed(ref, allowed) {
+		return nil
+	}
+	return field.Forbidden(repoPath, fmt.Sprintf("repo %s is not allowed in namespace %s, the allowed repos are %q", ref, r.Namespace, allowed))
+}
+
+// validateAllowedRepository checks the repo of the GitHubRepository of spec.repositoryRef against the
+// AllowedReposAnnotation of the namespace, if it has any
+func (r *GitHubIssue) validateAllowedRepository() *field.Error {
+	refPath := field.NewPath("spec", "repositoryRef")
+	allowed, ok, err := r.allowedRepos()
+	if err != nil {
+		return field.InternalError(refPath, err)
+	}
+	if !ok {
+		return nil
+	}
+	key := types.NamespacedName{Namespace: r.Spec.RepositoryRef.Namespace, Name: r.Spec.RepositoryRef.Name}
+	if key.Namespace == "" {
+		key.Namespace = r.Namespace
+	}
+	ghRepo := GitHubRepository{}
+	if err := webhookReader.Get(context.Background(), key, &ghRepo); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.NotFound(refPath, key.String())
+		}
+		return field.InternalError(refPath, fmt.Errorf("getting GitHubRepository %s: %v", key, err))
+	}
+	ref, err := reporef.Parse(ghRepo.Spec.Repo)
+	if err != nil {
+		return field.Invalid(refPath, key.String(), fmt.Sprintf("the repo of the GitHubRepository is malformed: %v", err))
+	}
+	if ref.Host == "" {
+		ref.Host = ghRepo.Spec.Host
+	}
+	if repoAllowed(ref, allowed) {
+		return nil
+	}
+	return field.Forbidden(refPath, fmt.Sprintf("repo %s of GitHubRepository %s is not allowed in namespace %s, the allowed repos are %q", ref, key, r.Namespace, allowed))
+}
+
+// allowedRepos returns the AllowedReposAnnotation of the namespace, and false if it has none
+func (r *GitHubIssue) allowedRepos() (string, bool, error) {
+	if webhookReader == nil {
+		return "", false, nil
+	}
+	namespace := corev1.Namespace{}
+	if err := webhookReader.Get(context.Background(), types.NamespacedName{Name: r.Namespace}, &namespace); err != nil {
+		return "", false, fmt.Errorf("getting namespace %s: %v", r.Namespace, err)
+	}
+	allowed, ok := namespace.Annotations[AllowedReposAnnotation]
+	return allowed, ok, nil
+}
+
+// repoAllowed returns true if the repo matches any of the comma separated patterns
+func repoAllowed(ref reporef.Ref, patterns string) bool {
+	key := strings.TrimPrefix(ref.Key(), "/")
+	for _, pattern := range strings.Split(patterns, ",") {
+		if matched, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), key); matched {
+			return true
+		}
+	}
+	return false
+}
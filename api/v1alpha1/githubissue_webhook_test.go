@@ -0,0 +1,192 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newWebhookTestIssue returns a valid GitHubIssue in a namespace allowing the repos of arielireni only
+func newWebhookTestIssue(t *testing.T) *GitHubIssue {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{AllowedReposAnnotation: "arielireni/*, github.example.com/platform/issues"},
+	}}
+	webhookReader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()
+	t.Cleanup(func() { webhookReader = nil })
+	return &GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "team"},
+		Spec: GitHubIssueSpec{
+			Repo:      "arielireni/Issues-Example",
+			Title:     "title1",
+			Labels:    []string{"bug", "good first issue"},
+			Assignees: []string{"octo-cat"},
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	ghIssue := newWebhookTestIssue(t)
+	if err := ghIssue.ValidateCreate(); err != nil {
+		t.Fatalf("Expected a valid issue but got %v", err)
+	}
+
+	invalid := map[string]func(*GitHubIssue){
+		"spec.title":        func(g *GitHubIssue) { g.Spec.Title = "  " },
+		"spec.description":  func(g *GitHubIssue) { g.Spec.Description = strings.Repeat("a", MaxBodyLength+1) },
+		"spec.labels[1]":    func(g *GitHubIssue) { g.Spec.Labels = []string{"bug", "Bug"} },
+		"spec.assignees[0]": func(g *GitHubIssue) { g.Spec.Assignees = []string{"-octocat"} },
+		"spec.repo":         func(g *GitHubIssue) { g.Spec.Repo = "kubernetes/kubernetes" },
+	}
+	for path, mutate := range invalid {
+		ghIssue := newWebhookTestIssue(t)
+		mutate(ghIssue)
+		if err := ghIssue.ValidateCreate(); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected %s to be rejected but got %v", path, err)
+		}
+	}
+
+	// Enterprise repos match the patterns by their host
+	ghIssue = newWebhookTestIssue(t)
+	ghIssue.Spec.Repo = "https://github.example.com/platform/issues"
+	if err := ghIssue.ValidateCreate(); err != nil {
+		t.Errorf("Expected the enterprise repo to be allowed but got %v", err)
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	old := newWebhookTestIssue(t)
+
+	// The title can be changed until the issue is created
+	updated := old.DeepCopy()
+	updated.Spec.Title = "title2"
+	if err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("Expected the title to be changeable but got %v", err)
+	}
+
+	// But not once its number is recorded
+	old.Status.Number = 3
+	if err := updated.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "spec.title") {
+		t.Errorf("Expected the title change to be rejected but got %v", err)
+	}
+
+	// A repo that was allowed when the object was created stays valid
+	old = newWebhookTestIssue(t)
+	old.Spec.Repo = "kubernetes/kubernetes"
+	updated = old.DeepCopy()
+	updated.Spec.Description = "body"
+	if err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("Expected an unchanged repo to be accepted but got %v", err)
+	}
+
+	// An issue is transferred to a repo of the same owner only
+	old = newWebhookTestIssue(t)
+	old.Status.Number, old.Status.Repo = 3, "arielireni/Issues-Example"
+	updated = old.DeepCopy()
+	updated.Spec.RepoChangePolicy = "Transfer"
+	updated.Spec.Repo = "arielireni/Other"
+	if err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("Expected a transfer to a repo of the owner to be accepted but got %v", err)
+	}
+	updated.Spec.Repo = "github.example.com/platform/issues"
+	if err := updated.ValidateUpdate(old); err == nil || !strings.Contains(err.Error(), "spec.repoChangePolicy") {
+		t.Errorf("Expected a transfer to another owner to be rejected but got %v", err)
+	}
+	updated.Spec.RepoChangePolicy = "Recreate"
+	if err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("Expected a recreate in another owner's repo to be accepted but got %v", err)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "team",
+		Annotations: map[string]string{
+			DefaultRepoAnnotation:        "arielireni/Issues-Example",
+			DefaultLabelsAnnotation:      "triage, team/platform",
+			DefaultAssigneesAnnotation:   "octocat",
+			DefaultCredentialsAnnotation: "team-token",
+		},
+	}}
+	webhookReader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()
+	t.Cleanup(func() { webhookReader = nil })
+
+	// An issue with only a title gets the defaults of its namespace
+	ghIssue := &GitHubIssue{ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "team"}, Spec: GitHubIssueSpec{Title: "title1"}}
+	ghIssue.Default()
+	if ghIssue.Spec.Repo != "arielireni/Issues-Example" || ghIssue.Spec.Provider != ProviderGitHub {
+		t.Errorf("Expected the default repo and provider but got %+v", ghIssue.Spec)
+	}
+	if len(ghIssue.Spec.Labels) != 2 || ghIssue.Spec.Labels[1] != "team/platform" || len(ghIssue.Spec.Assignees) != 1 {
+		t.Errorf("Expected the default labels and assignees but got %v, %v", ghIssue.Spec.Labels, ghIssue.Spec.Assignees)
+	}
+	if ref := ghIssue.Spec.CredentialsRef; ref == nil || ref.Name != "team-token" || ref.Key != "token" {
+		t.Errorf("Expected the default credentials but got %+v", ref)
+	}
+
+	// The fields set in the spec are kept, an empty list included
+	ghIssue = &GitHubIssue{ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "team"}, Spec: GitHubIssueSpec{
+		Title:          "title1",
+		Repo:           "arielireni/other",
+		Labels:         []string{},
+		CredentialsRef: &CredentialsReference{Name: "own-token", Key: "pat"},
+	}}
+	ghIssue.Default()
+	if ghIssue.Spec.Repo != "arielireni/other" || len(ghIssue.Spec.Labels) != 0 || ghIssue.Spec.CredentialsRef.Name != "own-token" || ghIssue.Spec.CredentialsRef.Key != "pat" {
+		t.Errorf("Expected the spec to be kept but got %+v", ghIssue.Spec)
+	}
+
+	// Without a default repo the issue is rejected
+	ghIssue = &GitHubIssue{ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "other"}, Spec: GitHubIssueSpec{Title: "title1"}}
+	ghIssue.Default()
+	if err := ghIssue.ValidateCreate(); err == nil || !strings.Contains(err.Error(), "spec.repo") {
+		t.Errorf("Expected the missing repo to be rejected but got %v", err)
+	}
+
+	// Unless it references a GitHubRepository, whose defaults win over the namespace's
+	ghIssue = &GitHubIssue{ObjectMeta: metav1.ObjectMeta{Name: "issue1", Namespace: "team"}, Spec: GitHubIssueSpec{
+		Title:         "title1",
+		RepositoryRef: &RepositoryReference{Name: "issues"},
+	}}
+	ghIssue.Default()
+	if ghIssue.Spec.Repo != "" || ghIssue.Spec.Labels != nil || ghIssue.Spec.CredentialsRef != nil {
+		t.Errorf("Expected the defaults to be left to the repository but got %+v", ghIssue.Spec)
+	}
+	if err := ghIssue.ValidateCreate(); err != nil {
+		t.Errorf("Expected the issue of a repository to be valid but got %v", err)
+	}
+}
+
+func TestValidateRepositoryRef(t *testing.T) {
+	// Given GitHubRepository objects of an allowed enterprise repo and of another repo
+	ghIssue := newWebhookTestIssue(t)
+	ghIssue.Spec.Repo = ""
+	ghIssue.Spec.RepositoryRef = &RepositoryReference{Name: "platform", Namespace: "platform"}
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	AddToScheme(s)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "team",
+		Annotations: map[string]string{AllowedReposAnnotation: "arielireni/*, github.example.com/platform/issues"},
+	}}
+	webhookReader = fake.NewClientBuilder().WithScheme(s).WithObjects(namespace,
+		&GitHubRepository{ObjectMeta: metav1.ObjectMeta{Name: "platform", Namespace: "platform"}, Spec: GitHubRepositorySpec{Repo: "platform/issues", Host: "github.example.com"}},
+		&GitHubRepository{ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "team"}, Spec: GitHubRepositorySpec{Repo: "kubernetes/kubernetes"}},
+	).Build()
+
+	// Then the repo of the repository is checked against the allowed repos of the namespace
+	if err := ghIssue.ValidateCreate(); err != nil {
+		t.Errorf("Expected the repo of the repository to be allowed but got %v", err)
+	}
+	for _, name := range []string{"kubernetes", "missing"} {
+		ghIssue.Spec.RepositoryRef = &RepositoryReference{Name: name}
+		if err := ghIssue.ValidateCreate(); err == nil || !strings.Contains(err.Error(), "spec.repositoryRef") {
+			t.Errorf("Expected the repository %s to be rejected but got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubIssueCommentSpec defines the desired state of GitHubIssueComment
+type GitHubIssueCommentSpec struct {
+	// IssueRef represents the GitHubIssue in the same namespace whose real issue is commented on
+	IssueRef corev1.LocalObjectReference `json:"issueRef"`
+
+	// Body represents the body of the comment, edited on GitHub whenever it changes
+	// +kubebuilder:validation:MinLength=1
+	Body string `json:"body"`
+
+	// DeletionPolicy represents what happens to the comment when the object is deleted, it is deleted by default
+	// or hidden as outdated with Minimize
+	// +kubebuilder:validation:Enum=Delete;Minimize
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// GitHubIssueCommentStatus defines the observed state of GitHubIssueComment
+type GitHubIssueCommentStatus struct {
+	// CommentID represents the id of the real comment
+	CommentID int64 `json:"commentId,omitempty"`
+
+	// NodeID represents the GraphQL node id of the real comment
+	NodeID string `json:"nodeId,omitempty"`
+
+	// URL represents the link to the real comment
+	URL string `json:"url,omitempty"`
+
+	// Repo represents the repo of the real comment, kept to delete it after the GitHubIssue is gone
+	Repo string `json:"repo,omitempty"`
+
+	// IssueNumber represents the number of the real issue the comment is on
+	IssueNumber int `json:"issueNumber,omitempty"`
+
+	// LastUpdateTimestamp represents a timestamp of the last time the comment was updated
+	LastUpdateTimestamp string `json:"updated_at,omitempty"`
+
+	// Conditions represent the latest observations of the comment, such as whether it is synced
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Issue",type=string,JSONPath=`.spec.issueRef.name`
+//+kubebuilder:printcolumn:name="Comment",type=integer,JSONPath=`.status.commentId`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+
+// GitHubIssueComment is the Schema for the githubissuecomments API
+type GitHubIssueComment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubIssueCommentSpec   `json:"spec,omitempty"`
+	Status GitHubIssueCommentStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubIssueCommentList contains a list of GitHubIssueComment
+type GitHubIssueCommentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubIssueComment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubIssueComment{}, &GitHubIssueCommentList{})
+}
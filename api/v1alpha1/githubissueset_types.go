@@ -0,0 +1,121 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubIssueSetSpec defines the desired state of GitHubIssueSet
+type GitHubIssueSetSpec struct {
+	// Template represents the GitHubIssue created for every generated element.
+	// Its fields are Go templates, rendered with .Key, .Repo and .Values of the element
+	Template GitHubIssueTemplate `json:"template"`
+
+	// Generator represents the source of the elements, one GitHubIssue is created per element
+	Generator IssueSetGenerator `json:"generator"`
+}
+
+// GitHubIssueTemplate describes the GitHubIssue objects created by a GitHubIssueSet
+type GitHubIssueTemplate struct {
+	// Labels represents labels added to every created GitHubIssue
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Repo represents the repo of the issue, defaults to the element's repo
+	Repo string `json:"repo,omitempty"`
+
+	// Title represents the title of the issue
+	Title string `json:"title"`
+
+	// Description represents the description of the issue
+	Description string `json:"description,omitempty"`
+}
+
+// IssueSetGenerator generates the elements of a GitHubIssueSet, exactly one of its fields should be set
+type IssueSetGenerator struct {
+	// List represents a static list of elements
+	List []IssueSetElement `json:"list,omitempty"`
+
+	// Repos represents a list of repos, each one is an element keyed by the repo
+	Repos []string `json:"repos,omitempty"`
+
+	// ConfigMaps selects ConfigMaps in the namespace of the set, each one is an element
+	// keyed by its name, with its data as values
+	ConfigMaps *metav1.LabelSelector `json:"configMaps,omitempty"`
+}
+
+// IssueSetElement is a single element of a static list generator
+type IssueSetElement struct {
+	// Key represents the unique identity of the element in the set
+	Key string `json:"key"`
+
+	// Repo represents the repo of the element
+	Repo string `json:"repo,omitempty"`
+
+	// Values represents values available to the template
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// GitHubIssueSetStatus defines the observed state of GitHubIssueSet
+type GitHubIssueSetStatus struct {
+	// Issues represents the number of GitHubIssue objects owned by the set
+	Issues int `json:"issues"`
+
+	// Open represents the number of owned issues whose real issue is open
+	Open int `json:"open"`
+
+	// Closed represents the number of owned issues whose real issue is closed
+	Closed int `json:"closed"`
+
+	// Synced represents the number of owned issues in sync with their real issue
+	Synced int `json:"synced"`
+
+	// Failed represents the number of owned issues that failed to sync
+	Failed int `json:"failed"`
+
+	// Conditions represent the latest observations of the set, such as whether its elements were generated
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Issues",type=integer,JSONPath=`.status.issues`
+//+kubebuilder:printcolumn:name="Open",type=integer,JSONPath=`.status.open`
+//+kubebuilder:printcolumn:name="Closed",type=integer,JSONPath=`.status.closed`
+//+kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`
+
+// GitHubIssueSet is the Schema for the githubissuesets API
+type GitHubIssueSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubIssueSetSpec   `json:"spec,omitempty"`
+	Status GitHubIssueSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubIssueSetList contains a list of GitHubIssueSet
+type GitHubIssueSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubIssueSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubIssueSet{}, &GitHubIssueSetList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubLabelSpec defines the desired state of GitHubLabel
+type GitHubLabelSpec struct {
+	// Repo represents the repo of the label, as owner/repo, host/owner/repo for an enterprise server, or its https
+	// or ssh url. It's the repo of the repositoryRef if not set
+	// +kubebuilder:validation:Pattern=`^(((https?|ssh|git)://)?([a-zA-Z0-9_.-]+@)?[a-zA-Z0-9.-]+(:[0-9]+)?[/:])?[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+/?$`
+	// +optional
+	Repo string `json:"repo,omitempty"`
+
+	// RepositoryRef represents the GitHubRepository the label belongs to, whose repo and credentials are used
+	// +optional
+	RepositoryRef *RepositoryReference `json:"repositoryRef,omitempty"`
+
+	// CredentialsRef represents the Secret in the namespace of the label holding the token it's managed with,
+	// instead of the token of its GitHubRepository or of the operator
+	// +optional
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+
+	// Name represents the name of the label, the label is renamed on GitHub when it changes
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=50
+	Name string `json:"name"`
+
+	// Color represents the hexadecimal color of the label, e.g. d73a4a or #d73a4a
+	// +kubebuilder:validation:Pattern=`^#?[0-9a-fA-F]{6}$`
+	Color string `json:"color"`
+
+	// Description represents a short description of the label
+	// +kubebuilder:validation:MaxLength=100
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// ConflictPolicy represents what happens when a label with the same name and other values already exists in
+	// the repo: the conflict is reported in the status by default, or the label is taken over with Overwrite
+	// +kubebuilder:validation:Enum=Report;Overwrite
+	// +kubebuilder:default=Report
+	// +optional
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+
+	// DeletionPolicy represents what happens to the label when the object is deleted, it is left in the repo by
+	// default or deleted, from the issues too, with Delete
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	// +kubebuilder:default=Orphan
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// GitHubLabelStatus defines the observed state of GitHubLabel
+type GitHubLabelStatus struct {
+	// Repo represents the repo of the managed label
+	Repo string `json:"repo,omitempty"`
+
+	// Name represents the name of the managed label on GitHub, kept to rename or delete it
+	Name string `json:"name,omitempty"`
+
+	// Conditions represent the latest observations of the label, such as whether it is synced or conflicts with
+	// another label
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Label",type=string,JSONPath=`.spec.name`
+//+kubebuilder:printcolumn:name="Repo",type=string,JSONPath=`.status.repo`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].reason`
+
+// GitHubLabel is the Schema for the githublabels API
+type GitHubLabel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubLabelSpec   `json:"spec,omitempty"`
+	Status GitHubLabelStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubLabelList contains a list of GitHubLabel
+type GitHubLabelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubLabel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubLabel{}, &GitHubLabelList{})
+}
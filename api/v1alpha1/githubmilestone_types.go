@@ -0,0 +1,125 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubMilestoneSpec defines the desired state of GitHubMilestone
+type GitHubMilestoneSpec struct {
+	// Repo represents the repo of the milestone, as owner/repo, host/owner/repo for an enterprise server, or its
+	// https or ssh url. It's the repo of the repositoryRef if not set
+	// +kubebuilder:validation:Pattern=`^(((https?|ssh|git)://)?([a-zA-Z0-9_.-]+@)?[a-zA-Z0-9.-]+(:[0-9]+)?[/:])?[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+/?$`
+	// +optional
+	Repo string `json:"repo,omitempty"`
+
+	// RepositoryRef represents the GitHubRepository the milestone belongs to, whose repo and credentials are used
+	// +optional
+	RepositoryRef *RepositoryReference `json:"repositoryRef,omitempty"`
+
+	// CredentialsRef represents the Secret in the namespace of the milestone holding the token it's managed with,
+	// instead of the token of its GitHubRepository or of the operator
+	// +optional
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+
+	// Title represents the title of the milestone, an existing milestone with the title is taken over if it
+	// matches the spec, see ConflictPolicy. The milestone is renamed on GitHub when it changes
+	// +kubebuilder:validation:MinLength=1
+	Title string `json:"title"`
+
+	// Description represents the description of the milestone
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// DueOn represents the due date of the milestone as YYYY-MM-DD
+	// +kubebuilder:validation:Pattern=`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	// +optional
+	DueOn string `json:"dueOn,omitempty"`
+
+	// State represents the state of the milestone, open or closed
+	// +kubebuilder:validation:Enum=open;closed
+	// +kubebuilder:default=open
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// ConflictPolicy represents what happens when a milestone with the same title and other values already exists
+	// in the repo: the conflict is reported in the status by default, or the milestone is taken over with Overwrite
+	// +kubebuilder:validation:Enum=Report;Overwrite
+	// +kubebuilder:default=Report
+	// +optional
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+
+	// DeletionPolicy represents what happens to the milestone when the object is deleted, it is left in the repo
+	// by default or deleted, leaving its issues without a milestone, with Delete
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	// +kubebuilder:default=Orphan
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// GitHubMilestoneStatus defines the observed state of GitHubMilestone
+type GitHubMilestoneStatus struct {
+	// Repo represents the repo of the managed milestone
+	Repo string `json:"repo,omitempty"`
+
+	// Number represents the number of the milestone, set on the issues referencing it
+	Number int `json:"number,omitempty"`
+
+	// URL represents the link to the milestone
+	URL string `json:"url,omitempty"`
+
+	// OpenIssues represents the number of open issues in the milestone
+	OpenIssues int `json:"openIssues,omitempty"`
+
+	// ClosedIssues represents the number of closed issues in the milestone
+	ClosedIssues int `json:"closedIssues,omitempty"`
+
+	// Conditions represent the latest observations of the milestone, such as whether it is synced
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Title",type=string,JSONPath=`.spec.title`
+//+kubebuilder:printcolumn:name="Due",type=string,JSONPath=`.spec.dueOn`
+//+kubebuilder:printcolumn:name="Number",type=integer,JSONPath=`.status.number`
+//+kubebuilder:printcolumn:name="Open",type=integer,JSONPath=`.status.openIssues`
+//+kubebuilder:printcolumn:name="Closed",type=integer,JSONPath=`.status.closedIssues`
+//+kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+
+// GitHubMilestone is the Schema for the githubmilestones API
+type GitHubMilestone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubMilestoneSpec   `json:"spec,omitempty"`
+	Status GitHubMilestoneStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubMilestoneList contains a list of GitHubMilestone
+type GitHubMilestoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubMilestone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubMilestone{}, &GitHubMilestoneList{})
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubRecurringIssueSpec defines the desired state of GitHubRecurringIssue
+type GitHubRecurringIssueSpec struct {
+	// Schedule represents when an occurrence is created, in the cron format
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// StartingDeadlineSeconds represents how late an occurrence may still be created after its scheduled time,
+	// with no deadline when unset. Only the latest of several missed occurrences is created
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// Suspend stops the creation of new occurrences
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Template represents the GitHubIssue created for every occurrence.
+	// The title and description are Go templates, rendered with .Date (2006-01-02) and .Time of the occurrence
+	Template GitHubRecurringIssueTemplate `json:"template"`
+
+	// ClosePrevious closes the open occurrences when a new one is created
+	// +optional
+	ClosePrevious bool `json:"closePrevious,omitempty"`
+
+	// ClosedHistoryLimit represents the number of closed occurrences kept, defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ClosedHistoryLimit *int32 `json:"closedHistoryLimit,omitempty"`
+
+	// OpenHistoryLimit represents the number of open occurrences kept, older ones are deleted, which closes them.
+	// Open occurrences are never deleted when unset
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	OpenHistoryLimit *int32 `json:"openHistoryLimit,omitempty"`
+}
+
+// GitHubRecurringIssueTemplate describes the GitHubIssue objects created by a GitHubRecurringIssue
+type GitHubRecurringIssueTemplate struct {
+	// Labels represents labels added to every created GitHubIssue
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Repo represents the repo of the issue, as owner/repo or its url
+	// +kubebuilder:validation:Pattern=`^(((https?|ssh|git)://)?([a-zA-Z0-9_.-]+@)?[a-zA-Z0-9.-]+(:[0-9]+)?[/:])?[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+/?$`
+	Repo string `json:"repo"`
+
+	// Title represents the title of the issue. The occurrences are told apart by their title, so a template
+	// rendering the same title for consecutive occurrences, without .Date or .Time, is rejected
+	Title string `json:"title"`
+
+	// Description represents the description of the issue
+	Description string `json:"description,omitempty"`
+}
+
+// GitHubRecurringIssueStatus defines the observed state of GitHubRecurringIssue
+type GitHubRecurringIssueStatus struct {
+	// LastScheduleTime represents the scheduled time of the last created occurrence
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime represents the scheduled time of the next occurrence
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// Active represents the names of the GitHubIssue objects of the open occurrences
+	Active []string `json:"active,omitempty"`
+
+	// Conditions represent the latest observations of the recurring issue, such as whether its schedule is valid
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+//+kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+//+kubebuilder:printcolumn:name="Next Schedule",type=date,JSONPath=`.status.nextScheduleTime`
+
+// GitHubRecurringIssue is the Schema for the githubrecurringissues API
+type GitHubRecurringIssue struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubRecurringIssueSpec   `json:"spec,omitempty"`
+	Status GitHubRecurringIssueStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubRecurringIssueList contains a list of GitHubRecurringIssue
+type GitHubRecurringIssueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubRecurringIssue `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubRecurringIssue{}, &GitHubRecurringIssueList{})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHubRepositorySpec defines the desired state of GitHubRepository
+type GitHubRepositorySpec struct {
+	// Repo represents the repo, as owner/repo, host/owner/repo for an enterprise server, or its https or ssh url
+	// +kubebuilder:validation:Pattern=`^(((https?|ssh|git)://)?([a-zA-Z0-9_.-]+@)?[a-zA-Z0-9.-]+(:[0-9]+)?[/:])?[a-zA-Z0-9_.-]+/[a-zA-Z0-9_.-]+/?$`
+	Repo string `json:"repo"`
+
+	// Host represents the host of the GitHub Enterprise Server of a repo given as owner/repo
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// CredentialsRef represents the Secret in the namespace of the repository holding the token its issues are
+	// managed with, instead of the token of the operator
+	// +optional
+	CredentialsRef *CredentialsReference `json:"credentialsRef,omitempty"`
+
+	// DefaultLabels represents the labels of the referencing issues that don't set their own
+	// +optional
+	DefaultLabels []string `json:"defaultLabels,omitempty"`
+
+	// AllowedNamespaces represents the namespaces whose GitHubIssue objects may reference the repository besides
+	// its own, as names or patterns such as team-*
+	// +optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+// GitHubRepositoryStatus defines the observed state of GitHubRepository
+type GitHubRepositoryStatus struct {
+	// FullName represents the repo as owner/repo, as GitHub names it
+	FullName string `json:"fullName,omitempty"`
+
+	// Permission represents the access of the token to the repo: admin, maintain, write, triage or read
+	Permission string `json:"permission,omitempty"`
+
+	// OpenIssues represents the number of open issues in the repo, without the pull requests
+	OpenIssues int `json:"openIssues,omitempty"`
+
+	// Conditions represent the latest observations of the repository, such as whether it is ready for issues
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Repo",type=string,JSONPath=`.spec.repo`
+//+kubebuilder:printcolumn:name="Permission",type=string,JSONPath=`.status.permission`
+//+kubebuilder:printcolumn:name="Open Issues",type=integer,JSONPath=`.status.openIssues`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// GitHubRepository is the Schema for the githubrepositories API
+type GitHubRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitHubRepositorySpec   `json:"spec,omitempty"`
+	Status GitHubRepositoryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitHubRepositoryList contains a list of GitHubRepository
+type GitHubRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitHubRepository `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitHubRepository{}, &GitHubRepositoryList{})
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"io"
+	"io/ioutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultLockFile is the name of the lock file kept next to the manifests
+const defaultLockFile = "ghissue.lock.json"
+
+// LockEntry records the real issue a manifest manages
+type LockEntry struct {
+	Manifest string `json:"manifest"`
+	Repo     string `json:"repo"`
+	Title    string `json:"title"`
+	Number   int    `json:"number"`
+}
+
+// Lock maps the GitHubIssue manifests, by namespace/name, to their real issues
+type Lock struct {
+	Issues map[string]LockEntry `json:"issues"`
+}
+
+// manifest is a GitHubIssue read from a file
+type manifest struct {
+	path    string
+	ghIssue *examplev1alpha1.GitHubIssue
+}
+
+// plannedIssue is the plan for a single manifest, with what it takes to apply it
+type plannedIssue struct {
+	key         string
+	manifest    *manifest
+	ghIssue     *examplev1alpha1.GitHubIssue
+	plan        *examplev1alpha1.IssuePlan
+	issueData   *clients.Issue
+	issue       *clients.Issue
+	detailsData *clients.Details
+}
+
+// planManifests prints what apply would do to the real issues of a directory of manifests
+func planManifests(args []string) error {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	lockFile := flags.String("lock", "", "lock file path, "+defaultLockFile+" in DIR by default")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("plan expects a directory of GitHubIssue manifests")
+	}
+	dir := flags.Arg(0)
+	_, err := runManifests(newGithubClient(), dir, lockPath(dir, *lockFile), os.Stdout, nil)
+	return err
+}
+
+// applyManifests makes the real issues match a directory of manifests, after confirmation
+func applyManifests(args []string) error {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	lockFile := flags.String("lock", "", "lock file path, "+defaultLockFile+" in DIR by default")
+	autoApprove := flags.Bool("auto-approve", false, "apply the plan without asking for confirmation")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("apply expects a directory of GitHubIssue manifests")
+	}
+	dir := flags.Arg(0)
+	confirm := func() bool {
+		if *autoApprove {
+			return true
+		}
+		fmt.Print("\nApply these changes? Only 'yes' will be accepted: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		return strings.TrimSpace(answer) == "yes"
+	}
+	_, err := runManifests(newGithubClient(), dir, lockPath(dir, *lockFile), os.Stdout, confirm)
+	return err
+}
+
+func lockPath(dir, lockFile string) string {
+	if lockFile != "" {
+		return lockFile
+	}
+	return filepath.Join(dir, defaultLockFile)
+}
+
+// runManifests plans the manifests of dir against the real issues, and applies the plan if confirm approves it.
+// A nil confirm only plans. It returns the lock as it is afterwards.
+func runManifests(clientFrame clients.ClientFrame, dir, lockFile string, out io.Writer, confirm func() bool) (*Lock, error) {
+	manifests, reader, err := readManifests(dir)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := readLock(lockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	planned, err := planAll(clientFrame, reader, manifests, lock)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, p := range planned {
+		counts[p.plan.Action]++
+		if p.plan.Action == controllers.PlanNoop {
+			continue
+		}
+		fmt.Fprintf(out, "%s (%s) will be %s\n", p.key, p.ghIssue.Spec.Repo, actionPastTense(p.plan.Action))
+		for _, change := range p.plan.Changes {
+			if change.Field == "body" {
+				fmt.Fprint(out, indent(controllers.UnifiedDiff(change.From, change.To, "remote/body", "spec/description")))
+				continue
+			}
+			fmt.Fprintf(out, "    %s: %q -> %q\n", change.Field, change.From, change.To)
+		}
+	}
+	fmt.Fprintf(out, "Plan: %d to create, %d to edit, %d to close.\n",
+		counts[controllers.PlanCreate], counts[controllers.PlanEdit], counts[controllers.PlanClose])
+
+	if confirm == nil || len(planned) == counts[controllers.PlanNoop] {
+		return lock, nil
+	}
+	if !confirm() {
+		fmt.Fprintln(out, "Apply cancelled.")
+		return lock, nil
+	}
+
+	for _, p := range planned {
+		issue, returnErr := p.issue, &clients.Error{}
+		if p.plan.Action != controllers.PlanNoop {
+			issue, returnErr = controllers.ApplyPlan(clientFrame, p.plan, p.issueData, p.issue, p.detailsData)
+			if returnErr.ErrorCode != nil {
+				return lock, fmt.Errorf("%s: %s", p.key, returnErr.Message)
+			}
+			fmt.Fprintf(out, "%s: %s done\n", p.key, p.plan.Action)
+		}
+		// Record the issue right away, so a failure later on doesn't lose it
+		if p.manifest == nil {
+			delete(lock.Issues, p.key)
+		} else if issue != nil {
+			lock.Issues[p.key] = LockEntry{Manifest: p.manifest.path, Repo: p.ghIssue.Spec.Repo, Title: p.ghIssue.Spec.Title, Number: issue.Number}
+		}
+		if err := writeLock(lockFile, lock); err != nil {
+			return lock, err
+		}
+	}
+	return lock, nil
+}
+
+// planAll computes the plan of every manifest, and a close plan for every locked issue whose manifest was removed.
+// The manifests get the defaults of their Namespace, repository and credentials from the objects of reader, as
+// the webhook and the operator would give them
+func planAll(clientFrame clients.ClientFrame, reader client.Reader, manifests []*manifest, lock *Lock) ([]*plannedIssue, error) {
+	var planned []*plannedIssue
+	seen := map[string]bool{}
+	for _, m := range manifests {
+		key := m.ghIssue.Namespace + "/" + m.ghIssue.Name
+		if seen[key] {
+			return nil, fmt.Errorf("%s is defined more than once, in %s", key, m.path)
+		}
+		seen[key] = true
+		p := &plannedIssue{key: key, manifest: m, ghIssue: m.ghIssue}
+		if entry, ok := lock.Issues[key]; ok {
+			adoptNumber(p.ghIssue, entry.Number)
+		}
+		planned = append(planned, p)
+	}
+
+	// Issues of removed manifests are closed, as the operator does with deleted objects
+	var removed []string
+	for key := range lock.Issues {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	for _, key := range removed {
+		entry := lock.Issues[key]
+		ghIssue := &examplev1alpha1.GitHubIssue{Spec: examplev1alpha1.GitHubIssueSpec{Repo: entry.Repo, Title: entry.Title}}
+		now := metav1.Now()
+		ghIssue.DeletionTimestamp = &now
+		adoptNumber(ghIssue, entry.Number)
+		planned = append(planned, &plannedIssue{key: key, ghIssue: ghIssue})
+	}
+
+	ctx := context.Background()
+	for _, p := range planned {
+		if p.manifest != nil {
+			namespace := corev1.Namespace{}
+			if err := reader.Get(ctx, types.NamespacedName{Name: p.ghIssue.Namespace}, &namespace); err != nil && !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			p.ghIssue.DefaultFrom(namespace.Annotations)
+		}
+		repoData, issueData, detailsData, err := resolveIssue(ctx, reader, clientFrame, p.ghIssue)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p.key, err)
+		}
+		issueData.Labels, issueData.Assignees = p.ghIssue.Spec.Labels, p.ghIssue.Spec.Assignees
+		issue, returnErr := controllers.FindRealIssue(clientFrame, p.ghIssue, repoData, issueData, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, fmt.Errorf("%s: %s", p.key, returnErr.Message)
+		}
+		p.issueData, p.issue, p.detailsData = issueData, issue, detailsData
+		p.plan = controllers.ComputePlan(p.ghIssue, issueData, issue)
+	}
+	return planned, nil
+}
+
+// adoptNumber makes ghIssue manage the issue of the given number
+func adoptNumber(ghIssue *examplev1alpha1.GitHubIssue, number int) {
+	if ghIssue.Annotations == nil {
+		ghIssue.Annotations = map[string]string{}
+	}
+	ghIssue.Annotations[controllers.AdoptAnnotation] = strconv.Itoa(number)
+}
+
+// readManifests returns the GitHubIssue objects of the yaml files in dir and its subdirectories, with a reader of
+// the Namespace, GitHubRepository and Secret objects they may use. Other kinds are skipped
+func readManifests(dir string) ([]*manifest, *manifestReader, error) {
+	var manifests []*manifest
+	reader := &manifestReader{documents: map[string][]byte{}}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, document := range splitDocuments(content) {
+			var typeMeta metav1.TypeMeta
+			if err := yaml.Unmarshal(document, &typeMeta); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			if manifestReaderKinds[typeMeta.Kind] {
+				if err := reader.add(typeMeta.Kind, document); err != nil {
+					return fmt.Errorf("%s: %v", path, err)
+				}
+				continue
+			}
+			if typeMeta.Kind != "GitHubIssue" {
+				continue
+			}
+			ghIssue := &examplev1alpha1.GitHubIssue{}
+			if err := yaml.UnmarshalStrict(document, ghIssue); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			if ghIssue.Name == "" {
+				return fmt.Errorf("%s: GitHubIssue without a name", path)
+			}
+			if ghIssue.Namespace == "" {
+				ghIssue.Namespace = "default"
+			}
+			manifests = append(manifests, &manifest{path: path, ghIssue: ghIssue})
+		}
+		return nil
+	})
+	return manifests, reader, err
+}
+
+// manifestReaderKinds are the kinds of the manifests a manifestReader holds
+var manifestReaderKinds = map[string]bool{"Namespace": true, "GitHubRepository": true, "Secret": true}
+
+// manifestReader reads the objects of manifests, in place of the cluster of the operator
+type manifestReader struct {
+	// documents holds the manifests by kind/namespace/name
+	documents map[string][]byte
+}
+
+var _ client.Reader = &manifestReader{}
+
+// add keeps the manifest of an object of the kind, in the default namespace if it has none
+func (m *manifestReader) add(kind string, document []byte) error {
+	var object metav1.PartialObjectMetadata
+	if err := yaml.Unmarshal(document, &object); err != nil {
+		return err
+	}
+	if object.Namespace == "" && kind != "Namespace" {
+		object.Namespace = "default"
+	}
+	m.documents[kind+"/"+object.Namespace+"/"+object.Name] = document
+	return nil
+}
+
+// Get reads the manifest of the object, or returns a NotFound error
+func (m *manifestReader) Get(_ context.Context, key client.ObjectKey, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+	document, ok := m.documents[gvk.Kind+"/"+key.Namespace+"/"+key.Name]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+	if err := yaml.Unmarshal(document, obj); err != nil {
+		return err
+	}
+	if obj.GetNamespace() == "" && gvk.Kind != "Namespace" {
+		obj.SetNamespace(key.Namespace)
+	}
+	// The API server would merge the stringData of a Secret into its data
+	if secret, ok := obj.(*corev1.Secret); ok && len(secret.StringData) != 0 {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for k, v := range secret.StringData {
+			secret.Data[k] = []byte(v)
+		}
+	}
+	return nil
+}
+
+// List isn't needed to find the real issues
+func (m *manifestReader) List(_ context.Context, _ client.ObjectList, _ ...client.ListOption) error {
+	return fmt.Errorf("listing manifests is not supported")
+}
+
+// splitDocuments splits a multi-document yaml file, dropping empty documents
+func splitDocuments(content []byte) [][]byte {
+	var documents [][]byte
+	for _, document := range bytes.Split(append([]byte("\n"), content...), []byte("\n---")) {
+		if len(bytes.TrimSpace(document)) != 0 {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+// readLock reads the lock file, a missing lock file is an empty lock
+func readLock(lockFile string) (*Lock, error) {
+	lock := &Lock{Issues: map[string]LockEntry{}}
+	content, err := ioutil.ReadFile(lockFile)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("%s: %v", lockFile, err)
+	}
+	if lock.Issues == nil {
+		lock.Issues = map[string]LockEntry{}
+	}
+	return lock, nil
+}
+
+func writeLock(lockFile string, lock *Lock) error {
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(lockFile, append(content, '\n'), 0644)
+}
+
+func actionPastTense(action string) string {
+	switch action {
+	case controllers.PlanCreate:
+		return "created"
+	case controllers.PlanEdit:
+		return "edited"
+	case controllers.PlanClose:
+		return "closed"
+	case controllers.PlanReopen:
+		return "reopened"
+	}
+	return action
+}
+
+func indent(s string) string {
+	if s == "" {
+		return s
+	}
+	return "    " + strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\n", "\n    ") + "\n"
+}
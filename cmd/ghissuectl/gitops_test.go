@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifests = `apiVersion: example.training.redhat.com/v1alpha1
+kind: GitHubIssue
+metadata:
+  name: first
+spec:
+  repo: arielireni/Issues-Example
+  title: first issue
+  description: first body
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: skipped
+---
+apiVersion: example.training.redhat.com/v1alpha1
+kind: GitHubIssue
+metadata:
+  name: second
+  namespace: team
+spec:
+  repo: arielireni/Issues-Example
+  title: second issue
+  description: second body
+`
+
+func writeManifests(t *testing.T, dir, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "issues.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanDoesNotApply(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ghissuectl")
+	defer os.RemoveAll(dir)
+	writeManifests(t, dir, testManifests)
+	fakeClient := clients.NewFakeClient([]clients.Issue{}, true, nil)
+
+	out := &bytes.Buffer{}
+	if _, err := runManifests(fakeClient, dir, filepath.Join(dir, defaultLockFile), out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Plan: 2 to create, 0 to edit, 0 to close.") {
+		t.Errorf("unexpected plan:\n%s", out.String())
+	}
+	if fakeClient.CallsTo("CreateIssue") != 0 {
+		t.Errorf("plan created issues")
+	}
+	if _, err := os.Stat(filepath.Join(dir, defaultLockFile)); !os.IsNotExist(err) {
+		t.Errorf("plan wrote the lock file")
+	}
+}
+
+func TestApplyTracksIssues(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ghissuectl")
+	defer os.RemoveAll(dir)
+	writeManifests(t, dir, testManifests)
+	lockFile := filepath.Join(dir, defaultLockFile)
+	fakeClient := clients.NewFakeClient([]clients.Issue{}, true, nil)
+	approve := func() bool { return true }
+
+	lock, err := runManifests(fakeClient, dir, lockFile, &bytes.Buffer{}, approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Issues) != 2 || lock.Issues["default/first"].Number == 0 || lock.Issues["team/second"].Number == 0 {
+		t.Fatalf("unexpected lock: %+v", lock.Issues)
+	}
+
+	// A renamed title is still the same issue, found by its locked number
+	writeManifests(t, dir, strings.Replace(strings.Replace(testManifests, "first body", "edited body", 1), "title: first issue", "title: renamed", 1))
+	out := &bytes.Buffer{}
+	if _, err := runManifests(fakeClient, dir, lockFile, out, approve); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Plan: 0 to create, 1 to edit, 0 to close.") {
+		t.Errorf("unexpected plan:\n%s", out.String())
+	}
+
+	// A removed manifest closes its issue and leaves the lock
+	writeManifests(t, dir, strings.SplitN(testManifests, "---", 2)[0])
+	lock, err = runManifests(fakeClient, dir, lockFile, &bytes.Buffer{}, approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lock.Issues["team/second"]; ok || len(lock.Issues) != 1 {
+		t.Errorf("unexpected lock: %+v", lock.Issues)
+	}
+	for _, issue := range fakeClient.Issues() {
+		if issue.Title == "second issue" && issue.State != "closed" {
+			t.Errorf("removed manifest's issue is %s", issue.State)
+		}
+	}
+}
+
+const testRepositoryManifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: team
+  annotations:
+    example.training.redhat.com/default-assignees: octocat
+---
+apiVersion: example.training.redhat.com/v1alpha1
+kind: GitHubRepository
+metadata:
+  name: issues
+  namespace: platform
+spec:
+  repo: arielireni/Issues-Example
+  credentialsRef:
+    name: platform-token
+  defaultLabels: [triage]
+  allowedNamespaces: [team]
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: platform-token
+  namespace: platform
+stringData:
+  token: secret
+---
+apiVersion: example.training.redhat.com/v1alpha1
+kind: GitHubIssue
+metadata:
+  name: shared
+  namespace: team
+spec:
+  repositoryRef:
+    name: issues
+    namespace: platform
+  title: shared issue
+`
+
+func TestPlanResolvesRepository(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "ghissuectl")
+	defer os.RemoveAll(dir)
+	writeManifests(t, dir, testRepositoryManifests)
+	manifests, reader, err := readManifests(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The issue gets the defaults of its Namespace, and the repo, labels and token of its GitHubRepository
+	planned, err := planAll(clients.NewFakeClient([]clients.Issue{}, true, nil), reader, manifests, &Lock{Issues: map[string]LockEntry{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := planned[0]
+	if p.ghIssue.Spec.Repo != "arielireni/Issues-Example" || !reflect.DeepEqual(p.issueData.Labels, []string{"triage"}) ||
+		!reflect.DeepEqual(p.issueData.Assignees, []string{"octocat"}) || p.detailsData.Token != "secret" {
+		t.Errorf("Expected the issue to be resolved but got %+v, %+v with token %q", p.ghIssue.Spec, p.issueData, p.detailsData.Token)
+	}
+
+	// And a missing Secret fails the plan rather than falling back to another token
+	writeManifests(t, dir, strings.Replace(testRepositoryManifests, "name: platform-token\n  namespace", "name: other-token\n  namespace", 1))
+	manifests, reader, _ = readManifests(dir)
+	if _, err := planAll(clients.NewFakeClient([]clients.Issue{}, true, nil), reader, manifests, &Lock{Issues: map[string]LockEntry{}}); err == nil || !strings.Contains(err.Error(), "team/shared") {
+		t.Errorf("Expected the missing Secret to fail the plan but got %v", err)
+	}
+}
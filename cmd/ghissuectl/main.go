@@ -0,0 +1,307 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ghissuectl operates the GitHubIssue objects managed by the operator, side by side with their real issues
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"io"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"os"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `ghissuectl operates GitHubIssue objects and their real GitHub issues.
+
+Usage:
+  ghissuectl list   [-n namespace | -A]
+  ghissuectl diff   [-n namespace] NAME
+  ghissuectl resync [-n namespace] NAME
+  ghissuectl adopt  [-n namespace] NAME NUMBER
+
+Without a cluster, from a directory of GitHubIssue manifests:
+  ghissuectl plan   [-lock file] DIR
+  ghissuectl apply  [-lock file] [-auto-approve] DIR
+
+The issues are managed with the credentials the operator uses: the Secret of their credentialsRef or of
+their GitHubRepository, read from the cluster or from the Secret manifests of DIR, or else the GitHub token
+of the TOKEN environment variable. Repos on GitHub Enterprise Server hosts are allowed by ENTERPRISE_HOSTS,
+a comma separated list of hosts like the --enterprise-hosts flag of the operator.
+`
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(examplev1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Commands working on manifests don't need a cluster
+	standalone := map[string]func(args []string) error{
+		"plan":  planManifests,
+		"apply": applyManifests,
+	}
+	if command, ok := standalone[os.Args[1]]; ok {
+		if err := command(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	commands := map[string]func(ctx context.Context, k8sClient client.Client, args []string) error{
+		"list":   list,
+		"diff":   diff,
+		"resync": resync,
+		"adopt":  adopt,
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "unable to load kubeconfig:", err)
+		os.Exit(1)
+	}
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "unable to create kubernetes client:", err)
+		os.Exit(1)
+	}
+
+	if err := command(context.Background(), k8sClient, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// list prints the GitHubIssue objects with their real issue number, state and sync condition
+func list(ctx context.Context, k8sClient client.Client, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	namespace := flags.String("n", "default", "namespace of the GitHubIssue objects")
+	allNamespaces := flags.Bool("A", false, "list the GitHubIssue objects of all namespaces")
+	flags.Parse(args)
+
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	var opts []client.ListOption
+	if !*allNamespaces {
+		opts = append(opts, client.InNamespace(*namespace))
+	}
+	if err := k8sClient.List(ctx, &ghIssues, opts...); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tREPO\tNUMBER\tSTATE\tSYNCED\tMESSAGE")
+	for _, ghIssue := range ghIssues.Items {
+		number := "-"
+		if ghIssue.Status.Number != 0 {
+			number = "#" + strconv.Itoa(ghIssue.Status.Number)
+		}
+		synced, message := "Unknown", ""
+		if condition := meta.FindStatusCondition(ghIssue.Status.Conditions, controllers.ConditionSynced); condition != nil {
+			synced, message = string(condition.Status), condition.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", ghIssue.Namespace, ghIssue.Name, valueOr(ghIssue.Spec.Repo, ghIssue.Status.Repo),
+			number, valueOr(ghIssue.Status.State, "-"), synced, firstLine(message))
+	}
+	return w.Flush()
+}
+
+// diff prints the changes the operator would make to the real issue of a GitHubIssue
+func diff(ctx context.Context, k8sClient client.Client, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	namespace := flags.String("n", "default", "namespace of the GitHubIssue object")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("diff expects the name of a GitHubIssue")
+	}
+
+	ghIssue := examplev1alpha1.GitHubIssue{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: *namespace, Name: flags.Arg(0)}, &ghIssue); err != nil {
+		return err
+	}
+	githubClient := newGithubClient()
+	repoData, issueData, detailsData, err := resolveIssue(ctx, k8sClient, githubClient, &ghIssue)
+	if err != nil {
+		return err
+	}
+	issue, returnErr := controllers.FindRealIssue(githubClient, &ghIssue, repoData, issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		return fmt.Errorf("%s", returnErr.Message)
+	}
+	if err := controllers.DesiredIssue(ctx, k8sClient, &ghIssue, issueData); err != nil {
+		return err
+	}
+	printDiff(os.Stdout, &ghIssue, issueData, issue)
+	return nil
+}
+
+// printDiff prints the plan of the reconciler for the real issue, with a unified diff of the body
+func printDiff(out io.Writer, ghIssue *examplev1alpha1.GitHubIssue, issueData *clients.Issue, issue *clients.Issue) {
+	plan := controllers.ComputePlan(ghIssue, issueData, issue)
+	if issue == nil {
+		fmt.Fprintf(out, "Issue %q does not exist in %s, it will be created\n", ghIssue.Spec.Title, ghIssue.Spec.Repo)
+		return
+	}
+	fmt.Fprintf(out, "Issue #%d in %s (%s)\n", issue.Number, ghIssue.Spec.Repo, issue.State)
+	if plan.Action == controllers.PlanNoop {
+		fmt.Fprintln(out, "No differences")
+		return
+	}
+	fmt.Fprintf(out, "It will be %s\n", actionPastTense(plan.Action))
+	for _, change := range plan.Changes {
+		if change.Field == "body" {
+			fmt.Fprint(out, controllers.UnifiedDiff(change.From, change.To, "remote/body", "desired/body"))
+			continue
+		}
+		fmt.Fprintf(out, "%s: %q -> %q\n", change.Field, change.From, change.To)
+	}
+}
+
+// resync triggers an immediate reconciliation of a GitHubIssue
+func resync(ctx context.Context, k8sClient client.Client, args []string) error {
+	flags := flag.NewFlagSet("resync", flag.ExitOnError)
+	namespace := flags.String("n", "default", "namespace of the GitHubIssue object")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("resync expects the name of a GitHubIssue")
+	}
+
+	err := annotate(ctx, k8sClient, *namespace, flags.Arg(0), controllers.ResyncAnnotation, time.Now().UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("githubissue/%s resync requested\n", flags.Arg(0))
+	return nil
+}
+
+// adopt makes a GitHubIssue manage an existing issue by its number
+func adopt(ctx context.Context, k8sClient client.Client, args []string) error {
+	flags := flag.NewFlagSet("adopt", flag.ExitOnError)
+	namespace := flags.String("n", "default", "namespace of the GitHubIssue object")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return fmt.Errorf("adopt expects the name of a GitHubIssue and an issue number")
+	}
+	number, err := strconv.Atoi(flags.Arg(1))
+	if err != nil || number <= 0 {
+		return fmt.Errorf("invalid issue number %q", flags.Arg(1))
+	}
+
+	ghIssue := examplev1alpha1.GitHubIssue{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: *namespace, Name: flags.Arg(0)}, &ghIssue); err != nil {
+		return err
+	}
+	// Make sure the issue exists before the operator starts managing it
+	githubClient := newGithubClient()
+	repoData, _, detailsData, err := resolveIssue(ctx, k8sClient, githubClient, &ghIssue)
+	if err != nil {
+		return err
+	}
+	issue, returnErr := githubClient.GetIssue(repoData, number, detailsData)
+	if returnErr.ErrorCode != nil {
+		return fmt.Errorf("%s", returnErr.Message)
+	}
+
+	if err := annotate(ctx, k8sClient, *namespace, flags.Arg(0), controllers.AdoptAnnotation, strconv.Itoa(number)); err != nil {
+		return err
+	}
+	fmt.Printf("githubissue/%s adopted issue #%d %q\n", flags.Arg(0), issue.Number, issue.Title)
+	return nil
+}
+
+// resolveIssue fills the repo and labels a GitHubIssue leaves to its GitHubRepository and returns the data of its
+// real issue, with the token of the credentials the operator manages it with
+func resolveIssue(ctx context.Context, reader client.Reader, clientFrame clients.ClientFrame, ghIssue *examplev1alpha1.GitHubIssue) (*clients.Repo, *clients.Issue, *clients.Details, error) {
+	ghRepo, returnErr := controllers.WithRepository(ctx, reader, ghIssue)
+	if returnErr.ErrorCode != nil {
+		return nil, nil, nil, fmt.Errorf("%s", returnErr.Message)
+	}
+	repoData, issueData, detailsData := clientFrame.InitDataStructs(ghIssue.Spec.Repo, ghIssue.Spec.Title, ghIssue.Spec.Description)
+	if returnErr := controllers.WithCredentials(ctx, reader, ghIssue, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		return nil, nil, nil, fmt.Errorf("%s", returnErr.Message)
+	}
+	return repoData, issueData, detailsData, nil
+}
+
+// newGithubClient returns a GitHub client allowed on the ENTERPRISE_HOSTS
+func newGithubClient() *clients.GithubClient {
+	githubClient := clients.NewGithubClient()
+	for _, host := range strings.Split(os.Getenv("ENTERPRISE_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			githubClient.EnterpriseHosts = append(githubClient.EnterpriseHosts, host)
+		}
+	}
+	return githubClient
+}
+
+// annotate sets a single annotation on a GitHubIssue
+func annotate(ctx context.Context, k8sClient client.Client, namespace, name, key, value string) error {
+	ghIssue := examplev1alpha1.GitHubIssue{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &ghIssue); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	annotations := ghIssue.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	ghIssue.SetAnnotations(annotations)
+	return k8sClient.Patch(ctx, &ghIssue, patch)
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func firstLine(s string) string {
+	for i, c := range s {
+		if c == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
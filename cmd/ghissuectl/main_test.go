@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers"
+	"github.com/arielireni/example-operator/controllers/clients"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"testing"
+)
+
+func TestDiffRendersBody(t *testing.T) {
+	// Given a parent issue whose real issue has the description of the spec, without the task list of its child,
+	// and a title edited on GitHub
+	parent := &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: "default"},
+		Spec:       examplev1alpha1.GitHubIssueSpec{Repo: "arielireni/Issues-Example", Title: "parent", Description: "body"},
+	}
+	child := &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: "default"},
+		Spec: examplev1alpha1.GitHubIssueSpec{
+			Repo:      "arielireni/Issues-Example",
+			Title:     "child",
+			ParentRef: &examplev1alpha1.IssueReference{Name: "parent"},
+		},
+		Status: examplev1alpha1.GitHubIssueStatus{Number: 2, Repo: "arielireni/Issues-Example"},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(parent, child).Build()
+	issue := &clients.Issue{Title: "parent (edited)", Description: "body", Number: 1, State: "open"}
+	_, issueData, _ := clients.NewFakeClient(nil, true, nil).InitDataStructs(parent.Spec.Repo, parent.Spec.Title, parent.Spec.Description)
+
+	// When diffing it
+	if err := controllers.DesiredIssue(context.Background(), k8sClient, parent, issueData); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	out := &bytes.Buffer{}
+	printDiff(out, parent, issueData, issue)
+
+	// Then the task list the reconciler renders is shown, and the title the reconciler never edits isn't
+	if !strings.Contains(out.String(), "+- [ ] arielireni/Issues-Example#2") || !strings.Contains(out.String(), "It will be edited") {
+		t.Errorf("Expected the task list in the diff but got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "title") {
+		t.Errorf("Expected no title diff but got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+)
+
+// activityOf summarizes the conversation on the real issue for the status
+func activityOf(issue *clients.Issue) *examplev1alpha1.IssueActivity {
+	activity := &examplev1alpha1.IssueActivity{
+		Author:          issue.Author,
+		CreatedAt:       issue.CreatedAt,
+		Comments:        issue.Comments,
+		LastCommenter:   issue.LastCommenter,
+		LastCommentedAt: issue.LastCommentedAt,
+	}
+	if issue.State == "closed" {
+		activity.ClosedAt = issue.ClosedAt
+		activity.ClosedBy = issue.ClosedBy
+	}
+	if r := issue.Reactions; r != nil {
+		activity.Reactions = &examplev1alpha1.ReactionCounts{
+			Total:      r.TotalCount,
+			ThumbsUp:   r.PlusOne,
+			ThumbsDown: r.MinusOne,
+			Laugh:      r.Laugh,
+			Hooray:     r.Hooray,
+			Confused:   r.Confused,
+			Heart:      r.Heart,
+			Rocket:     r.Rocket,
+			Eyes:       r.Eyes,
+		}
+	}
+	return activity
+}
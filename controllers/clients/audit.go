@@ -0,0 +1,366 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-logr/logr"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Implementation of AuditClient - records every mutation performed on GitHub */
+
+// Audited operations
+const (
+	OperationCreate   = "create"
+	OperationEdit     = "edit"
+	OperationClose    = "close"
+	OperationReopen   = "reopen"
+	OperationTransfer = "transfer"
+
+	OperationCreateComment   = "create-comment"
+	OperationEditComment     = "edit-comment"
+	OperationDeleteComment   = "delete-comment"
+	OperationMinimizeComment = "minimize-comment"
+
+	OperationCreateLabel = "create-label"
+	OperationEditLabel   = "edit-label"
+	OperationDeleteLabel = "delete-label"
+
+	OperationCreateMilestone = "create-milestone"
+	OperationEditMilestone   = "edit-milestone"
+	OperationDeleteMilestone = "delete-milestone"
+)
+
+// AuditEntry structure declaration - a single mutation performed on GitHub
+type AuditEntry struct {
+	Time       string `json:"time"`
+	Operation  string `json:"operation"`
+	Actor      string `json:"actor"`
+	Resource   string `json:"resource,omitempty"`
+	URL        string `json:"url"`
+	Number     int    `json:"number,omitempty"`
+	CommentID  int64  `json:"comment_id,omitempty"`
+	BeforeHash string `json:"before_hash,omitempty"`
+	AfterHash  string `json:"after_hash,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditSink stores audit entries, an implementation must only ever append to its record
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// AuditClient wraps a ClientFrame and records its create, edit, close, reopen and transfer calls, and the mutations of
+// comments, labels and milestones
+type AuditClient struct {
+	ClientFrame
+	Sink AuditSink
+	// Log reports the entries the sink failed to record
+	Log logr.Logger
+
+	mu sync.Mutex
+	// actors holds the login of every token seen so far, by tokenIdentity
+	actors map[string]string
+}
+
+func (a *AuditClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
+	after := *issueData
+	after.State = "open"
+	issue, returnErr := a.ClientFrame.CreateIssue(issueData, detailsData)
+	entry := a.newAuditEntry(OperationCreate, detailsData, detailsData.ApiURL, returnErr)
+	entry.AfterHash = contentHash(&after)
+	if issue != nil {
+		entry.Number = issue.Number
+	}
+	return issue, a.record(entry, returnErr)
+}
+
+func (a *AuditClient) EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	operation := OperationEdit
+	if issue.State == "closed" && issueData.State == "open" {
+		operation = OperationReopen
+	}
+	before := *issue
+	after := *issue
+	after.Description = issueData.Description
+	if issueData.State != "" {
+		after.State = issueData.State
+	}
+	returnErr := a.ClientFrame.EditIssue(issueData, issue, detailsData)
+	entry := a.newAuditEntry(operation, detailsData, detailsData.ApiURL+"/"+fmt.Sprint(before.Number), returnErr)
+	entry.Number = before.Number
+	entry.BeforeHash = contentHash(&before)
+	entry.AfterHash = contentHash(&after)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	before := *issue
+	after := *issue
+	after.State = "closed"
+	returnErr := a.ClientFrame.CloseIssue(issueData, issue, detailsData)
+	entry := a.newAuditEntry(OperationClose, detailsData, detailsData.ApiURL+"/"+fmt.Sprint(before.Number), returnErr)
+	entry.Number = before.Number
+	entry.BeforeHash = contentHash(&before)
+	entry.AfterHash = contentHash(&after)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error {
+	before := *issue
+	returnErr := a.ClientFrame.TransferIssue(issue, detailsData, targetData)
+	entry := a.newAuditEntry(OperationTransfer, detailsData, detailsData.ApiURL+"/"+fmt.Sprint(before.Number), returnErr)
+	entry.Number = before.Number
+	entry.BeforeHash = contentHash(&before)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) CreateComment(number int, commentData *Comment, detailsData *Details) (*Comment, *Error) {
+	comment, returnErr := a.ClientFrame.CreateComment(number, commentData, detailsData)
+	entry := a.newAuditEntry(OperationCreateComment, detailsData, detailsData.ApiURL+"/"+fmt.Sprint(number)+"/comments", returnErr)
+	entry.Number = number
+	entry.AfterHash = commentHash(commentData)
+	if comment != nil {
+		entry.CommentID = comment.ID
+	}
+	return comment, a.record(entry, returnErr)
+}
+
+func (a *AuditClient) EditComment(commentData *Comment, comment *Comment, detailsData *Details) *Error {
+	before := *comment
+	returnErr := a.ClientFrame.EditComment(commentData, comment, detailsData)
+	entry := a.newAuditEntry(OperationEditComment, detailsData, commentURL(before.ID, detailsData), returnErr)
+	entry.CommentID = before.ID
+	entry.BeforeHash = commentHash(&before)
+	entry.AfterHash = commentHash(commentData)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) DeleteComment(comment *Comment, detailsData *Details) *Error {
+	returnErr := a.ClientFrame.DeleteComment(comment, detailsData)
+	entry := a.newAuditEntry(OperationDeleteComment, detailsData, commentURL(comment.ID, detailsData), returnErr)
+	entry.CommentID = comment.ID
+	entry.BeforeHash = commentHash(comment)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) MinimizeComment(comment *Comment, classifier string, detailsData *Details) *Error {
+	returnErr := a.ClientFrame.MinimizeComment(comment, classifier, detailsData)
+	entry := a.newAuditEntry(OperationMinimizeComment, detailsData, commentURL(comment.ID, detailsData), returnErr)
+	entry.CommentID = comment.ID
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) CreateLabel(labelData *Label, detailsData *Details) (*Label, *Error) {
+	label, returnErr := a.ClientFrame.CreateLabel(labelData, detailsData)
+	entry := a.newAuditEntry(OperationCreateLabel, detailsData, labelsURL(detailsData), returnErr)
+	entry.AfterHash = labelHash(labelData)
+	return label, a.record(entry, returnErr)
+}
+
+func (a *AuditClient) EditLabel(labelData *Label, label *Label, detailsData *Details) *Error {
+	before := *label
+	returnErr := a.ClientFrame.EditLabel(labelData, label, detailsData)
+	entry := a.newAuditEntry(OperationEditLabel, detailsData, labelURL(before.Name, detailsData), returnErr)
+	entry.BeforeHash = labelHash(&before)
+	entry.AfterHash = labelHash(labelData)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) DeleteLabel(label *Label, detailsData *Details) *Error {
+	returnErr := a.ClientFrame.DeleteLabel(label, detailsData)
+	entry := a.newAuditEntry(OperationDeleteLabel, detailsData, labelURL(label.Name, detailsData), returnErr)
+	entry.BeforeHash = labelHash(label)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) CreateMilestone(milestoneData *Milestone, detailsData *Details) (*Milestone, *Error) {
+	milestone, returnErr := a.ClientFrame.CreateMilestone(milestoneData, detailsData)
+	entry := a.newAuditEntry(OperationCreateMilestone, detailsData, milestonesURL(detailsData), returnErr)
+	entry.AfterHash = milestoneHash(milestoneData)
+	return milestone, a.record(entry, returnErr)
+}
+
+func (a *AuditClient) EditMilestone(milestoneData *Milestone, milestone *Milestone, detailsData *Details) *Error {
+	before := *milestone
+	returnErr := a.ClientFrame.EditMilestone(milestoneData, milestone, detailsData)
+	entry := a.newAuditEntry(OperationEditMilestone, detailsData, milestoneURL(before.Number, detailsData), returnErr)
+	entry.BeforeHash = milestoneHash(&before)
+	entry.AfterHash = milestoneHash(milestoneData)
+	return a.record(entry, returnErr)
+}
+
+func (a *AuditClient) DeleteMilestone(milestone *Milestone, detailsData *Details) *Error {
+	returnErr := a.ClientFrame.DeleteMilestone(milestone, detailsData)
+	entry := a.newAuditEntry(OperationDeleteMilestone, detailsData, milestoneURL(milestone.Number, detailsData), returnErr)
+	entry.BeforeHash = milestoneHash(milestone)
+	return a.record(entry, returnErr)
+}
+
+// record stores the entry and returns the result of the mutation as is. A failure to store it is only logged, as
+// failing a mutation that was performed would have it performed again
+func (a *AuditClient) record(entry AuditEntry, returnErr *Error) *Error {
+	if err := a.Sink.Record(entry); err != nil {
+		a.Log.Error(err, "failed to record audit entry", "entry", entry)
+	}
+	return returnErr
+}
+
+func (a *AuditClient) newAuditEntry(operation string, detailsData *Details, url string, returnErr *Error) AuditEntry {
+	entry := AuditEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		Operation: operation,
+		Actor:     a.actor(detailsData),
+		Resource:  detailsData.Resource,
+		URL:       url,
+	}
+	if returnErr != nil {
+		entry.StatusCode = returnErr.StatusCode
+		entry.Error = returnErr.Message
+	}
+	return entry
+}
+
+// actor returns the login of the user of the token of detailsData, looked up once per token. If it can't be looked
+// up the token is identified by tokenIdentity, and the lookup is tried again with the next entry
+func (a *AuditClient) actor(detailsData *Details) string {
+	identity := tokenIdentity(detailsData.Token)
+	if detailsData.Token == "" {
+		return identity
+	}
+	a.mu.Lock()
+	login, found := a.actors[identity]
+	a.mu.Unlock()
+	if found {
+		return login
+	}
+	user, returnErr := a.ClientFrame.GetUser(detailsData)
+	if returnErr.ErrorCode != nil || user.Login == "" {
+		return identity
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.actors[identity] = user.Login
+	return user.Login
+}
+
+// tokenIdentity identifies the token that performed a mutation without exposing it
+func tokenIdentity(token string) string {
+	if token == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token-sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// contentHash returns a hash of the user visible content of an issue
+func contentHash(issue *Issue) string {
+	sum := sha256.Sum256([]byte(issue.Title + "\x00" + issue.Description + "\x00" + issue.State))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// commentHash returns a hash of the body of a comment
+func commentHash(comment *Comment) string {
+	sum := sha256.Sum256([]byte(comment.Body))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func labelHash(label *Label) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(label.Name) + "\n" + strings.ToLower(label.Color) + "\n" + label.Description))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func milestoneHash(milestone *Milestone) string {
+	sum := sha256.Sum256([]byte(milestone.Title + "\n" + milestone.Description + "\n" + milestone.DueOn + "\n" + milestone.State))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func NewAuditClient(clientFrame ClientFrame, sink AuditSink) *AuditClient {
+	return &AuditClient{
+		ClientFrame: clientFrame,
+		Sink:        sink,
+		Log:         logr.Discard(),
+		actors:      map[string]string{},
+	}
+}
+
+/* Audit sinks */
+
+// FileAuditSink appends every entry as a JSON line to a file
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (f *FileAuditSink) Record(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+func (f *FileAuditSink) Close() error {
+	return f.file.Close()
+}
+
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{file: file}, nil
+}
+
+// WebhookAuditSink posts every entry as JSON to an HTTP endpoint
+type WebhookAuditSink struct {
+	HttpClient http.Client
+	URL        string
+}
+
+func (w *WebhookAuditSink) Record(entry AuditEntry) error {
+	jsonData, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	resp, err := w.HttpClient.Post(w.URL, "application/json", bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func NewWebhookAuditSink(url string) *WebhookAuditSink {
+	return &WebhookAuditSink{
+		HttpClient: http.Client{Timeout: 10 * time.Second},
+		URL:        url,
+	}
+}
+
+// MultiAuditSink records every entry in all of its sinks
+type MultiAuditSink []AuditSink
+
+func (m MultiAuditSink) Record(entry AuditEntry) error {
+	var firstErr error
+	for _, sink := range m {
+		if err := sink.Record(entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,152 @@
+package clients
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileAuditSink(t *testing.T) {
+	// Given an audited client writing to a JSON-lines file
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	auditClient := NewAuditClient(NewFakeClient([]Issue{}, true, nil), sink)
+	_, issueData, detailsData := auditClient.InitDataStructs("owner/repo", "title1", "body")
+	detailsData.Resource = "default/issue1"
+
+	// When creating, editing and closing an issue
+	issue, _ := auditClient.CreateIssue(issueData, detailsData)
+	issueData.Description = "new body"
+	auditClient.EditIssue(issueData, issue, detailsData)
+	auditClient.CloseIssue(issueData, issue, detailsData)
+	sink.Close()
+
+	// Then every mutation is appended to the file in order
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := AuditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON line but got %q", scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+	operations := []string{OperationCreate, OperationEdit, OperationClose}
+	if len(entries) != len(operations) {
+		t.Fatalf("Expected %d entries but got %d", len(operations), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Operation != operations[i] {
+			t.Errorf("Expected %s but got %s", operations[i], entry.Operation)
+		}
+		if entry.Resource != "default/issue1" || entry.Actor == "" || entry.Actor == detailsData.Token {
+			t.Errorf("Expected resource and hidden actor identity but got %+v", entry)
+		}
+	}
+	if entries[1].BeforeHash == entries[1].AfterHash {
+		t.Errorf("Expected the edit to change the content hash")
+	}
+}
+
+func TestWebhookAuditSink(t *testing.T) {
+	// Given a webhook receiving audit entries
+	var received []AuditEntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		entry := AuditEntry{}
+		json.NewDecoder(req.Body).Decode(&entry)
+		received = append(received, entry)
+	}))
+	defer server.Close()
+	auditClient := NewAuditClient(NewFakeClient([]Issue{}, true, nil), NewWebhookAuditSink(server.URL))
+	_, issueData, detailsData := auditClient.InitDataStructs("owner/repo", "title1", "body")
+
+	// When creating an issue
+	if _, returnErr := auditClient.CreateIssue(issueData, detailsData); returnErr != nil && returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %v", returnErr.ErrorCode)
+	}
+
+	// Then the webhook gets the create entry
+	if len(received) != 1 || received[0].Operation != OperationCreate {
+		t.Errorf("Expected a single create entry but got %+v", received)
+	}
+}
+
+func TestWebhookAuditSinkFailure(t *testing.T) {
+	// Given a webhook that rejects audit entries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	auditClient := NewAuditClient(NewFakeClient([]Issue{}, true, nil), NewWebhookAuditSink(server.URL))
+	_, issueData, detailsData := auditClient.InitDataStructs("owner/repo", "title1", "body")
+
+	// When creating an issue
+	issue, returnErr := auditClient.CreateIssue(issueData, detailsData)
+
+	// Then the issue that was created is returned, so that it isn't created again
+	if (returnErr != nil && returnErr.ErrorCode != nil) || issue == nil {
+		t.Errorf("Expected the created issue but got %+v", returnErr)
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	// Given an audited client and two tokens
+	sink := &memoryAuditSink{}
+	users := &userLookups{ClientFrame: NewFakeClient([]Issue{}, true, nil)}
+	auditClient := NewAuditClient(users, sink)
+	_, issueData, detailsData := auditClient.InitDataStructs("owner/repo", "title1", "body")
+
+	// When creating and editing issues with both tokens
+	for _, token := range []string{"team-a", "team-b"} {
+		detailsData.Token = token
+		issueData.Title = token
+		issue, _ := auditClient.CreateIssue(issueData, detailsData)
+		auditClient.EditIssue(issueData, issue, detailsData)
+	}
+
+	// Then every entry has the login of the user of its token, looked up once per token
+	if len(sink.entries) != 4 {
+		t.Fatalf("Expected 4 entries but got %d", len(sink.entries))
+	}
+	for _, entry := range sink.entries {
+		if entry.Actor != "fake-user" {
+			t.Errorf("Expected actor fake-user but got %s", entry.Actor)
+		}
+	}
+	if users.calls != 2 {
+		t.Errorf("Expected a single user lookup per token but got %d", users.calls)
+	}
+}
+
+// memoryAuditSink keeps the entries it records
+type memoryAuditSink struct {
+	entries []AuditEntry
+}
+
+func (m *memoryAuditSink) Record(entry AuditEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// userLookups counts the users looked up through it
+type userLookups struct {
+	ClientFrame
+	calls int
+}
+
+func (u *userLookups) GetUser(detailsData *Details) (*User, *Error) {
+	u.calls++
+	return u.ClientFrame.GetUser(detailsData)
+}
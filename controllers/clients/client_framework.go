@@ -1,11 +1,32 @@
 package clients
 
+import "encoding/json"
+
 type ClientFrame interface {
 	InitDataStructs(repo, title, body string) (*Repo, *Issue, *Details)
 	FindIssue(repoData *Repo, issueData *Issue, detailsData *Details) (*Issue, *Error)
+	GetIssue(repoData *Repo, number int, detailsData *Details) (*Issue, *Error)
 	CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error)
 	EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error
 	CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error
+	TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error
+	SyncProjectItem(issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error)
+	GetRepository(detailsData *Details) (*Repository, *Error)
+	GetUser(detailsData *Details) (*User, *Error)
+	GetLabel(name string, detailsData *Details) (*Label, *Error)
+	CreateLabel(labelData *Label, detailsData *Details) (*Label, *Error)
+	EditLabel(labelData *Label, label *Label, detailsData *Details) *Error
+	DeleteLabel(label *Label, detailsData *Details) *Error
+	FindMilestone(title string, detailsData *Details) (*Milestone, *Error)
+	GetMilestone(number int, detailsData *Details) (*Milestone, *Error)
+	CreateMilestone(milestoneData *Milestone, detailsData *Details) (*Milestone, *Error)
+	EditMilestone(milestoneData *Milestone, milestone *Milestone, detailsData *Details) *Error
+	DeleteMilestone(milestone *Milestone, detailsData *Details) *Error
+	GetComment(id int64, detailsData *Details) (*Comment, *Error)
+	CreateComment(number int, commentData *Comment, detailsData *Details) (*Comment, *Error)
+	EditComment(commentData *Comment, comment *Comment, detailsData *Details) *Error
+	DeleteComment(comment *Comment, detailsData *Details) *Error
+	MinimizeComment(comment *Comment, classifier string, detailsData *Details) *Error
 }
 
 // Repo structure declaration - all data fields for getting a repo's issues list
@@ -19,18 +40,101 @@ type Issue struct {
 	Title               string `json:"title"`
 	Description         string `json:"body"`
 	Number              int    `json:"number"`
+	NodeID              string `json:"node_id,omitempty"`
 	State               string `json:"state,omitempty"`
 	LastUpdateTimestamp string `json:"updated_at,omitempty"`
+	ClosedAt            string `json:"closed_at,omitempty"`
+	// StateReason represents why the issue was closed (completed, not_planned) or reopened
+	StateReason string `json:"state_reason,omitempty"`
+	// Comments represents the number of comments on the issue
+	Comments int `json:"comments,omitempty"`
+	// Labels and Assignees of a desired issue are managed only if they aren't nil
+	Labels    []string `json:"-"`
+	Assignees []string `json:"-"`
+	// Milestone represents the number of the milestone of the issue, managed only if it isn't 0
+	Milestone int `json:"-"`
+	// The following fields are read from GitHub only, see UnmarshalJSON
+	Author    string `json:"-"`
+	CreatedAt string `json:"-"`
+	ClosedBy  string `json:"-"`
+	// LastCommenter and LastCommentedAt represent the latest comment, if the issue has any
+	LastCommenter   string     `json:"-"`
+	LastCommentedAt string     `json:"-"`
+	Reactions       *Reactions `json:"-"`
+	// ProjectItems are filled by the GraphQLClient only
+	ProjectItems []ProjectItem `json:"-"`
+}
+
+// Reactions structure declaration - the number of reactions to an issue by their content
+type Reactions struct {
+	TotalCount int `json:"total_count"`
+	PlusOne    int `json:"+1"`
+	MinusOne   int `json:"-1"`
+	Laugh      int `json:"laugh"`
+	Hooray     int `json:"hooray"`
+	Confused   int `json:"confused"`
+	Heart      int `json:"heart"`
+	Rocket     int `json:"rocket"`
+	Eyes       int `json:"eyes"`
+}
+
+// UnmarshalJSON reads an issue of the REST API, including the fields that are never sent back to it
+func (i *Issue) UnmarshalJSON(data []byte) error {
+	type issue Issue
+	type user struct {
+		Login string `json:"login"`
+	}
+	payload := struct {
+		*issue
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Assignees []user `json:"assignees"`
+		Milestone *struct {
+			Number int `json:"number"`
+		} `json:"milestone"`
+		User      *user      `json:"user"`
+		CreatedAt string     `json:"created_at"`
+		ClosedBy  *user      `json:"closed_by"`
+		Reactions *Reactions `json:"reactions"`
+	}{issue: (*issue)(i)}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	i.Labels, i.Assignees = nil, nil
+	for _, label := range payload.Labels {
+		i.Labels = append(i.Labels, label.Name)
+	}
+	for _, assignee := range payload.Assignees {
+		i.Assignees = append(i.Assignees, assignee.Login)
+	}
+	i.Milestone = 0
+	if payload.Milestone != nil {
+		i.Milestone = payload.Milestone.Number
+	}
+	i.Author, i.ClosedBy = "", ""
+	if payload.User != nil {
+		i.Author = payload.User.Login
+	}
+	i.CreatedAt = payload.CreatedAt
+	if payload.ClosedBy != nil {
+		i.ClosedBy = payload.ClosedBy.Login
+	}
+	i.Reactions = payload.Reactions
+	return nil
 }
 
 // Details structure declaration - all owner's details
 type Details struct {
 	ApiURL string
 	Token  string
+	// Resource represents the k8s object the requests are made for
+	Resource string
 }
 
 // Error structure declaration - errors with messages
 type Error struct {
-	ErrorCode error
-	Message   string
+	ErrorCode  error
+	Message    string
+	StatusCode int
 }
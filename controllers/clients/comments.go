@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Comment structure declaration - a comment on an issue
+type Comment struct {
+	ID        int64  `json:"id,omitempty"`
+	NodeID    string `json:"node_id,omitempty"`
+	Body      string `json:"body"`
+	HTMLURL   string `json:"html_url,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	// IsMinimized is set if the comment was hidden
+	IsMinimized bool `json:"-"`
+}
+
+// MinimizeOutdated hides a comment as outdated, one of the classifiers of the minimizeComment mutation
+const MinimizeOutdated = "OUTDATED"
+
+const minimizeCommentMutation = `mutation MinimizeComment($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) { minimizedComment { isMinimized } }
+}`
+
+// GetComment returns the comment by its id, a missing comment is an error with status 404
+func (g *GithubClient) GetComment(id int64, detailsData *Details) (*Comment, *Error) {
+	body, resp, returnErr := g.doRequest("GET", commentURL(id, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("Getting GitHub comment failed with response: \n", resp, body)
+	}
+	var comment *Comment
+	if err := json.Unmarshal(body, &comment); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return comment, &Error{StatusCode: resp.StatusCode}
+}
+
+func (g *GithubClient) CreateComment(number int, commentData *Comment, detailsData *Details) (*Comment, *Error) {
+	apiURL := detailsData.ApiURL + "/" + fmt.Sprint(number) + "/comments"
+	body, resp, returnErr := g.doRequest("POST", apiURL, map[string]string{"body": commentData.Body}, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("Creating GitHub comment failed with response: \n", resp, body)
+	}
+	var comment *Comment
+	if err := json.Unmarshal(body, &comment); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return comment, &Error{StatusCode: resp.StatusCode}
+}
+
+func (g *GithubClient) EditComment(commentData *Comment, comment *Comment, detailsData *Details) *Error {
+	body, resp, returnErr := g.doRequest("PATCH", commentURL(comment.ID, detailsData), map[string]string{"body": commentData.Body}, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("Editing GitHub comment failed with response: \n", resp, body)
+	}
+	json.Unmarshal(body, comment)
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// DeleteComment deletes the comment, a comment that is already gone is not an error
+func (g *GithubClient) DeleteComment(comment *Comment, detailsData *Details) *Error {
+	body, resp, returnErr := g.doRequest("DELETE", commentURL(comment.ID, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return responseError("Deleting GitHub comment failed with response: \n", resp, body)
+	}
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// MinimizeComment hides the comment for the classifier, e.g. MinimizeOutdated, through the GraphQL API
+func (g *GithubClient) MinimizeComment(comment *Comment, classifier string, detailsData *Details) *Error {
+	variables := map[string]interface{}{"id": comment.NodeID, "classifier": classifier}
+	if returnErr := g.graphql("MinimizeComment", minimizeCommentMutation, variables, &struct{}{}, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	comment.IsMinimized = true
+	return &Error{StatusCode: http.StatusOK}
+}
+
+// commentURL returns the REST url of a comment, next to the issues url of its repo
+func commentURL(id int64, detailsData *Details) string {
+	return detailsData.ApiURL + "/comments/" + fmt.Sprint(id)
+}
@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+	"testing"
+)
+
+func TestGithubClientComments(t *testing.T) {
+	// Given an issue
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+
+	// When commenting on it, then the comment is posted
+	comment, returnErr := githubClient.CreateComment(1, &Comment{Body: "hello"}, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if comment.ID == 0 || comment.NodeID == "" || comment.HTMLURL == "" || comment.Body != "hello" {
+		t.Errorf("Expected the posted comment but got %+v", comment)
+	}
+
+	// When editing it, then its body is changed
+	if returnErr = githubClient.EditComment(&Comment{Body: "hello again"}, comment, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	got, returnErr := githubClient.GetComment(comment.ID, detailsData)
+	if returnErr.ErrorCode != nil || got.Body != "hello again" {
+		t.Errorf("Expected the edited comment but got %+v, %v", got, returnErr.ErrorCode)
+	}
+
+	// When minimizing it, then it is hidden as outdated
+	if returnErr = githubClient.MinimizeComment(comment, MinimizeOutdated, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if stored := server.Comments(testRepo, 1); len(stored) != 1 || stored[0].MinimizedReason != MinimizeOutdated {
+		t.Errorf("Expected the comment to be minimized but got %+v", stored)
+	}
+
+	// When deleting it, then it is gone, and deleting it again is not an error
+	if returnErr = githubClient.DeleteComment(comment, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if _, returnErr = githubClient.GetComment(comment.ID, detailsData); returnErr.StatusCode != 404 {
+		t.Errorf("Expected a not found error but got %+v", returnErr)
+	}
+	if returnErr = githubClient.DeleteComment(comment, detailsData); returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if returnErr = githubClient.MinimizeComment(comment, MinimizeOutdated, detailsData); returnErr.StatusCode != 404 {
+		t.Errorf("Expected a not found error but got %+v", returnErr)
+	}
+}
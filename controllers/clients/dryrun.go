@@ -0,0 +1,92 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+)
+
+/* Implementation of DryRunClient - reads from GitHub but never mutates it */
+
+// ErrDryRun is the error of the mutations a DryRunClient doesn't perform
+var ErrDryRun = errors.New("dry run")
+
+// DryRunClient wraps a ClientFrame and refuses every mutation with ErrDryRun, the message of the error tells what
+// the mutation would have done
+type DryRunClient struct {
+	ClientFrame
+}
+
+func (d *DryRunClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
+	return nil, dryRunError("create issue %q", issueData.Title)
+}
+
+func (d *DryRunClient) EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	return dryRunError("edit issue #%d", issue.Number)
+}
+
+func (d *DryRunClient) CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	return dryRunError("close issue #%d", issue.Number)
+}
+
+func (d *DryRunClient) TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error {
+	return dryRunError("transfer issue #%d", issue.Number)
+}
+
+func (d *DryRunClient) SyncProjectItem(issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error) {
+	return nil, dryRunError("add issue #%d to its project", issue.Number)
+}
+
+func (d *DryRunClient) CreateLabel(labelData *Label, detailsData *Details) (*Label, *Error) {
+	return nil, dryRunError("create label %s", labelData.Name)
+}
+
+func (d *DryRunClient) EditLabel(labelData *Label, label *Label, detailsData *Details) *Error {
+	return dryRunError("edit label %s", label.Name)
+}
+
+func (d *DryRunClient) DeleteLabel(label *Label, detailsData *Details) *Error {
+	return dryRunError("delete label %s", label.Name)
+}
+
+func (d *DryRunClient) CreateMilestone(milestoneData *Milestone, detailsData *Details) (*Milestone, *Error) {
+	return nil, dryRunError("create milestone %q", milestoneData.Title)
+}
+
+func (d *DryRunClient) EditMilestone(milestoneData *Milestone, milestone *Milestone, detailsData *Details) *Error {
+	return dryRunError("edit milestone #%d", milestone.Number)
+}
+
+func (d *DryRunClient) DeleteMilestone(milestone *Milestone, detailsData *Details) *Error {
+	return dryRunError("delete milestone #%d", milestone.Number)
+}
+
+func (d *DryRunClient) CreateComment(number int, commentData *Comment, detailsData *Details) (*Comment, *Error) {
+	return nil, dryRunError("comment on issue #%d", number)
+}
+
+func (d *DryRunClient) EditComment(commentData *Comment, comment *Comment, detailsData *Details) *Error {
+	return dryRunError("edit comment %d", comment.ID)
+}
+
+func (d *DryRunClient) DeleteComment(comment *Comment, detailsData *Details) *Error {
+	return dryRunError("delete comment %d", comment.ID)
+}
+
+func (d *DryRunClient) MinimizeComment(comment *Comment, classifier string, detailsData *Details) *Error {
+	return dryRunError("minimize comment %d", comment.ID)
+}
+
+// IsDryRun returns true if returnErr is a mutation refused by a DryRunClient
+func IsDryRun(returnErr *Error) bool {
+	return returnErr != nil && errors.Is(returnErr.ErrorCode, ErrDryRun)
+}
+
+func dryRunError(format string, args ...interface{}) *Error {
+	return &Error{ErrorCode: ErrDryRun, Message: "Dry run, would " + fmt.Sprintf(format, args...)}
+}
+
+func NewDryRunClient(clientFrame ClientFrame) *DryRunClient {
+	return &DryRunClient{
+		ClientFrame: clientFrame,
+	}
+}
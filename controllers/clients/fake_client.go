@@ -3,95 +3,654 @@ package clients
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 )
 
 /* Implementation of FakeClient - "test" */
 
+// FakeClient keeps the issues in memory, so the changes made through it can be inspected
 type FakeClient struct {
-	issues []Issue
-	err    error
+	// Latency is added to every call, to simulate a slow GitHub API
+	Latency time.Duration
+	// Repository is returned by GetRepository with the number of open issues stored, an admin's repo with
+	// issues enabled if nil
+	Repository *Repository
+
+	mu         sync.Mutex
+	issues     []*fakeIssue
+	nextNumber int
+	errs       map[string]error
+	calls      []Call
+	items      map[string]*FakeProjectItem
+	comments   []*FakeComment
+	nextID     int64
+	labels     []*FakeLabel
+	milestones []*FakeMilestone
+}
+
+// FakeLabel is a label stored by the FakeClient, with the repo it belongs to
+type FakeLabel struct {
+	Label
+	apiURL string
+}
+
+// FakeMilestone is a milestone stored by the FakeClient, with the repo it belongs to
+type FakeMilestone struct {
+	Milestone
+	apiURL string
+}
+
+// FakeComment is a comment stored by the FakeClient, with the issue it was posted on
+type FakeComment struct {
+	Comment
+	Number int
+	apiURL string
+}
+
+// FakeProjectItem is an issue on a project board stored by the FakeClient, with the values set on it
+type FakeProjectItem struct {
+	ProjectItem
+	Number  int
+	Project Project
+}
+
+// fakeIssue is an issue stored by the FakeClient, with the url of the repo it belongs to
+type fakeIssue struct {
+	Issue
+	// apiURL is empty for the issues given to NewFakeClient, which belong to every repo
+	apiURL string
+}
+
+// Call structure declaration - a call made to the FakeClient
+type Call struct {
+	Method string
+	Title  string
+	Number int
 }
 
 func (f *FakeClient) InitDataStructs(repo, title, body string) (*Repo, *Issue, *Details) {
-	splitRepo := strings.Split(repo, "/")
-	// Init Repo data
-	owner := splitRepo[1]
-	repoName := splitRepo[0]
-	repoData := Repo{Owner: owner, Repo: repoName}
-	// Init Issue data
-	issueData := Issue{Title: title, Description: body}
-	// Init Details data
-	apiURL := "TestURL"
-	token := "TestToken"
-	detailsData := Details{ApiURL: apiURL, Token: token}
-	return &repoData, &issueData, &detailsData
+	return initDataStructs("fake://github", repo, title, body, "TestToken")
 }
 
 func (f *FakeClient) FindIssue(repoData *Repo, issueData *Issue, detailsData *Details) (*Issue, *Error) {
-	returnErr := Error{}
-	for _, issue := range f.issues {
-		if issue.Title == issueData.Title {
-			return &issue, &returnErr
+	if returnErr := f.begin("FindIssue", issueData.Title, 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	for _, stored := range f.issues {
+		if stored.Title == issueData.Title && stored.inRepo(detailsData) {
+			issue := stored.Issue
+			return &issue, &Error{}
 		}
 	}
-	returnErr.ErrorCode = fmt.Errorf("FindIssue error")
-	returnErr.Message = "Error with find issue"
-	return nil, &returnErr
+	return nil, &Error{}
 }
 
-func (f *FakeClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
-	returnErr := Error{
-		ErrorCode: f.err,
-		Message:   "Error with create issue",
+func (f *FakeClient) GetIssue(repoData *Repo, number int, detailsData *Details) (*Issue, *Error) {
+	if returnErr := f.begin("GetIssue", "", number); returnErr.ErrorCode != nil {
+		return nil, returnErr
 	}
-	if f.err != nil {
-		return &Issue{}, &returnErr
+	defer f.mu.Unlock()
+	stored := f.find(&Issue{Number: number}, detailsData)
+	if stored == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("GetIssue error"), Message: "Error with get issue", StatusCode: 404}
 	}
+	issue := stored.Issue
+	return &issue, &Error{}
+}
+
+func (f *FakeClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
+	if returnErr := f.begin("CreateIssue", issueData.Title, 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
 	newIssue := Issue{
 		Title:               issueData.Title,
 		Description:         issueData.Description,
-		Number:              issueData.Number,
-		State:               issueData.State,
-		LastUpdateTimestamp: issueData.LastUpdateTimestamp,
+		Number:              f.nextNumber,
+		NodeID:              fmt.Sprintf("I_fake_%d", f.nextNumber),
+		State:               "open",
+		LastUpdateTimestamp: timestamp(),
+		Labels:              append([]string(nil), issueData.Labels...),
+		Assignees:           append([]string(nil), issueData.Assignees...),
+		Milestone:           issueData.Milestone,
+		Author:              fakeLogin,
 	}
-	f.issues = append(f.issues, newIssue)
-	return &newIssue, nil
+	newIssue.CreatedAt = newIssue.LastUpdateTimestamp
+	f.nextNumber++
+	f.issues = append(f.issues, &fakeIssue{Issue: newIssue, apiURL: detailsData.ApiURL})
+	return &newIssue, &Error{StatusCode: 201}
 }
 
 func (f *FakeClient) EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
-	returnErr := Error{}
-	for _, issue := range f.issues {
-		if issue.Title == issueData.Title {
-			issue.Description = issueData.Description
-			return &returnErr
+	if returnErr := f.begin("EditIssue", issue.Title, issue.Number); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.find(issue, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("EditIssue error"), Message: "Error with edit issue", StatusCode: 404}
+	}
+	stored.Description = issueData.Description
+	if issueData.Labels != nil {
+		stored.Labels = append([]string(nil), issueData.Labels...)
+	}
+	if issueData.Assignees != nil {
+		stored.Assignees = append([]string(nil), issueData.Assignees...)
+	}
+	if issueData.Milestone != 0 {
+		stored.Milestone = issueData.Milestone
+	}
+	if issueData.State != "" {
+		stored.State = issueData.State
+		stored.StateReason = issueData.StateReason
+		if stored.State == "open" {
+			stored.ClosedAt = ""
+			stored.ClosedBy = ""
+		}
+	}
+	stored.LastUpdateTimestamp = timestamp()
+	*issue = stored.Issue
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	if returnErr := f.begin("CloseIssue", issue.Title, issue.Number); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.find(issue, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("CloseIssue error"), Message: "Error with close issue", StatusCode: 404}
+	}
+	stored.State = "closed"
+	stored.StateReason = issueData.StateReason
+	stored.LastUpdateTimestamp = timestamp()
+	stored.ClosedAt = stored.LastUpdateTimestamp
+	stored.ClosedBy = fakeLogin
+	*issue = stored.Issue
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error {
+	if returnErr := f.begin("TransferIssue", issue.Title, issue.Number); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.find(issue, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("TransferIssue error"), Message: "Error with transfer issue", StatusCode: 404}
+	}
+	// The transferred issue gets a new number, and belongs to the target repo only
+	stored.Number = f.nextNumber
+	f.nextNumber++
+	stored.apiURL = targetData.ApiURL
+	stored.LastUpdateTimestamp = timestamp()
+	*issue = stored.Issue
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) SyncProjectItem(issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error) {
+	if returnErr := f.begin("SyncProjectItem", issue.Title, issue.Number); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	projectID := project.ID
+	if projectID == "" {
+		projectID = fmt.Sprintf("PVT_%s_%d", project.Owner, project.Number)
+	}
+	key := fmt.Sprintf("%s/%s#%d", projectID, detailsData.ApiURL, issue.Number)
+	item, ok := f.items[key]
+	if !ok {
+		item = &FakeProjectItem{ProjectItem: ProjectItem{ID: fmt.Sprintf("PVTI_%d", len(f.items)+1), ProjectID: projectID}, Number: issue.Number}
+		f.items[key] = item
+	}
+	item.Project = *project
+	result := item.ProjectItem
+	return &result, &Error{}
+}
+
+func (f *FakeClient) GetComment(id int64, detailsData *Details) (*Comment, *Error) {
+	if returnErr := f.begin("GetComment", "", 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findComment(id, detailsData)
+	if stored == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("GetComment error"), Message: "Error with get comment", StatusCode: 404}
+	}
+	comment := stored.Comment
+	return &comment, &Error{}
+}
+
+func (f *FakeClient) CreateComment(number int, commentData *Comment, detailsData *Details) (*Comment, *Error) {
+	if returnErr := f.begin("CreateComment", "", number); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.find(&Issue{Number: number}, detailsData)
+	if stored == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("CreateComment error"), Message: "Error with create comment", StatusCode: 404}
+	}
+	newComment := Comment{
+		ID:        f.nextID,
+		NodeID:    fmt.Sprintf("IC_fake_%d", f.nextID),
+		Body:      commentData.Body,
+		UpdatedAt: timestamp(),
+	}
+	stored.Comments++
+	stored.LastCommenter = fakeLogin
+	stored.LastCommentedAt = newComment.UpdatedAt
+	f.nextID++
+	f.comments = append(f.comments, &FakeComment{Comment: newComment, Number: number, apiURL: detailsData.ApiURL})
+	return &newComment, &Error{StatusCode: 201}
+}
+
+func (f *FakeClient) EditComment(commentData *Comment, comment *Comment, detailsData *Details) *Error {
+	if returnErr := f.begin("EditComment", "", 0); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findComment(comment.ID, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("EditComment error"), Message: "Error with edit comment", StatusCode: 404}
+	}
+	stored.Body = commentData.Body
+	stored.UpdatedAt = timestamp()
+	*comment = stored.Comment
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) DeleteComment(comment *Comment, detailsData *Details) *Error {
+	if returnErr := f.begin("DeleteComment", "", 0); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	for i, stored := range f.comments {
+		if stored.ID == comment.ID && stored.apiURL == detailsData.ApiURL {
+			f.comments = append(f.comments[:i], f.comments[i+1:]...)
+			if issue := f.find(&Issue{Number: stored.Number}, detailsData); issue != nil {
+				issue.Comments--
+			}
+			break
+		}
+	}
+	return &Error{StatusCode: 204}
+}
+
+func (f *FakeClient) MinimizeComment(comment *Comment, classifier string, detailsData *Details) *Error {
+	if returnErr := f.begin("MinimizeComment", "", 0); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findComment(comment.ID, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("MinimizeComment error"), Message: "Error with minimize comment", StatusCode: 404}
+	}
+	stored.IsMinimized = true
+	comment.IsMinimized = true
+	return &Error{StatusCode: 200}
+}
+
+// Comments returns a copy of all stored comments
+func (f *FakeClient) Comments() []FakeComment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	comments := make([]FakeComment, 0, len(f.comments))
+	for _, stored := range f.comments {
+		comments = append(comments, *stored)
+	}
+	return comments
+}
+
+func (f *FakeClient) findComment(id int64, detailsData *Details) *FakeComment {
+	for _, stored := range f.comments {
+		if stored.ID == id && stored.apiURL == detailsData.ApiURL {
+			return stored
+		}
+	}
+	return nil
+}
+
+// ProjectItems returns a copy of all issues added to projects
+func (f *FakeClient) ProjectItems() []FakeProjectItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	items := make([]FakeProjectItem, 0, len(f.items))
+	for _, item := range f.items {
+		items = append(items, *item)
+	}
+	return items
+}
+
+func (f *FakeClient) GetUser(detailsData *Details) (*User, *Error) {
+	if returnErr := f.begin("GetUser", "", 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	return &User{Login: fakeLogin}, &Error{StatusCode: 200}
+}
+
+// FailOn makes every following call to method fail with err, a nil err clears the failure
+func (f *FakeClient) FailOn(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+func (f *FakeClient) GetRepository(detailsData *Details) (*Repository, *Error) {
+	if returnErr := f.begin("GetRepository", "", 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	repository := Repository{HasIssues: true, Permission: "admin"}
+	if f.Repository != nil {
+		repository = *f.Repository
+	}
+	owner, name, _ := repoOfDetails(detailsData)
+	repository.FullName = owner + "/" + name
+	repository.OpenIssues = 0
+	for _, stored := range f.issues {
+		if stored.inRepo(detailsData) && stored.State != "closed" {
+			repository.OpenIssues++
 		}
 	}
-	returnErr.ErrorCode = fmt.Errorf("EditIssue error")
-	returnErr.Message = "Error with edit issue"
-	return &returnErr
+	return &repository, &Error{}
 }
 
-func (f *FakeClient) CloseIssue(issue *Issue, issueData *Issue, detailsData *Details) *Error {
-	returnErr := Error{}
+func (f *FakeClient) GetLabel(name string, detailsData *Details) (*Label, *Error) {
+	if returnErr := f.begin("GetLabel", "", 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findLabel(name, detailsData)
+	if stored == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("GetLabel error"), Message: "Error with get label", StatusCode: 404}
+	}
+	label := stored.Label
+	return &label, &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) CreateLabel(labelData *Label, detailsData *Details) (*Label, *Error) {
+	if returnErr := f.begin("CreateLabel", "", 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	if f.findLabel(labelData.Name, detailsData) != nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("CreateLabel error"), Message: "Error with create label, it already exists", StatusCode: 422}
+	}
+	f.labels = append(f.labels, &FakeLabel{Label: *labelData, apiURL: detailsData.ApiURL})
+	label := *labelData
+	return &label, &Error{StatusCode: 201}
+}
+
+func (f *FakeClient) EditLabel(labelData *Label, label *Label, detailsData *Details) *Error {
+	if returnErr := f.begin("EditLabel", "", 0); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findLabel(label.Name, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("EditLabel error"), Message: "Error with edit label", StatusCode: 404}
+	}
+	if other := f.findLabel(labelData.Name, detailsData); other != nil && other != stored {
+		return &Error{ErrorCode: fmt.Errorf("EditLabel error"), Message: "Error with edit label, the new name already exists", StatusCode: 422}
+	}
+	stored.Label = *labelData
+	*label = stored.Label
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) DeleteLabel(label *Label, detailsData *Details) *Error {
+	if returnErr := f.begin("DeleteLabel", "", 0); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	for i, stored := range f.labels {
+		if stored.apiURL == detailsData.ApiURL && strings.EqualFold(stored.Name, label.Name) {
+			f.labels = append(f.labels[:i], f.labels[i+1:]...)
+			return &Error{StatusCode: 204}
+		}
+	}
+	return &Error{StatusCode: 404}
+}
+
+// Labels returns a copy of all stored labels
+func (f *FakeClient) Labels() []Label {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	labels := make([]Label, 0, len(f.labels))
+	for _, stored := range f.labels {
+		labels = append(labels, stored.Label)
+	}
+	return labels
+}
+
+// findLabel returns the stored label of the repo, GitHub ignoring the case of label names
+func (f *FakeClient) findLabel(name string, detailsData *Details) *FakeLabel {
+	for _, stored := range f.labels {
+		if stored.apiURL == detailsData.ApiURL && strings.EqualFold(stored.Name, name) {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (f *FakeClient) FindMilestone(title string, detailsData *Details) (*Milestone, *Error) {
+	if returnErr := f.begin("FindMilestone", title, 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	for _, stored := range f.milestones {
+		if stored.apiURL == detailsData.ApiURL && stored.Title == title {
+			milestone := f.countIssues(stored)
+			return &milestone, &Error{StatusCode: 200}
+		}
+	}
+	return nil, &Error{}
+}
+
+func (f *FakeClient) GetMilestone(number int, detailsData *Details) (*Milestone, *Error) {
+	if returnErr := f.begin("GetMilestone", "", number); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findMilestone(number, detailsData)
+	if stored == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("GetMilestone error"), Message: "Error with get milestone", StatusCode: 404}
+	}
+	milestone := f.countIssues(stored)
+	return &milestone, &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) CreateMilestone(milestoneData *Milestone, detailsData *Details) (*Milestone, *Error) {
+	if returnErr := f.begin("CreateMilestone", milestoneData.Title, 0); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	defer f.mu.Unlock()
+	number := 1
+	for _, stored := range f.milestones {
+		if stored.apiURL == detailsData.ApiURL && stored.Number >= number {
+			number = stored.Number + 1
+		}
+	}
+	milestone := *milestoneData
+	milestone.Number = number
+	if milestone.State == "" {
+		milestone.State = "open"
+	}
+	f.milestones = append(f.milestones, &FakeMilestone{Milestone: milestone, apiURL: detailsData.ApiURL})
+	return &milestone, &Error{StatusCode: 201}
+}
+
+func (f *FakeClient) EditMilestone(milestoneData *Milestone, milestone *Milestone, detailsData *Details) *Error {
+	if returnErr := f.begin("EditMilestone", milestoneData.Title, milestone.Number); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	stored := f.findMilestone(milestone.Number, detailsData)
+	if stored == nil {
+		return &Error{ErrorCode: fmt.Errorf("EditMilestone error"), Message: "Error with edit milestone", StatusCode: 404}
+	}
+	stored.Title = milestoneData.Title
+	stored.Description = milestoneData.Description
+	stored.DueOn = milestoneData.DueOn
+	if milestoneData.State != "" {
+		stored.State = milestoneData.State
+	}
+	*milestone = f.countIssues(stored)
+	return &Error{StatusCode: 200}
+}
+
+func (f *FakeClient) DeleteMilestone(milestone *Milestone, detailsData *Details) *Error {
+	if returnErr := f.begin("DeleteMilestone", milestone.Title, milestone.Number); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	defer f.mu.Unlock()
+	for i, stored := range f.milestones {
+		if stored.apiURL == detailsData.ApiURL && stored.Number == milestone.Number {
+			f.milestones = append(f.milestones[:i], f.milestones[i+1:]...)
+			for _, issue := range f.issues {
+				if issue.inRepo(detailsData) && issue.Milestone == milestone.Number {
+					issue.Milestone = 0
+				}
+			}
+			return &Error{StatusCode: 204}
+		}
+	}
+	return &Error{StatusCode: 404}
+}
+
+// Milestones returns a copy of all stored milestones
+func (f *FakeClient) Milestones() []Milestone {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	milestones := make([]Milestone, 0, len(f.milestones))
+	for _, stored := range f.milestones {
+		milestones = append(milestones, f.countIssues(stored))
+	}
+	return milestones
+}
+
+func (f *FakeClient) findMilestone(number int, detailsData *Details) *FakeMilestone {
+	for _, stored := range f.milestones {
+		if stored.apiURL == detailsData.ApiURL && stored.Number == number {
+			return stored
+		}
+	}
+	return nil
+}
+
+// countIssues returns the stored milestone with the number of its open and closed issues
+func (f *FakeClient) countIssues(stored *FakeMilestone) Milestone {
+	milestone := stored.Milestone
+	milestone.OpenIssues, milestone.ClosedIssues = 0, 0
 	for _, issue := range f.issues {
-		if issue.Title == issueData.Title {
-			issue.State = "closed"
-			return &returnErr
+		if issue.apiURL != stored.apiURL || issue.Milestone != milestone.Number {
+			continue
+		}
+		if issue.State == "closed" {
+			milestone.ClosedIssues++
+		} else {
+			milestone.OpenIssues++
 		}
 	}
-	returnErr.ErrorCode = fmt.Errorf("CloseIssue error")
-	returnErr.Message = "Error with close issue"
-	return &returnErr
+	return milestone
 }
 
+// Calls returns all calls made so far, in order
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call{}, f.calls...)
+}
+
+// CallsTo returns the number of calls made so far to method
+func (f *FakeClient) CallsTo(method string) int {
+	count := 0
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Issues returns a copy of all stored issues
+func (f *FakeClient) Issues() []Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	issues := make([]Issue, 0, len(f.issues))
+	for _, stored := range f.issues {
+		issues = append(issues, stored.Issue)
+	}
+	return issues
+}
+
+// begin records the call, waits for the latency and returns the injected error if there is one.
+// On success the lock is held and has to be released by the caller
+func (f *FakeClient) begin(method, title string, number int) *Error {
+	time.Sleep(f.Latency)
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Title: title, Number: number})
+	if err := f.errs[method]; err != nil {
+		f.mu.Unlock()
+		return &Error{ErrorCode: err, Message: "Error with " + method}
+	}
+	return &Error{}
+}
+
+// find returns the stored issue by its number, or by its title if it has no number
+func (f *FakeClient) find(issue *Issue, detailsData *Details) *fakeIssue {
+	for _, stored := range f.issues {
+		if !stored.inRepo(detailsData) {
+			continue
+		}
+		if (issue.Number != 0 && stored.Number == issue.Number) || (issue.Number == 0 && stored.Title == issue.Title) {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (i *fakeIssue) inRepo(detailsData *Details) bool {
+	return i.apiURL == "" || i.apiURL == detailsData.ApiURL
+}
+
+// fakeLogin is the author of everything done through the FakeClient
+const fakeLogin = "fake-user"
+
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// NewFakeClient returns a FakeClient holding issues, if isSuccessful is false every CreateIssue fails with err
 func NewFakeClient(issues []Issue, isSuccessful bool, err error) *FakeClient {
-	if isSuccessful {
-		return &FakeClient{
-			issues: issues,
+	f := &FakeClient{
+		nextNumber: 1,
+		errs:       map[string]error{},
+		items:      map[string]*FakeProjectItem{},
+		nextID:     1,
+	}
+	for _, issue := range issues {
+		if issue.State == "" {
+			issue.State = "open"
+		}
+		if issue.Number == 0 {
+			issue.Number = f.nextNumber
+		}
+		if issue.Number >= f.nextNumber {
+			f.nextNumber = issue.Number + 1
 		}
+		f.issues = append(f.issues, &fakeIssue{Issue: issue})
 	}
-	return &FakeClient{
-		issues: issues,
-		err:    err,
+	if !isSuccessful && err != nil {
+		f.errs["CreateIssue"] = err
 	}
+	return f
 }
@@ -0,0 +1,480 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Project structure declaration - a Projects (v2) board served by the fake GraphQL API
+type Project struct {
+	ID     string
+	Owner  string
+	Number int
+	Fields []ProjectField
+	Items  []ProjectItem
+}
+
+// ProjectField structure declaration - a field of a project, dataType is one of TEXT, NUMBER, DATE,
+// SINGLE_SELECT and ITERATION
+type ProjectField struct {
+	ID         string
+	Name       string
+	DataType   string
+	Options    []ProjectOption
+	Iterations []ProjectIteration
+}
+
+// ProjectOption structure declaration - an option of a single select field
+type ProjectOption struct {
+	ID   string
+	Name string
+}
+
+// ProjectIteration structure declaration - an iteration of an iteration field, StartDate is formatted as 2006-01-02
+type ProjectIteration struct {
+	ID        string
+	Title     string
+	StartDate string
+	Duration  int
+}
+
+// ProjectItem structure declaration - an issue on a project, with its field values by field id
+type ProjectItem struct {
+	ID        string
+	ContentID string
+	Values    map[string]map[string]interface{}
+}
+
+// AddProject seeds a project, and returns it with the ids of its fields, options and iterations assigned
+func (s *Server) AddProject(project Project) Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := len(s.projects) + 1
+	if project.ID == "" {
+		project.ID = fmt.Sprintf("PVT_%d", index)
+	}
+	for i := range project.Fields {
+		field := &project.Fields[i]
+		if field.ID == "" {
+			field.ID = fmt.Sprintf("PVTF_%d_%d", index, i+1)
+		}
+		for j := range field.Options {
+			if field.Options[j].ID == "" {
+				field.Options[j].ID = fmt.Sprintf("%s_option_%d", field.ID, j+1)
+			}
+		}
+		for j := range field.Iterations {
+			if field.Iterations[j].ID == "" {
+				field.Iterations[j].ID = fmt.Sprintf("%s_iteration_%d", field.ID, j+1)
+			}
+		}
+	}
+	stored := project
+	s.projects = append(s.projects, &stored)
+	return stored
+}
+
+// Project returns a copy of the project with the given id, with its items
+func (s *Server) Project(id string) (Project, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, project := range s.projects {
+		if project.ID == id {
+			copied := *project
+			copied.Items = append([]ProjectItem{}, project.Items...)
+			return copied, true
+		}
+	}
+	return Project{}, false
+}
+
+// graphqlRequest is the payload of a GraphQL request, the fake dispatches by the operation name only
+type graphqlRequest struct {
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (s *Server) graphql(w http.ResponseWriter, req *http.Request, body string) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	payload := graphqlRequest{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	str := func(name string) string {
+		value, _ := payload.Variables[name].(string)
+		return value
+	}
+
+	switch payload.OperationName {
+	case "ProjectByID":
+		var node interface{}
+		if project := s.findProject(str("id")); project != nil {
+			node = projectJSON(project)
+		}
+		writeData(w, map[string]interface{}{"node": node})
+	case "ProjectByNumber":
+		number, _ := payload.Variables["number"].(float64)
+		var projectV2 interface{}
+		for _, project := range s.projects {
+			if strings.EqualFold(project.Owner, str("owner")) && project.Number == int(number) {
+				projectV2 = projectJSON(project)
+			}
+		}
+		if projectV2 == nil {
+			writeGraphQLError(w, "NOT_FOUND", fmt.Sprintf("Could not resolve to a ProjectV2 with the number %d.", int(number)))
+			return
+		}
+		writeData(w, map[string]interface{}{"repositoryOwner": map[string]interface{}{"projectV2": projectV2}})
+	case "IssueProjectItems":
+		nodes := []interface{}{}
+		for _, project := range s.projects {
+			for _, item := range project.Items {
+				if item.ContentID == str("id") {
+					nodes = append(nodes, itemJSON(project, item))
+				}
+			}
+		}
+		writeData(w, map[string]interface{}{"node": map[string]interface{}{"projectItems": map[string]interface{}{"nodes": nodes}}})
+	case "AddProjectItem":
+		project := s.findProject(str("project"))
+		if project == nil {
+			writeGraphQLError(w, "NOT_FOUND", "Could not resolve to a node with the global id of '"+str("project")+"'")
+			return
+		}
+		// Adding an issue that is already on the project returns its existing item, as GitHub does
+		item := findItem(project, func(item *ProjectItem) bool { return item.ContentID == str("content") })
+		if item == nil {
+			project.Items = append(project.Items, ProjectItem{
+				ID:        fmt.Sprintf("PVTI_%s_%d", project.ID, len(project.Items)+1),
+				ContentID: str("content"),
+				Values:    map[string]map[string]interface{}{},
+			})
+			item = &project.Items[len(project.Items)-1]
+		}
+		writeData(w, map[string]interface{}{"addProjectV2ItemById": map[string]interface{}{"item": map[string]interface{}{"id": item.ID}}})
+	case "UpdateProjectItemField":
+		project := s.findProject(str("project"))
+		var item *ProjectItem
+		if project != nil {
+			item = findItem(project, func(item *ProjectItem) bool { return item.ID == str("item") })
+		}
+		value, _ := payload.Variables["value"].(map[string]interface{})
+		if item == nil || value == nil {
+			writeGraphQLError(w, "NOT_FOUND", "Could not resolve to a ProjectV2Item with the id '"+str("item")+"'")
+			return
+		}
+		item.Values[str("field")] = value
+		writeData(w, map[string]interface{}{"updateProjectV2ItemFieldValue": map[string]interface{}{"projectV2Item": map[string]interface{}{"id": item.ID}}})
+	case "RepositoryIssues":
+		s.repositoryIssues(w, str("owner")+"/"+str("name"), str("after"))
+	case "CreateIssue":
+		repo := strings.TrimPrefix(str("repository"), "R_")
+		if str("title") == "" {
+			writeGraphQLError(w, "UNPROCESSABLE", "Title can't be blank")
+			return
+		}
+		now := timestamp()
+		issue := &Issue{
+			Number:    s.nextNumber(repo),
+			Title:     str("title"),
+			Body:      str("body"),
+			State:     "open",
+			Labels:    []Label{},
+			User:      User{Login: s.Login},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		issue.NodeID = fmt.Sprintf("I_%s_%d", strings.Replace(repo, "/", "_", -1), issue.Number)
+		s.issues[repo] = append(s.issues[repo], issue)
+		s.writeIssuePayload(w, "createIssue", repo, issue)
+	case "UpdateIssue", "CloseIssue", "ReopenIssue":
+		repo, issue := s.findIssueByNodeID(str("id"))
+		if issue == nil {
+			writeGraphQLError(w, "NOT_FOUND", "Could not resolve to a node with the global id of '"+str("id")+"'")
+			return
+		}
+		now := timestamp()
+		switch payload.OperationName {
+		case "UpdateIssue":
+			if body, ok := payload.Variables["body"].(string); ok {
+				issue.Body = body
+			}
+		case "CloseIssue":
+			reason := strings.ToLower(str("stateReason"))
+			if reason == "" {
+				reason = "completed"
+			}
+			if issue.State != "closed" {
+				issue.ClosedAt = &now
+				issue.ClosedBy = &User{Login: s.Login}
+			}
+			issue.State, issue.StateReason = "closed", &reason
+		case "ReopenIssue":
+			reason := "reopened"
+			issue.State, issue.StateReason, issue.ClosedAt, issue.ClosedBy = "open", &reason, nil, nil
+		}
+		issue.UpdatedAt = now
+		s.writeIssuePayload(w, strings.ToLower(payload.OperationName[:1])+payload.OperationName[1:], repo, issue)
+	case "Repository":
+		repo := strings.ToLower(str("owner") + "/" + str("name"))
+		permission := s.Permission
+		if permission == "" {
+			permission = "ADMIN"
+		}
+		open := 0
+		for _, issue := range s.issues[repo] {
+			if issue.State == "open" {
+				open++
+			}
+		}
+		writeData(w, map[string]interface{}{"repository": map[string]interface{}{
+			"nameWithOwner":    str("owner") + "/" + str("name"),
+			"isPrivate":        false,
+			"hasIssuesEnabled": true,
+			"viewerPermission": permission,
+			"issues":           map[string]interface{}{"totalCount": open},
+		}})
+	case "RepositoryID":
+		writeData(w, map[string]interface{}{"repository": map[string]interface{}{"id": "R_" + strings.ToLower(str("owner")+"/"+str("name"))}})
+	case "TransferIssue":
+		repo, issue := s.findIssueByNodeID(str("id"))
+		target := strings.TrimPrefix(str("repository"), "R_")
+		if issue == nil {
+			writeGraphQLError(w, "NOT_FOUND", "Could not resolve to a node with the global id of '"+str("id")+"'")
+			return
+		}
+		// The issue keeps its node id and gets the next number of the target repo
+		for i, moved := range s.issues[repo] {
+			if moved == issue {
+				s.issues[repo] = append(s.issues[repo][:i], s.issues[repo][i+1:]...)
+				break
+			}
+		}
+		issue.Number = s.nextNumber(target)
+		issue.UpdatedAt = timestamp()
+		s.issues[target] = append(s.issues[target], issue)
+		s.writeIssuePayload(w, "transferIssue", target, issue)
+	case "MinimizeComment":
+		for _, comments := range s.comments {
+			for _, comment := range comments {
+				if comment.NodeID == str("id") {
+					comment.MinimizedReason = str("classifier")
+					writeData(w, map[string]interface{}{"minimizeComment": map[string]interface{}{"minimizedComment": map[string]interface{}{"isMinimized": true}}})
+					return
+				}
+			}
+		}
+		writeGraphQLError(w, "NOT_FOUND", "Could not resolve to a node with the global id of '"+str("id")+"'")
+	default:
+		writeGraphQLError(w, "UNKNOWN_OPERATION", "Operation "+payload.OperationName+" is not supported by the fake")
+	}
+}
+
+// graphqlPageSize is the number of issues in a page of the RepositoryIssues query
+const graphqlPageSize = 100
+
+// repositoryIssues serves a page of the issues of a repo, newest first, the cursor is the offset of the page
+func (s *Server) repositoryIssues(w http.ResponseWriter, repo, after string) {
+	repo = strings.ToLower(repo)
+	issues := s.issues[repo]
+	start := 0
+	if after != "" {
+		fmt.Sscanf(after, "%d", &start)
+	}
+	end := start + graphqlPageSize
+	if end > len(issues) {
+		end = len(issues)
+	}
+	nodes := []interface{}{}
+	for i := len(issues) - 1 - start; i >= len(issues)-end; i-- {
+		nodes = append(nodes, s.issueJSON(repo, issues[i]))
+	}
+	writeData(w, map[string]interface{}{
+		"repository": map[string]interface{}{
+			"id": "R_" + repo,
+			"issues": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"hasNextPage": end < len(issues), "endCursor": fmt.Sprint(end)},
+				"nodes":    nodes,
+			},
+		},
+		"rateLimit": map[string]interface{}{
+			"cost":      1,
+			"remaining": s.RateLimit - s.used,
+			"resetAt":   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+func (s *Server) writeIssuePayload(w http.ResponseWriter, field, repo string, issue *Issue) {
+	writeData(w, map[string]interface{}{field: map[string]interface{}{"issue": s.issueJSON(repo, issue)}})
+}
+
+// issueJSON returns the issue as selected by the IssueFields fragment
+func (s *Server) issueJSON(repo string, issue *Issue) map[string]interface{} {
+	labels := []interface{}{}
+	for _, label := range issue.Labels {
+		labels = append(labels, map[string]interface{}{"name": label.Name})
+	}
+	assignees := []interface{}{}
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, map[string]interface{}{"login": assignee.Login})
+	}
+	items := []interface{}{}
+	for _, project := range s.projects {
+		for _, item := range project.Items {
+			if item.ContentID == issue.NodeID {
+				items = append(items, map[string]interface{}{"id": item.ID, "project": map[string]interface{}{"id": project.ID}})
+			}
+		}
+	}
+	var milestone interface{}
+	if issue.Milestone != nil {
+		milestone = map[string]interface{}{"number": issue.Milestone.Number}
+	}
+	var stateReason interface{}
+	if issue.StateReason != nil {
+		stateReason = strings.ToUpper(*issue.StateReason)
+	}
+	lastComments := []interface{}{}
+	if comments := s.comments[commentsKey(repo, issue.Number)]; len(comments) > 0 {
+		last := comments[len(comments)-1]
+		lastComments = append(lastComments, map[string]interface{}{"author": map[string]interface{}{"login": last.User.Login}, "createdAt": last.CreatedAt})
+	}
+	closedEvents := []interface{}{}
+	if issue.ClosedBy != nil {
+		closedEvents = append(closedEvents, map[string]interface{}{"actor": map[string]interface{}{"login": issue.ClosedBy.Login}})
+	}
+	reactionGroups := []interface{}{}
+	if r := issue.Reactions; r != nil {
+		for content, count := range map[string]int{
+			"THUMBS_UP": r.PlusOne, "THUMBS_DOWN": r.MinusOne, "LAUGH": r.Laugh, "HOORAY": r.Hooray,
+			"CONFUSED": r.Confused, "HEART": r.Heart, "ROCKET": r.Rocket, "EYES": r.Eyes,
+		} {
+			reactionGroups = append(reactionGroups, map[string]interface{}{"content": content, "reactors": map[string]interface{}{"totalCount": count}})
+		}
+	}
+	return map[string]interface{}{
+		"id":             issue.NodeID,
+		"number":         issue.Number,
+		"title":          issue.Title,
+		"body":           issue.Body,
+		"state":          strings.ToUpper(issue.State),
+		"stateReason":    stateReason,
+		"updatedAt":      issue.UpdatedAt,
+		"closedAt":       issue.ClosedAt,
+		"labels":         map[string]interface{}{"nodes": labels},
+		"assignees":      map[string]interface{}{"nodes": assignees},
+		"milestone":      milestone,
+		"author":         map[string]interface{}{"login": issue.User.Login},
+		"createdAt":      issue.CreatedAt,
+		"timelineItems":  map[string]interface{}{"nodes": closedEvents},
+		"comments":       map[string]interface{}{"totalCount": issue.Comments, "nodes": lastComments},
+		"reactionGroups": reactionGroups,
+		"projectItems":   map[string]interface{}{"nodes": items},
+	}
+}
+
+// nextNumber returns the number of the next issue of the repo
+func (s *Server) nextNumber(repo string) int {
+	number := 1
+	for _, issue := range s.issues[repo] {
+		if issue.Number >= number {
+			number = issue.Number + 1
+		}
+	}
+	return number
+}
+
+// findIssueByNodeID returns the issue with the node id, and its repo
+func (s *Server) findIssueByNodeID(id string) (string, *Issue) {
+	for repo, issues := range s.issues {
+		for _, issue := range issues {
+			if issue.NodeID == id {
+				return repo, issue
+			}
+		}
+	}
+	return "", nil
+}
+
+func (s *Server) findProject(id string) *Project {
+	for _, project := range s.projects {
+		if project.ID == id {
+			return project
+		}
+	}
+	return nil
+}
+
+func findItem(project *Project, match func(*ProjectItem) bool) *ProjectItem {
+	for i := range project.Items {
+		if match(&project.Items[i]) {
+			return &project.Items[i]
+		}
+	}
+	return nil
+}
+
+func projectJSON(project *Project) map[string]interface{} {
+	fields := []interface{}{}
+	for _, field := range project.Fields {
+		node := map[string]interface{}{"id": field.ID, "name": field.Name, "dataType": field.DataType}
+		switch field.DataType {
+		case "SINGLE_SELECT":
+			options := []interface{}{}
+			for _, option := range field.Options {
+				options = append(options, map[string]interface{}{"id": option.ID, "name": option.Name})
+			}
+			node["options"] = options
+		case "ITERATION":
+			iterations := []interface{}{}
+			for _, iteration := range field.Iterations {
+				iterations = append(iterations, map[string]interface{}{
+					"id": iteration.ID, "title": iteration.Title, "startDate": iteration.StartDate, "duration": iteration.Duration,
+				})
+			}
+			node["configuration"] = map[string]interface{}{"iterations": iterations}
+		}
+		fields = append(fields, node)
+	}
+	return map[string]interface{}{"id": project.ID, "fields": map[string]interface{}{"nodes": fields}}
+}
+
+func itemJSON(project *Project, item ProjectItem) map[string]interface{} {
+	values := []interface{}{}
+	for fieldID, value := range item.Values {
+		node := map[string]interface{}{"field": map[string]interface{}{"id": fieldID}}
+		for key, v := range value {
+			// The inputs are named after the values, except for the option of a single select field
+			if key == "singleSelectOptionId" {
+				key = "optionId"
+			}
+			node[key] = v
+		}
+		values = append(values, node)
+	}
+	return map[string]interface{}{
+		"id":          item.ID,
+		"project":     map[string]interface{}{"id": project.ID},
+		"fieldValues": map[string]interface{}{"nodes": values},
+	}
+}
+
+func writeData(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// writeGraphQLError responds as GitHub does to a failed query, with status 200 and the error in the body
+func writeGraphQLError(w http.ResponseWriter, errorType, message string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":   nil,
+		"errors": []interface{}{map[string]interface{}{"type": errorType, "message": message}},
+	})
+}
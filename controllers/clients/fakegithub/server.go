@@ -0,0 +1,972 @@
+// Package fakegithub implements an in-process fake of the GitHub issues REST API and of the issues and projects
+// GraphQL API for tests
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Issue structure declaration - an issue as served by the fake GitHub API
+type Issue struct {
+	Number    int        `json:"number"`
+	NodeID    string     `json:"node_id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	Labels    []Label    `json:"labels"`
+	Assignees []User     `json:"assignees"`
+	Milestone *Milestone `json:"milestone"`
+	Comments  int        `json:"comments"`
+	User      User       `json:"user"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+	ClosedAt  *string    `json:"closed_at"`
+	// StateReason is completed or not_planned for a closed issue, reopened for a reopened one
+	StateReason *string    `json:"state_reason"`
+	ClosedBy    *User      `json:"closed_by"`
+	Reactions   *Reactions `json:"reactions"`
+}
+
+// Reactions structure declaration - the reaction totals of an issue
+type Reactions struct {
+	TotalCount int `json:"total_count"`
+	PlusOne    int `json:"+1"`
+	MinusOne   int `json:"-1"`
+	Laugh      int `json:"laugh"`
+	Hooray     int `json:"hooray"`
+	Confused   int `json:"confused"`
+	Heart      int `json:"heart"`
+	Rocket     int `json:"rocket"`
+	Eyes       int `json:"eyes"`
+}
+
+// Label structure declaration - a label of a repo, or attached to an issue
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Milestone structure declaration - a milestone of a repo
+type Milestone struct {
+	Number       int     `json:"number"`
+	Title        string  `json:"title"`
+	Description  *string `json:"description"`
+	State        string  `json:"state"`
+	DueOn        *string `json:"due_on"`
+	OpenIssues   int     `json:"open_issues"`
+	ClosedIssues int     `json:"closed_issues"`
+	HTMLURL      string  `json:"html_url"`
+}
+
+// User structure declaration - the author of an issue or a comment
+type User struct {
+	Login string `json:"login"`
+}
+
+// Comment structure declaration - a comment on an issue
+type Comment struct {
+	ID        int64  `json:"id"`
+	NodeID    string `json:"node_id"`
+	Body      string `json:"body"`
+	HTMLURL   string `json:"html_url"`
+	User      User   `json:"user"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// MinimizedReason is the classifier the comment was hidden for, served by the GraphQL API only
+	MinimizedReason string `json:"-"`
+}
+
+// Fault makes the server fail matching requests with the given status
+type Fault struct {
+	// Method to fail, any method if empty
+	Method string
+	// PathSuffix the request path has to end with, any path if empty
+	PathSuffix string
+	// Status to respond with
+	Status int
+	// Times is the number of requests to fail, 0 fails all of them
+	Times int
+}
+
+// Request structure declaration - a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+}
+
+// Server is a fake GitHub API, the zero value of its fields accepts every request
+type Server struct {
+	*httptest.Server
+
+	// Token that requests have to be authorized with, any token is accepted if empty
+	Token string
+	// Login of the user the token belongs to
+	Login string
+	// RateLimit is the number of requests served before responding with a rate limit error
+	RateLimit int
+	// Permission is the viewerPermission of the user on every repo, ADMIN if empty
+	Permission string
+
+	mu            sync.Mutex
+	issues        map[string][]*Issue
+	comments      map[string][]*Comment
+	labels        map[string][]*Label
+	milestones    map[string][]*Milestone
+	projects      []*Project
+	faults        []*Fault
+	requests      []Request
+	used          int
+	nextCommentID int64
+}
+
+func NewServer() *Server {
+	s := &Server{
+		Login:         "fake-user",
+		RateLimit:     5000,
+		issues:        map[string][]*Issue{},
+		comments:      map[string][]*Comment{},
+		labels:        map[string][]*Label{},
+		milestones:    map[string][]*Milestone{},
+		nextCommentID: 1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddIssue seeds an issue in the repo ("owner/repo"), and returns it with its assigned number
+func (s *Server) AddIssue(repo string, issue Issue) Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo = strings.ToLower(repo)
+	now := timestamp()
+	issue.Number = s.nextNumber(repo)
+	issue.NodeID = fmt.Sprintf("I_%s_%d", strings.Replace(repo, "/", "_", -1), issue.Number)
+	if issue.State == "" {
+		issue.State = "open"
+	}
+	if issue.User.Login == "" {
+		issue.User.Login = s.Login
+	}
+	if issue.CreatedAt == "" {
+		issue.CreatedAt = now
+	}
+	if issue.UpdatedAt == "" {
+		issue.UpdatedAt = now
+	}
+	if issue.Labels == nil {
+		issue.Labels = []Label{}
+	}
+	stored := issue
+	s.issues[repo] = append(s.issues[repo], &stored)
+	return stored
+}
+
+// Issues returns a copy of all issues of the repo ("owner/repo")
+func (s *Server) Issues(repo string) []Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issues := []Issue{}
+	for _, issue := range s.issues[strings.ToLower(repo)] {
+		issues = append(issues, *issue)
+	}
+	return issues
+}
+
+// Comments returns a copy of all comments on an issue of the repo ("owner/repo")
+func (s *Server) Comments(repo string, number int) []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comments := []Comment{}
+	for _, comment := range s.comments[commentsKey(strings.ToLower(repo), number)] {
+		comments = append(comments, *comment)
+	}
+	return comments
+}
+
+// AddLabel seeds a label in the repo ("owner/repo")
+func (s *Server) AddLabel(repo string, label Label) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo = strings.ToLower(repo)
+	s.labels[repo] = append(s.labels[repo], &label)
+}
+
+// Labels returns a copy of all labels of the repo ("owner/repo")
+func (s *Server) Labels(repo string) []Label {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	labels := []Label{}
+	for _, label := range s.labels[strings.ToLower(repo)] {
+		labels = append(labels, *label)
+	}
+	return labels
+}
+
+// Milestones returns a copy of all milestones of the repo ("owner/repo")
+func (s *Server) Milestones(repo string) []Milestone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo = strings.ToLower(repo)
+	milestones := []Milestone{}
+	for _, milestone := range s.milestones[repo] {
+		milestones = append(milestones, s.milestoneJSON(repo, milestone))
+	}
+	return milestones
+}
+
+// AddFault injects a failure for the matching requests
+func (s *Server) AddFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// Requests returns all requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rawBody, _ := ioutil.ReadAll(req.Body)
+	body := string(rawBody)
+	s.requests = append(s.requests, Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+
+	if s.Token != "" {
+		auth := req.Header.Get("Authorization")
+		if auth != "token "+s.Token && auth != "Bearer "+s.Token {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+	}
+
+	// Rate limit headers are sent with every response
+	reset := time.Now().Add(time.Hour).Unix()
+	remaining := s.RateLimit - s.used
+	if remaining <= 0 {
+		setRateLimitHeaders(w, s.RateLimit, 0, s.used, reset)
+		writeError(w, http.StatusForbidden, "API rate limit exceeded for "+s.Login+".")
+		return
+	}
+	s.used++
+	setRateLimitHeaders(w, s.RateLimit, remaining-1, s.used, reset)
+
+	for _, fault := range s.faults {
+		if fault.Times < 0 {
+			continue
+		}
+		if (fault.Method == "" || fault.Method == req.Method) && strings.HasSuffix(req.URL.Path, fault.PathSuffix) {
+			if fault.Times > 0 {
+				fault.Times--
+				if fault.Times == 0 {
+					fault.Times = -1
+				}
+			}
+			writeError(w, fault.Status, http.StatusText(fault.Status))
+			return
+		}
+	}
+
+	s.route(w, req, body)
+}
+
+// route dispatches /repos/{owner}/{repo}/issues[/{number}[/comments|/labels[/{name}]]],
+// /repos/{owner}/{repo}/issues/comments/{id}, /repos/{owner}/{repo}/labels[/{name}],
+// /repos/{owner}/{repo}/milestones[/{number}], /user and /graphql
+func (s *Server) route(w http.ResponseWriter, req *http.Request, body string) {
+	if req.URL.Path == "/graphql" {
+		s.graphql(w, req, body)
+		return
+	}
+	if req.URL.Path == "/user" && req.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, map[string]string{"login": s.Login})
+		return
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "repos" || (parts[3] != "issues" && parts[3] != "labels" && parts[3] != "milestones") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	repo := strings.ToLower(parts[1] + "/" + parts[2])
+	rest := parts[4:]
+
+	switch parts[3] {
+	case "labels":
+		s.repoLabels(w, req, repo, rest, body)
+		return
+	case "milestones":
+		s.repoMilestones(w, req, repo, rest, body)
+		return
+	}
+
+	if len(rest) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			s.listIssues(w, req, repo)
+		case http.MethodPost:
+			s.createIssue(w, repo, body)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	if rest[0] == "comments" && len(rest) == 2 {
+		id, err := strconv.ParseInt(rest[1], 10, 64)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		s.comment(w, req, repo, id, body)
+		return
+	}
+
+	number, err := strconv.Atoi(rest[0])
+	issue := s.findIssue(repo, number)
+	if err != nil || issue == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch {
+	case len(rest) == 1 && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, issue)
+	case len(rest) == 1 && req.Method == http.MethodPatch:
+		s.editIssue(w, repo, issue, body)
+	case len(rest) == 2 && rest[1] == "comments":
+		s.issueComments(w, req, repo, issue, body)
+	case len(rest) >= 2 && rest[1] == "labels":
+		s.issueLabels(w, req, issue, rest[2:], body)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) listIssues(w http.ResponseWriter, req *http.Request, repo string) {
+	query := req.URL.Query()
+	state := query.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	matching := []*Issue{}
+	for _, issue := range s.issues[repo] {
+		if state == "all" || issue.State == state {
+			matching = append(matching, issue)
+		}
+	}
+	last := (len(matching) + perPage - 1) / perPage
+	if last == 0 {
+		last = 1
+	}
+	start := (page - 1) * perPage
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + perPage
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	// Link header as GitHub sends it, pointing back to this server
+	var links []string
+	pageURL := func(p int) string {
+		query.Set("page", strconv.Itoa(p))
+		query.Set("per_page", strconv.Itoa(perPage))
+		return fmt.Sprintf("<%s%s?%s>", s.URL, req.URL.Path, query.Encode())
+	}
+	if page < last {
+		links = append(links, pageURL(page+1)+`; rel="next"`, pageURL(last)+`; rel="last"`)
+	}
+	if page > 1 {
+		links = append(links, pageURL(1)+`; rel="first"`, pageURL(page-1)+`; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	writeJSON(w, http.StatusOK, matching[start:end])
+}
+
+// issueRequest is the payload of the create and edit requests
+type issueRequest struct {
+	Title       *string   `json:"title"`
+	Body        *string   `json:"body"`
+	State       *string   `json:"state"`
+	StateReason *string   `json:"state_reason"`
+	Labels      *[]string `json:"labels"`
+	Assignees   *[]string `json:"assignees"`
+	Milestone   *int      `json:"milestone"`
+}
+
+func (s *Server) createIssue(w http.ResponseWriter, repo, body string) {
+	payload := issueRequest{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if payload.Title == nil || *payload.Title == "" || (payload.Milestone != nil && s.findMilestone(repo, *payload.Milestone) == nil) {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	now := timestamp()
+	issue := &Issue{
+		Number:    s.nextNumber(repo),
+		Title:     *payload.Title,
+		State:     "open",
+		Labels:    []Label{},
+		User:      User{Login: s.Login},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	issue.NodeID = fmt.Sprintf("I_%s_%d", strings.Replace(repo, "/", "_", -1), issue.Number)
+	if payload.Body != nil {
+		issue.Body = *payload.Body
+	}
+	if payload.Labels != nil {
+		for _, name := range *payload.Labels {
+			issue.Labels = append(issue.Labels, Label{Name: name})
+		}
+	}
+	if payload.Assignees != nil {
+		for _, login := range *payload.Assignees {
+			issue.Assignees = append(issue.Assignees, User{Login: login})
+		}
+	}
+	if payload.Milestone != nil {
+		issue.Milestone = s.findMilestone(repo, *payload.Milestone)
+	}
+	s.issues[repo] = append(s.issues[repo], issue)
+	writeJSON(w, http.StatusCreated, issue)
+}
+
+func (s *Server) editIssue(w http.ResponseWriter, repo string, issue *Issue, body string) {
+	payload := issueRequest{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if payload.Milestone != nil && s.findMilestone(repo, *payload.Milestone) == nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	now := timestamp()
+	if payload.Title != nil {
+		issue.Title = *payload.Title
+	}
+	if payload.Body != nil {
+		issue.Body = *payload.Body
+	}
+	if payload.State != nil {
+		switch *payload.State {
+		case "closed":
+			if issue.State != "closed" {
+				issue.ClosedAt = &now
+				issue.ClosedBy = &User{Login: s.Login}
+			}
+		case "open":
+			issue.ClosedAt = nil
+			issue.ClosedBy = nil
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		issue.State = *payload.State
+		switch {
+		case payload.StateReason != nil:
+			issue.StateReason = payload.StateReason
+		case issue.State == "closed":
+			reason := "completed"
+			issue.StateReason = &reason
+		default:
+			reason := "reopened"
+			issue.StateReason = &reason
+		}
+	}
+	if payload.Labels != nil {
+		issue.Labels = []Label{}
+		for _, name := range *payload.Labels {
+			issue.Labels = append(issue.Labels, Label{Name: name})
+		}
+	}
+	if payload.Assignees != nil {
+		issue.Assignees = []User{}
+		for _, login := range *payload.Assignees {
+			issue.Assignees = append(issue.Assignees, User{Login: login})
+		}
+	}
+	if payload.Milestone != nil {
+		issue.Milestone = s.findMilestone(repo, *payload.Milestone)
+	}
+	issue.UpdatedAt = now
+	writeJSON(w, http.StatusOK, issue)
+}
+
+func (s *Server) issueComments(w http.ResponseWriter, req *http.Request, repo string, issue *Issue, body string) {
+	key := commentsKey(repo, issue.Number)
+	switch req.Method {
+	case http.MethodGet:
+		comments := s.comments[key]
+		if comments == nil {
+			comments = []*Comment{}
+		}
+		// Paginated only if asked to, the Link header is left out
+		if perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page")); perPage > 0 {
+			page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+			if page <= 0 {
+				page = 1
+			}
+			start := (page - 1) * perPage
+			if start > len(comments) {
+				start = len(comments)
+			}
+			end := start + perPage
+			if end > len(comments) {
+				end = len(comments)
+			}
+			comments = comments[start:end]
+		}
+		writeJSON(w, http.StatusOK, comments)
+	case http.MethodPost:
+		payload := struct {
+			Body string `json:"body"`
+		}{}
+		if err := json.Unmarshal([]byte(body), &payload); err != nil || payload.Body == "" {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		now := timestamp()
+		comment := &Comment{
+			ID:        s.nextCommentID,
+			NodeID:    fmt.Sprintf("IC_%d", s.nextCommentID),
+			Body:      payload.Body,
+			HTMLURL:   fmt.Sprintf("https://github.com/%s/issues/%d#issuecomment-%d", repo, issue.Number, s.nextCommentID),
+			User:      User{Login: s.Login},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		s.nextCommentID++
+		s.comments[key] = append(s.comments[key], comment)
+		issue.Comments++
+		writeJSON(w, http.StatusCreated, comment)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (s *Server) comment(w http.ResponseWriter, req *http.Request, repo string, id int64, body string) {
+	for key, comments := range s.comments {
+		if !strings.HasPrefix(key, repo+"#") {
+			continue
+		}
+		for i, comment := range comments {
+			if comment.ID != id {
+				continue
+			}
+			switch req.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, comment)
+			case http.MethodPatch:
+				payload := struct {
+					Body string `json:"body"`
+				}{}
+				if err := json.Unmarshal([]byte(body), &payload); err != nil || payload.Body == "" {
+					writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+					return
+				}
+				comment.Body = payload.Body
+				comment.UpdatedAt = timestamp()
+				writeJSON(w, http.StatusOK, comment)
+			case http.MethodDelete:
+				s.comments[key] = append(comments[:i], comments[i+1:]...)
+				number, _ := strconv.Atoi(key[len(repo)+1:])
+				if issue := s.findIssue(repo, number); issue != nil {
+					issue.Comments--
+				}
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+			}
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) issueLabels(w http.ResponseWriter, req *http.Request, issue *Issue, name []string, body string) {
+	switch {
+	case len(name) == 0 && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, issue.Labels)
+	case len(name) == 0 && (req.Method == http.MethodPost || req.Method == http.MethodPut):
+		payload := struct {
+			Labels []string `json:"labels"`
+		}{}
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+			return
+		}
+		if req.Method == http.MethodPut {
+			issue.Labels = []Label{}
+		}
+		for _, label := range payload.Labels {
+			if !hasLabel(issue, label) {
+				issue.Labels = append(issue.Labels, Label{Name: label})
+			}
+		}
+		issue.UpdatedAt = timestamp()
+		writeJSON(w, http.StatusOK, issue.Labels)
+	case len(name) == 1 && req.Method == http.MethodDelete:
+		label, _ := url.PathUnescape(name[0])
+		for i, existing := range issue.Labels {
+			if existing.Name == label {
+				issue.Labels = append(issue.Labels[:i], issue.Labels[i+1:]...)
+				issue.UpdatedAt = timestamp()
+				writeJSON(w, http.StatusOK, issue.Labels)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Label does not exist")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// labelRequest is the payload of the create and edit label requests
+type labelRequest struct {
+	Name        *string `json:"name"`
+	NewName     *string `json:"new_name"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+func (s *Server) repoLabels(w http.ResponseWriter, req *http.Request, repo string, name []string, body string) {
+	if len(name) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			labels := []*Label{}
+			writeJSON(w, http.StatusOK, append(labels, s.labels[repo]...))
+		case http.MethodPost:
+			payload := labelRequest{}
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+				return
+			}
+			if payload.Name == nil || *payload.Name == "" || s.findLabel(repo, *payload.Name) != nil {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			label := &Label{Name: *payload.Name}
+			setLabel(label, payload)
+			s.labels[repo] = append(s.labels[repo], label)
+			writeJSON(w, http.StatusCreated, label)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	label := s.findLabel(repo, strings.Join(name, "/"))
+	if label == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, label)
+	case http.MethodPatch:
+		payload := labelRequest{}
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+			return
+		}
+		if payload.NewName != nil && *payload.NewName != "" {
+			if other := s.findLabel(repo, *payload.NewName); other != nil && other != label {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			s.renameLabel(repo, label.Name, *payload.NewName)
+			label.Name = *payload.NewName
+		}
+		setLabel(label, payload)
+		writeJSON(w, http.StatusOK, label)
+	case http.MethodDelete:
+		for i, existing := range s.labels[repo] {
+			if existing == label {
+				s.labels[repo] = append(s.labels[repo][:i], s.labels[repo][i+1:]...)
+			}
+		}
+		s.renameLabel(repo, label.Name, "")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func setLabel(label *Label, payload labelRequest) {
+	if payload.Color != nil {
+		label.Color = strings.TrimPrefix(*payload.Color, "#")
+	}
+	if payload.Description != nil {
+		label.Description = *payload.Description
+	}
+}
+
+// renameLabel renames the label on the issues of the repo, or removes it from them if newName is empty
+func (s *Server) renameLabel(repo, name, newName string) {
+	for _, issue := range s.issues[repo] {
+		for i, existing := range issue.Labels {
+			if !strings.EqualFold(existing.Name, name) {
+				continue
+			}
+			if newName == "" {
+				issue.Labels = append(issue.Labels[:i], issue.Labels[i+1:]...)
+			} else {
+				issue.Labels[i].Name = newName
+			}
+			break
+		}
+	}
+}
+
+// findLabel returns the label of the repo, ignoring the case of its name as GitHub does
+func (s *Server) findLabel(repo, name string) *Label {
+	for _, label := range s.labels[repo] {
+		if strings.EqualFold(label.Name, name) {
+			return label
+		}
+	}
+	return nil
+}
+
+// milestoneRequest is the payload of the create and edit milestone requests
+type milestoneRequest struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	State       *string `json:"state"`
+	DueOn       *string `json:"due_on"`
+}
+
+func (s *Server) repoMilestones(w http.ResponseWriter, req *http.Request, repo string, rest []string, body string) {
+	if len(rest) == 0 {
+		switch req.Method {
+		case http.MethodGet:
+			state := req.URL.Query().Get("state")
+			if state == "" {
+				state = "open"
+			}
+			milestones := []Milestone{}
+			for _, milestone := range s.milestones[repo] {
+				if state == "all" || milestone.State == state {
+					milestones = append(milestones, s.milestoneJSON(repo, milestone))
+				}
+			}
+			writeJSON(w, http.StatusOK, milestones)
+		case http.MethodPost:
+			payload := milestoneRequest{}
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+				return
+			}
+			if payload.Title == nil || *payload.Title == "" || s.milestoneTitled(repo, *payload.Title) != nil {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			number := 1
+			for _, milestone := range s.milestones[repo] {
+				if milestone.Number >= number {
+					number = milestone.Number + 1
+				}
+			}
+			milestone := &Milestone{Number: number, State: "open", HTMLURL: fmt.Sprintf("%s/%s/milestone/%d", s.URL, repo, number)}
+			if !setMilestone(milestone, payload) {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+			s.milestones[repo] = append(s.milestones[repo], milestone)
+			writeJSON(w, http.StatusCreated, s.milestoneJSON(repo, milestone))
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	number, err := strconv.Atoi(rest[0])
+	milestone := s.findMilestone(repo, number)
+	if err != nil || len(rest) != 1 || milestone == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.milestoneJSON(repo, milestone))
+	case http.MethodPatch:
+		payload := milestoneRequest{}
+		if err := json.Unmarshal([]byte(body), &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+			return
+		}
+		if payload.Title != nil {
+			if other := s.milestoneTitled(repo, *payload.Title); other != nil && other != milestone {
+				writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+		}
+		if !setMilestone(milestone, payload) {
+			writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.milestoneJSON(repo, milestone))
+	case http.MethodDelete:
+		for i, existing := range s.milestones[repo] {
+			if existing == milestone {
+				s.milestones[repo] = append(s.milestones[repo][:i], s.milestones[repo][i+1:]...)
+			}
+		}
+		for _, issue := range s.issues[repo] {
+			if issue.Milestone == milestone {
+				issue.Milestone = nil
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// setMilestone applies the payload to the milestone, a null due_on removes the due date. It returns false if
+// the payload is invalid
+func setMilestone(milestone *Milestone, payload milestoneRequest) bool {
+	if payload.Title != nil {
+		milestone.Title = *payload.Title
+	}
+	if payload.Description != nil {
+		milestone.Description = payload.Description
+	}
+	if payload.State != nil {
+		if *payload.State != "open" && *payload.State != "closed" {
+			return false
+		}
+		milestone.State = *payload.State
+	}
+	milestone.DueOn = nil
+	if payload.DueOn != nil {
+		if _, err := time.Parse(time.RFC3339, *payload.DueOn); err != nil {
+			return false
+		}
+		milestone.DueOn = payload.DueOn
+	}
+	return true
+}
+
+// milestoneJSON returns a copy of the milestone with the number of its open and closed issues
+func (s *Server) milestoneJSON(repo string, milestone *Milestone) Milestone {
+	counted := *milestone
+	counted.OpenIssues, counted.ClosedIssues = 0, 0
+	for _, issue := range s.issues[repo] {
+		switch {
+		case issue.Milestone != milestone:
+		case issue.State == "closed":
+			counted.ClosedIssues++
+		default:
+			counted.OpenIssues++
+		}
+	}
+	return counted
+}
+
+func (s *Server) findMilestone(repo string, number int) *Milestone {
+	for _, milestone := range s.milestones[repo] {
+		if milestone.Number == number {
+			return milestone
+		}
+	}
+	return nil
+}
+
+func (s *Server) milestoneTitled(repo, title string) *Milestone {
+	for _, milestone := range s.milestones[repo] {
+		if milestone.Title == title {
+			return milestone
+		}
+	}
+	return nil
+}
+
+func (s *Server) findIssue(repo string, number int) *Issue {
+	for _, issue := range s.issues[repo] {
+		if issue.Number == number {
+			return issue
+		}
+	}
+	return nil
+}
+
+func hasLabel(issue *Issue, name string) bool {
+	for _, label := range issue.Labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func commentsKey(repo string, number int) string {
+	return repo + "#" + strconv.Itoa(number)
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, limit, remaining, used int, reset int64) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -14,136 +14,266 @@ import (
 
 type GithubClient struct {
 	HttpClient http.Client
+	// BaseURL represents the root of the GitHub REST API
+	BaseURL string
+	// EnterpriseHosts represents the GitHub Enterprise Server hosts the repos may be on besides the one of BaseURL,
+	// with their port if it isn't the default one
+	EnterpriseHosts []string
 	//Token      string
 }
 
 // InitDataStructs initializes issueData & detailsData
 func (g *GithubClient) InitDataStructs(repo, title, body string) (*Repo, *Issue, *Details) {
-	// Init Repo data
-	splitRepo := strings.Split(repo, "/")
-	owner := splitRepo[1]
-	repoName := splitRepo[0]
-	repoData := Repo{Owner: owner, Repo: repoName}
-
-	// Init Issue data
-	issueData := Issue{Title: title, Description: body}
-
-	// Init Details data
-	apiURL := "https://api.github.com/repos/" + repo + "/issues"
-	token := os.Getenv("TOKEN")
-	detailsData := Details{ApiURL: apiURL, Token: token}
-
-	return &repoData, &issueData, &detailsData
+	return initDataStructs(g.BaseURL, repo, title, body, os.Getenv("TOKEN"))
 }
 
 func (g *GithubClient) FindIssue(repoData *Repo, issueData *Issue, detailsData *Details) (*Issue, *Error) {
-	apiURL := detailsData.ApiURL + "?state=all"
-	// API request for all repository's issues
-	jsonData, _ := json.Marshal(&repoData)
-	// Creating client to set custom headers for Authorization
-	client := g.HttpClient
-	req, _ := http.NewRequest("GET", apiURL, bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "token "+detailsData.Token)
-	resp, err := client.Do(req)
-	returnErr := Error{}
-	if err != nil {
-		returnErr = Error{ErrorCode: err, Message: "GET request from GitHub API failed with error: \n" + err.Error()}
-		return nil, &returnErr
+	// API request for all repository's issues, page by page
+	apiURL := detailsData.ApiURL + "?state=all&per_page=100"
+	for apiURL != "" {
+		body, resp, returnErr := g.doRequest("GET", apiURL, nil, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, responseError("Listing GitHub issues failed with response: \n", resp, body)
+		}
+		// Create array with all repository's issues
+		var allIssues []Issue
+		err := json.Unmarshal(body, &allIssues)
+		if err != nil {
+			returnErr = &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body)}
+			return nil, returnErr
+		}
+		// If we found the issue, we will return it. Otherwise, continue to the next page
+		for _, issue := range allIssues {
+			if issue.Title == issueData.Title {
+				if issue.Comments > 0 {
+					if returnErr = g.lastComment(&issue, detailsData); returnErr.ErrorCode != nil {
+						return nil, returnErr
+					}
+				}
+				return &issue, returnErr
+			}
+		}
+		apiURL = nextPage(resp)
 	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-	// Create array with all repository's issues
-	var allIssues []Issue
-	err = json.Unmarshal(body, &allIssues)
+	return nil, &Error{}
+}
+
+// GetIssue returns the issue by its number, a missing issue is an error
+func (g *GithubClient) GetIssue(repoData *Repo, number int, detailsData *Details) (*Issue, *Error) {
+	issueApiURL := detailsData.ApiURL + "/" + fmt.Sprint(number)
+	body, resp, returnErr := g.doRequest("GET", issueApiURL, nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("Getting GitHub issue failed with response: \n", resp, body)
+	}
+	var issue *Issue
+	err := json.Unmarshal(body, &issue)
 	if err != nil {
-		fmt.Println(string(body))
-		returnErr = Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body)}
-		return nil, &returnErr
-	}
-	// If we found the issue, we will return it. Otherwise, return nil
-	for _, issue := range allIssues {
-		if issue.Title == issueData.Title {
-			return &issue, &returnErr
+		returnErr = &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body)}
+		return nil, returnErr
+	}
+	if issue.Comments > 0 {
+		if returnErr = g.lastComment(issue, detailsData); returnErr.ErrorCode != nil {
+			return nil, returnErr
 		}
 	}
-	return nil, &returnErr
+	return issue, returnErr
+}
+
+// lastComment fills the author and time of the latest comment, the last page of a single comment per page
+func (g *GithubClient) lastComment(issue *Issue, detailsData *Details) *Error {
+	apiURL := fmt.Sprintf("%s/%d/comments?per_page=1&page=%d", detailsData.ApiURL, issue.Number, issue.Comments)
+	body, resp, returnErr := g.doRequest("GET", apiURL, nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("Listing GitHub comments failed with response: \n", resp, body)
+	}
+	var comments []struct {
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		CreatedAt string `json:"created_at"`
+	}
+	if err := json.Unmarshal(body, &comments); err != nil {
+		return &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body)}
+	}
+	if len(comments) > 0 {
+		issue.LastCommenter = comments[0].User.Login
+		issue.LastCommentedAt = comments[0].CreatedAt
+	}
+	return &Error{}
 }
 
 func (g *GithubClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
-	apiURL := detailsData.ApiURL
-	// Make it json
-	jsonData, _ := json.Marshal(issueData)
-	// Creating client to set custom headers for Authorization
-	client := g.HttpClient
-	req, _ := http.NewRequest("POST", apiURL, bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "token "+detailsData.Token)
-	resp, err := client.Do(req)
-	returnErr := Error{}
-	if err != nil {
-		returnErr = Error{ErrorCode: err, Message: "POST request from GitHub API failed with error: \n" + err.Error()}
-		return nil, &returnErr
+	body, resp, returnErr := g.doRequest("POST", detailsData.ApiURL, newIssueRequest(issueData, issueData), detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
 	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		returnErr = Error{ErrorCode: err, Message: "Creating GitHub issue failed with response: \n" + string(body)}
-		return nil, &returnErr
+		return nil, responseError("Creating GitHub issue failed with response: \n", resp, body)
 	}
 	var issue *Issue
-	issueBody, _ := ioutil.ReadAll(resp.Body)
-	err = json.Unmarshal(issueBody, &issue)
-	return issue, &returnErr
+	err := json.Unmarshal(body, &issue)
+	if err != nil {
+		returnErr = &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+		return nil, returnErr
+	}
+	returnErr.StatusCode = resp.StatusCode
+	return issue, returnErr
 }
 
 func (g *GithubClient) EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
 	issue.Description = issueData.Description
+	// A desired state reopens a closed issue
+	if issueData.State != "" {
+		issue.State = issueData.State
+		issue.StateReason = issueData.StateReason
+	}
 	issueApiURL := detailsData.ApiURL + "/" + fmt.Sprint(issue.Number)
-	jsonData, _ := json.Marshal(issue)
 	// Now update
-	client := g.HttpClient
-	req, _ := http.NewRequest("PATCH", issueApiURL, bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "token "+detailsData.Token)
-	resp, err := client.Do(req)
-	returnErr := Error{}
-	if err != nil {
-		returnErr = Error{ErrorCode: err, Message: "PATCH request from GitHub API failed with error: \n" + err.Error()}
-		return &returnErr
+	body, resp, returnErr := g.doRequest("PATCH", issueApiURL, newIssueRequest(issue, issueData), detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
 	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		returnErr = Error{ErrorCode: err, Message: "Editing GitHub issue failed with response: \n" + string(body)}
-		return &returnErr
+		return responseError("Editing GitHub issue failed with response: \n", resp, body)
 	}
-	return &returnErr
+	json.Unmarshal(body, issue)
+	returnErr.StatusCode = resp.StatusCode
+	return returnErr
 }
 
 func (g *GithubClient) CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
 	issueApiURL := detailsData.ApiURL + "/" + fmt.Sprint(issue.Number)
 	issue.State = "closed"
-	jsonData, _ := json.Marshal(issue)
+	issue.StateReason = issueData.StateReason
 	// Now update
+	body, resp, returnErr := g.doRequest("PATCH", issueApiURL, issue, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("Closing GitHub issue failed with error: \n", resp, body)
+	}
+	json.Unmarshal(body, issue)
+	returnErr.StatusCode = resp.StatusCode
+	return returnErr
+}
+
+// issueRequest is the payload of the create and edit requests, the labels, assignees and milestone are sent
+// only if they are managed
+type issueRequest struct {
+	*Issue
+	Labels    *[]string `json:"labels,omitempty"`
+	Assignees *[]string `json:"assignees,omitempty"`
+	Milestone int       `json:"milestone,omitempty"`
+}
+
+// newIssueRequest returns the payload setting issue, with the labels, assignees and milestone of issueData if it
+// has any
+func newIssueRequest(issue *Issue, issueData *Issue) issueRequest {
+	payload := issueRequest{Issue: issue, Milestone: issueData.Milestone}
+	if issueData.Labels != nil {
+		labels := issueData.Labels
+		payload.Labels = &labels
+	}
+	if issueData.Assignees != nil {
+		assignees := issueData.Assignees
+		payload.Assignees = &assignees
+	}
+	return payload
+}
+
+// doRequest sends an authorized request to the GitHub API, with payload encoded as json if given,
+// and returns the response with its body already read
+func (g *GithubClient) doRequest(method, apiURL string, payload interface{}, detailsData *Details) ([]byte, *http.Response, *Error) {
+	var reqBody *bytes.Reader
+	if payload != nil {
+		jsonData, _ := json.Marshal(payload)
+		reqBody = bytes.NewReader(jsonData)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+	// Creating client to set custom headers for Authorization
 	client := g.HttpClient
-	req, _ := http.NewRequest("PATCH", issueApiURL, bytes.NewReader(jsonData))
-	req.Header.Set("Authorization", "token "+detailsData.Token)
+	req, err := http.NewRequest(method, apiURL, reqBody)
+	if err != nil {
+		return nil, nil, &Error{ErrorCode: err, Message: method + " request from GitHub API failed with error: \n" + err.Error()}
+	}
+	baseHost := sameHost(apiURL, g.BaseURL)
+	if !baseHost && !g.enterpriseHost(req.URL.Host) {
+		return nil, nil, &Error{
+			ErrorCode:  fmt.Errorf("host %s is not an allowed GitHub Enterprise host", req.URL.Host),
+			Message:    "Host " + req.URL.Host + " is not an allowed GitHub Enterprise host, add it to --enterprise-hosts",
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// The token of the operator never leaves the host of BaseURL
+	if detailsData.Token != "" && (baseHost || detailsData.Token != os.Getenv("TOKEN")) {
+		req.Header.Set("Authorization", "token "+detailsData.Token)
+	}
 	resp, err := client.Do(req)
-	returnErr := Error{}
 	if err != nil {
-		returnErr = Error{ErrorCode: err, Message: "PATCH request from GitHub API failed with error: \n" + err.Error()}
-		return &returnErr
+		return nil, nil, &Error{ErrorCode: err, Message: method + " request from GitHub API failed with error: \n" + err.Error()}
 	}
 	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		returnErr = Error{ErrorCode: err, Message: "Closing GitHub issue failed with error: \n" + string(body)}
-		return &returnErr
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &Error{ErrorCode: err, Message: "Reading GitHub API response failed with error: \n" + err.Error()}
+	}
+	return body, resp, &Error{}
+}
+
+// enterpriseHost returns true if host is one of EnterpriseHosts
+func (g *GithubClient) enterpriseHost(host string) bool {
+	for _, allowed := range g.EnterpriseHosts {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseError builds the error for an unexpected response status, telling rate limiting apart
+func responseError(message string, resp *http.Response, body []byte) *Error {
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		message = "GitHub API rate limit exceeded, resets at " + resp.Header.Get("X-RateLimit-Reset") + ": \n"
+	}
+	return &Error{
+		ErrorCode:  fmt.Errorf("GitHub API responded with status %d", resp.StatusCode),
+		Message:    message + string(body),
+		StatusCode: resp.StatusCode,
+	}
+}
+
+// nextPage returns the url of the next page from the Link header, or an empty string on the last page
+func nextPage(resp *http.Response) string {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) != `rel="next"` {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(parts[0]), "<>")
 	}
-	return &returnErr
+	return ""
 }
 
 func NewGithubClient() *GithubClient {
 	return &GithubClient{
 		HttpClient: http.Client{},
+		BaseURL:    "https://api.github.com",
 		//Token:      os.Getenv("TOKEN"),
 	}
 }
@@ -0,0 +1,335 @@
+package clients
+
+import (
+	"fmt"
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testRepo = "arielireni/Issues-Example"
+
+// newTestGithubClient returns a GithubClient talking to a fresh fake GitHub server
+func newTestGithubClient(t *testing.T) (*GithubClient, *fakegithub.Server) {
+	server := fakegithub.NewServer()
+	t.Cleanup(server.Close)
+	githubClient := NewGithubClient()
+	githubClient.BaseURL = server.URL
+	return githubClient, server
+}
+
+func TestGithubClientFindIssue(t *testing.T) {
+	// Given a repo with more issues than fit in a single page
+	githubClient, server := newTestGithubClient(t)
+	for i := 1; i <= 250; i++ {
+		server.AddIssue(testRepo, fakegithub.Issue{Title: fmt.Sprintf("title%d", i), Body: "body"})
+	}
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "closed", State: "closed"})
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title240", "")
+
+	// When finding an issue on the last page, then it is found
+	issue, returnErr := githubClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue == nil || issue.Number != 240 || issue.Description != "body" || issue.State != "open" {
+		t.Errorf("Expected issue 240 but got %+v", issue)
+	}
+
+	// When finding a closed issue, then it is found as well
+	issueData.Title = "closed"
+	issue, _ = githubClient.FindIssue(repoData, issueData, detailsData)
+	if issue == nil || issue.State != "closed" {
+		t.Errorf("Expected the closed issue but got %+v", issue)
+	}
+
+	// When finding a missing issue, then nil is returned without error
+	issueData.Title = "missing"
+	issue, returnErr = githubClient.FindIssue(repoData, issueData, detailsData)
+	if issue != nil || returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil issue and no error but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+}
+
+func TestGithubClientGetIssue(t *testing.T) {
+	// Given an existing issue
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1", Body: "body"})
+	repoData, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+
+	// When getting it by its number, then it is returned
+	issue, returnErr := githubClient.GetIssue(repoData, 1, detailsData)
+	if returnErr.ErrorCode != nil || issue == nil || issue.Title != "title1" {
+		t.Errorf("Expected issue title1 but got %+v, %+v", issue, returnErr)
+	}
+
+	// When getting a missing issue, then the error has the not found status
+	_, returnErr = githubClient.GetIssue(repoData, 2, detailsData)
+	if returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected not found error but got %+v", returnErr)
+	}
+}
+
+func TestGithubClientHeaders(t *testing.T) {
+	// Given a server requiring a token
+	githubClient, server := newTestGithubClient(t)
+	server.Token = "secret"
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "body")
+	detailsData.Token = "secret"
+
+	// When creating an issue
+	if _, returnErr := githubClient.CreateIssue(issueData, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	githubClient.FindIssue(repoData, issueData, detailsData)
+
+	// Then every request is authorized and asks for the v3 API
+	for _, req := range server.Requests() {
+		if req.Header.Get("Authorization") != "token secret" {
+			t.Errorf("Expected token authorization but got %q", req.Header.Get("Authorization"))
+		}
+		if req.Header.Get("Accept") != "application/vnd.github.v3+json" {
+			t.Errorf("Expected v3 accept header but got %q", req.Header.Get("Accept"))
+		}
+		if req.Method == http.MethodPost && req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected json content type but got %q", req.Header.Get("Content-Type"))
+		}
+	}
+
+	// When using a wrong token, then the request fails with the response status
+	detailsData.Token = "wrong"
+	_, returnErr := githubClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected unauthorized error but got %+v", returnErr)
+	}
+}
+
+func TestGithubClientCreateIssue(t *testing.T) {
+	// Given an empty repo
+	githubClient, server := newTestGithubClient(t)
+	_, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "body")
+
+	// When creating an issue, then the created issue is decoded from the response
+	issue, returnErr := githubClient.CreateIssue(issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue == nil || issue.Number != 1 || issue.State != "open" || issue.LastUpdateTimestamp == "" {
+		t.Errorf("Expected created issue 1 but got %+v", issue)
+	}
+	if returnErr.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status %d but got %d", http.StatusCreated, returnErr.StatusCode)
+	}
+	if issues := server.Issues(testRepo); len(issues) != 1 || issues[0].Body != "body" {
+		t.Errorf("Expected a single issue with the body but got %+v", issues)
+	}
+}
+
+func TestGithubClientEditIssue(t *testing.T) {
+	// Given an existing issue
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1", Body: "old"})
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "new")
+	issue, _ := githubClient.FindIssue(repoData, issueData, detailsData)
+
+	// When editing its description, then the real issue is updated
+	if returnErr := githubClient.EditIssue(issueData, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issues := server.Issues(testRepo); issues[0].Body != "new" {
+		t.Errorf("Expected body %q but got %q", "new", issues[0].Body)
+	}
+}
+
+func TestGithubClientLabelsAndAssignees(t *testing.T) {
+	githubClient, server := newTestGithubClient(t)
+	_, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "body")
+
+	// When creating an issue without labels and assignees, then the payload leaves them out
+	issue, returnErr := githubClient.CreateIssue(issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if requests := server.Requests(); strings.Contains(requests[len(requests)-1].Body, "labels") {
+		t.Errorf("Expected no labels in the request but got %s", requests[len(requests)-1].Body)
+	}
+
+	// When editing it with labels and assignees, then the real issue gets them
+	issueData.Labels = []string{"bug"}
+	issueData.Assignees = []string{"octocat"}
+	if returnErr = githubClient.EditIssue(issueData, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	stored := server.Issues(testRepo)[0]
+	if len(stored.Labels) != 1 || stored.Labels[0].Name != "bug" || len(stored.Assignees) != 1 || stored.Assignees[0].Login != "octocat" {
+		t.Errorf("Expected the labels and assignees on the real issue but got %+v", stored)
+	}
+	if len(issue.Labels) != 1 || len(issue.Assignees) != 1 {
+		t.Errorf("Expected the edited issue to hold them but got %+v", issue)
+	}
+}
+
+func TestGithubClientCloseIssue(t *testing.T) {
+	// Given an existing issue
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "")
+	issue, _ := githubClient.FindIssue(repoData, issueData, detailsData)
+
+	// When closing it, then the real issue is closed
+	if returnErr := githubClient.CloseIssue(issueData, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issues := server.Issues(testRepo); issues[0].State != "closed" || issues[0].ClosedAt == nil {
+		t.Errorf("Expected a closed issue but got %+v", issues[0])
+	}
+}
+
+func TestGithubClientTransferIssue(t *testing.T) {
+	// Given an issue in another repo than the target one
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "first"})
+	server.AddIssue("arielireni/Old-Issues", fakegithub.Issue{Title: "title1"})
+	repoData, issueData, detailsData := githubClient.InitDataStructs("arielireni/Old-Issues", "title1", "")
+	issue, _ := githubClient.FindIssue(repoData, issueData, detailsData)
+	_, _, targetData := githubClient.InitDataStructs(testRepo, "title1", "")
+
+	// When transferring it, then it moves with the next number of the target repo
+	if returnErr := githubClient.TransferIssue(issue, detailsData, targetData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue.Number != 2 || len(server.Issues("arielireni/Old-Issues")) != 0 || len(server.Issues(testRepo)) != 2 {
+		t.Errorf("Expected issue #2 in the target repo but got %+v", issue)
+	}
+}
+
+func TestGithubClientErrors(t *testing.T) {
+	// Given a server failing every mutation once
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	server.AddFault(fakegithub.Fault{Method: http.MethodPost, Status: http.StatusInternalServerError, Times: 1})
+	server.AddFault(fakegithub.Fault{Method: http.MethodPatch, Status: http.StatusUnprocessableEntity, Times: 2})
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "new")
+	issue, _ := githubClient.FindIssue(repoData, issueData, detailsData)
+
+	// Then every failure is reported with the response status
+	if _, returnErr := githubClient.CreateIssue(issueData, detailsData); returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected create to fail with %d but got %+v", http.StatusInternalServerError, returnErr)
+	}
+	if returnErr := githubClient.EditIssue(issueData, issue, detailsData); returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected edit to fail with %d but got %+v", http.StatusUnprocessableEntity, returnErr)
+	}
+	if returnErr := githubClient.CloseIssue(issueData, issue, detailsData); returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected close to fail with %d but got %+v", http.StatusUnprocessableEntity, returnErr)
+	}
+
+	// When the faults are used up, then the requests succeed again
+	if _, returnErr := githubClient.CreateIssue(issueData, detailsData); returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil but got error: %s", returnErr.Message)
+	}
+}
+
+func TestGithubClientRateLimit(t *testing.T) {
+	// Given a server that serves a single request
+	githubClient, server := newTestGithubClient(t)
+	server.RateLimit = 1
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "")
+	githubClient.FindIssue(repoData, issueData, detailsData)
+
+	// When the rate limit is exceeded, then the error says so
+	_, returnErr := githubClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode == nil || returnErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected forbidden error but got %+v", returnErr)
+	}
+	if !strings.Contains(returnErr.Message, "rate limit exceeded") {
+		t.Errorf("Expected a rate limit message but got %q", returnErr.Message)
+	}
+}
+
+func TestGithubClientActivity(t *testing.T) {
+	// Given a closed issue with labels, assignees, reactions and comments
+	githubClient, server := newTestGithubClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{
+		Title:     "title1",
+		User:      fakegithub.User{Login: "octocat"},
+		Labels:    []fakegithub.Label{{Name: "bug"}},
+		Assignees: []fakegithub.User{{Login: "hubot"}},
+		Reactions: &fakegithub.Reactions{TotalCount: 3, PlusOne: 2, Heart: 1},
+	})
+	repoData, issueData, detailsData := githubClient.InitDataStructs(testRepo, "title1", "")
+	githubClient.CreateComment(1, &Comment{Body: "first"}, detailsData)
+	githubClient.CreateComment(1, &Comment{Body: "second"}, detailsData)
+	issue, _ := githubClient.FindIssue(repoData, issueData, detailsData)
+	githubClient.CloseIssue(issueData, issue, detailsData)
+
+	// When getting it, then the activity is read from its payload and its last comment
+	issue, returnErr := githubClient.GetIssue(repoData, 1, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue.Author != "octocat" || issue.CreatedAt == "" || issue.ClosedBy != "fake-user" || issue.ClosedAt == "" {
+		t.Errorf("Expected the author and closer but got %+v", issue)
+	}
+	if issue.Comments != 2 || issue.LastCommenter != "fake-user" || issue.LastCommentedAt == "" {
+		t.Errorf("Expected 2 comments and the last commenter but got %+v", issue)
+	}
+	if issue.Reactions == nil || issue.Reactions.TotalCount != 3 || issue.Reactions.PlusOne != 2 || issue.Reactions.Heart != 1 {
+		t.Errorf("Expected the reactions but got %+v", issue.Reactions)
+	}
+	if len(issue.Labels) != 1 || issue.Labels[0] != "bug" || len(issue.Assignees) != 1 || issue.Assignees[0] != "hubot" {
+		t.Errorf("Expected the labels and assignees but got %v, %v", issue.Labels, issue.Assignees)
+	}
+
+	// When finding it by title, then its last comment is read too
+	found, returnErr := githubClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if found.Comments != 2 || found.LastCommenter != "fake-user" || found.LastCommentedAt != issue.LastCommentedAt {
+		t.Errorf("Expected 2 comments and the last commenter but got %+v", found)
+	}
+
+	// When reopening it, then it has no closer anymore
+	reopen := *issueData
+	reopen.State = "open"
+	if returnErr = githubClient.EditIssue(&reopen, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue.ClosedBy != "" || issue.Author != "octocat" {
+		t.Errorf("Expected no closer but got %+v", issue)
+	}
+}
+
+func TestGithubClientEnterpriseHosts(t *testing.T) {
+	// Given the token of the operator and a repo on a host other than the one of BaseURL
+	os.Setenv("TOKEN", "operator-token")
+	t.Cleanup(func() { os.Unsetenv("TOKEN") })
+	githubClient, server := newTestGithubClient(t)
+	githubClient.BaseURL = "https://api.github.com"
+	host := strings.TrimPrefix(server.URL, "http://")
+	repoData, _, detailsData := githubClient.InitDataStructs(server.URL+"/arielireni/Issues-Example", "", "")
+	if detailsData.Token != "" {
+		t.Errorf("Expected no token for the enterprise repo but got %q", detailsData.Token)
+	}
+
+	// When the host isn't allowed, then no request is sent
+	if _, returnErr := githubClient.GetIssue(repoData, 1, detailsData); returnErr.ErrorCode == nil || !strings.Contains(returnErr.Message, "--enterprise-hosts") {
+		t.Errorf("Expected the host to be refused but got %+v", returnErr)
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Fatalf("Expected no requests but got %+v", requests)
+	}
+
+	// When it's allowed, then the token of the operator is never sent there, the credentials of the repo are
+	githubClient.EnterpriseHosts = []string{host}
+	detailsData.Token = "operator-token"
+	githubClient.GetIssue(repoData, 1, detailsData)
+	detailsData.Token = "team-token"
+	githubClient.GetIssue(repoData, 1, detailsData)
+	requests := server.Requests()
+	if len(requests) != 2 || requests[0].Header.Get("Authorization") != "" || requests[1].Header.Get("Authorization") != "token team-token" {
+		t.Errorf("Expected only the team token to be sent but got %+v", requests)
+	}
+}
@@ -0,0 +1,675 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Implementation of GraphQLClient - "production", through the GitHub GraphQL API */
+
+// GraphQLClient fetches all issues of a repo, with their labels, assignees, comments count and project items,
+// in one paginated query, and serves the following calls for the repo from it until CacheTTL passes.
+// Comments are managed through the REST requests of the embedded GithubClient
+type GraphQLClient struct {
+	GithubClient
+	// CacheTTL represents how long the issues of a repo are reused, before they are queried again
+	CacheTTL time.Duration
+	// MinRateLimitRemaining represents the points kept in reserve, requests costing more fail until the reset
+	MinRateLimitRemaining int
+
+	mu        sync.Mutex
+	repos     map[string]*repoSnapshot
+	remaining int
+	resetAt   time.Time
+	now       func() time.Time
+}
+
+// repoSnapshot holds the issues of a repo as last queried, its lock is held while the repo is queried or mutated
+// so concurrent calls for the same repo share a single query
+type repoSnapshot struct {
+	mu      sync.Mutex
+	id      string
+	issues  []Issue
+	fetched time.Time
+}
+
+// Page sizes of the issues query, the cost of a page is the number of nodes it may return divided by 100
+const (
+	issuesPageSize       = 100
+	labelsPageSize       = 20
+	assigneesPageSize    = 10
+	projectItemsPageSize = 10
+	// The last comment and the closed event add a node each
+	issuesQueryCost = (issuesPageSize + issuesPageSize*(labelsPageSize+assigneesPageSize+projectItemsPageSize+2) + 99) / 100
+	mutationCost    = 1
+)
+
+// projectRequestCosts holds the cost of the requests of SyncProjectItem by operation, the items of an issue asking
+// for 50 field values each
+var projectRequestCosts = map[string]int{
+	"ProjectByID":            1,
+	"ProjectByNumber":        1,
+	"IssueProjectItems":      (50 + 50*50 + 99) / 100,
+	"AddProjectItem":         mutationCost,
+	"UpdateProjectItemField": mutationCost,
+}
+
+// GraphQL documents of the issues API
+var (
+	issueFieldsFragment = fmt.Sprintf(`fragment IssueFields on Issue {
+  id number title body state stateReason createdAt updatedAt closedAt
+  author { login }
+  timelineItems(last: 1, itemTypes: [CLOSED_EVENT]) { nodes { ... on ClosedEvent { actor { login } } } }
+  labels(first: %d) { nodes { name } }
+  assignees(first: %d) { nodes { login } }
+  milestone { number }
+  comments(last: 1) { totalCount nodes { author { login } createdAt } }
+  reactionGroups { content reactors { totalCount } }
+  projectItems(first: %d) { nodes { id project { id } } }
+}`, labelsPageSize, assigneesPageSize, projectItemsPageSize)
+	repositoryIssuesQuery = fmt.Sprintf(`query RepositoryIssues($owner: String!, $name: String!, $after: String) {
+  repository(owner: $owner, name: $name) {
+    id
+    issues(first: %d, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes { ...IssueFields }
+    }
+  }
+  rateLimit { cost remaining resetAt }
+}
+`, issuesPageSize) + issueFieldsFragment
+	createIssueMutation = `mutation CreateIssue($repository: ID!, $title: String!, $body: String) {
+  createIssue(input: {repositoryId: $repository, title: $title, body: $body}) { issue { ...IssueFields } }
+}
+` + issueFieldsFragment
+	updateIssueMutation = `mutation UpdateIssue($id: ID!, $body: String) {
+  updateIssue(input: {id: $id, body: $body}) { issue { ...IssueFields } }
+}
+` + issueFieldsFragment
+	closeIssueMutation = `mutation CloseIssue($id: ID!, $stateReason: IssueClosedStateReason) {
+  closeIssue(input: {issueId: $id, stateReason: $stateReason}) { issue { ...IssueFields } }
+}
+` + issueFieldsFragment
+	reopenIssueMutation = `mutation ReopenIssue($id: ID!) {
+  reopenIssue(input: {issueId: $id}) { issue { ...IssueFields } }
+}
+` + issueFieldsFragment
+)
+
+// graphqlIssue is an issue as returned by the IssueFields fragment
+type graphqlIssue struct {
+	ID          string  `json:"id"`
+	Number      int     `json:"number"`
+	Title       string  `json:"title"`
+	Body        string  `json:"body"`
+	State       string  `json:"state"`
+	StateReason *string `json:"stateReason"`
+	UpdatedAt   string  `json:"updatedAt"`
+	ClosedAt    *string `json:"closedAt"`
+	Labels      struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignees struct {
+		Nodes []struct {
+			Login string `json:"login"`
+		} `json:"nodes"`
+	} `json:"assignees"`
+	Milestone *struct {
+		Number int `json:"number"`
+	} `json:"milestone"`
+	CreatedAt string `json:"createdAt"`
+	Author    *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	TimelineItems struct {
+		Nodes []struct {
+			Actor *struct {
+				Login string `json:"login"`
+			} `json:"actor"`
+		} `json:"nodes"`
+	} `json:"timelineItems"`
+	Comments struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			CreatedAt string `json:"createdAt"`
+		} `json:"nodes"`
+	} `json:"comments"`
+	ReactionGroups []struct {
+		Content  string `json:"content"`
+		Reactors struct {
+			TotalCount int `json:"totalCount"`
+		} `json:"reactors"`
+	} `json:"reactionGroups"`
+	ProjectItems struct {
+		Nodes []struct {
+			ID      string `json:"id"`
+			Project struct {
+				ID string `json:"id"`
+			} `json:"project"`
+		} `json:"nodes"`
+	} `json:"projectItems"`
+}
+
+// issue converts the GraphQL issue to the REST representation used by the reconciler
+func (i *graphqlIssue) issue() Issue {
+	issue := Issue{
+		Title:               i.Title,
+		Description:         i.Body,
+		Number:              i.Number,
+		NodeID:              i.ID,
+		State:               strings.ToLower(i.State),
+		LastUpdateTimestamp: i.UpdatedAt,
+		Comments:            i.Comments.TotalCount,
+	}
+	if i.StateReason != nil {
+		issue.StateReason = strings.ToLower(*i.StateReason)
+	}
+	if i.ClosedAt != nil {
+		issue.ClosedAt = *i.ClosedAt
+	}
+	for _, label := range i.Labels.Nodes {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	for _, assignee := range i.Assignees.Nodes {
+		issue.Assignees = append(issue.Assignees, assignee.Login)
+	}
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Number
+	}
+	issue.CreatedAt = i.CreatedAt
+	if i.Author != nil {
+		issue.Author = i.Author.Login
+	}
+	if nodes := i.TimelineItems.Nodes; issue.State == "closed" && len(nodes) > 0 && nodes[0].Actor != nil {
+		issue.ClosedBy = nodes[0].Actor.Login
+	}
+	if nodes := i.Comments.Nodes; len(nodes) > 0 {
+		if nodes[0].Author != nil {
+			issue.LastCommenter = nodes[0].Author.Login
+		}
+		issue.LastCommentedAt = nodes[0].CreatedAt
+	}
+	if len(i.ReactionGroups) > 0 {
+		issue.Reactions = &Reactions{}
+		for _, group := range i.ReactionGroups {
+			if count := issue.Reactions.of(group.Content); count != nil {
+				*count = group.Reactors.TotalCount
+				issue.Reactions.TotalCount += group.Reactors.TotalCount
+			}
+		}
+	}
+	for _, item := range i.ProjectItems.Nodes {
+		issue.ProjectItems = append(issue.ProjectItems, ProjectItem{ID: item.ID, ProjectID: item.Project.ID})
+	}
+	return issue
+}
+
+// of returns the count of the reactions with a GraphQL ReactionContent, nil for an unknown one
+func (r *Reactions) of(content string) *int {
+	switch content {
+	case "THUMBS_UP":
+		return &r.PlusOne
+	case "THUMBS_DOWN":
+		return &r.MinusOne
+	case "LAUGH":
+		return &r.Laugh
+	case "HOORAY":
+		return &r.Hooray
+	case "CONFUSED":
+		return &r.Confused
+	case "HEART":
+		return &r.Heart
+	case "ROCKET":
+		return &r.Rocket
+	case "EYES":
+		return &r.Eyes
+	}
+	return nil
+}
+
+// InitDataStructs initializes repoData, issueData & detailsData, the api url is the REST one identifying the repo
+func (g *GraphQLClient) InitDataStructs(repo, title, body string) (*Repo, *Issue, *Details) {
+	return initDataStructs(g.BaseURL, repo, title, body, os.Getenv("TOKEN"))
+}
+
+// FindIssue returns the issue with the title, querying the repo again if it isn't known yet so that an issue created
+// since the repo was queried isn't created twice
+func (g *GraphQLClient) FindIssue(repoData *Repo, issueData *Issue, detailsData *Details) (*Issue, *Error) {
+	var found *Issue
+	lookup := func(snapshot *repoSnapshot) *Error {
+		for _, issue := range snapshot.issues {
+			if issue.Title == issueData.Title {
+				found = copyIssue(issue)
+				break
+			}
+		}
+		return &Error{}
+	}
+	if returnErr := g.withRepo(detailsData, false, lookup); returnErr.ErrorCode != nil || found != nil {
+		return found, returnErr
+	}
+	return found, g.withRepo(detailsData, true, lookup)
+}
+
+// GetIssue returns the issue by its number, querying the repo again if it isn't known yet. A missing issue is an error
+func (g *GraphQLClient) GetIssue(repoData *Repo, number int, detailsData *Details) (*Issue, *Error) {
+	var found *Issue
+	lookup := func(snapshot *repoSnapshot) *Error {
+		for _, issue := range snapshot.issues {
+			if issue.Number == number {
+				found = copyIssue(issue)
+			}
+		}
+		return &Error{}
+	}
+	if returnErr := g.withRepo(detailsData, false, lookup); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if found == nil {
+		if returnErr := g.withRepo(detailsData, true, lookup); returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+	}
+	if found == nil {
+		return nil, &Error{ErrorCode: fmt.Errorf("issue #%d not found", number), Message: "Getting GitHub issue failed: Not Found", StatusCode: http.StatusNotFound}
+	}
+	return found, &Error{}
+}
+
+func (g *GraphQLClient) CreateIssue(issueData *Issue, detailsData *Details) (*Issue, *Error) {
+	var created *Issue
+	returnErr := g.withRepo(detailsData, false, func(snapshot *repoSnapshot) *Error {
+		variables := map[string]interface{}{"repository": snapshot.id, "title": issueData.Title, "body": issueData.Description}
+		issue, returnErr := g.mutateIssue("CreateIssue", createIssueMutation, "createIssue", variables, snapshot, detailsData)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		created = issue
+		return g.setLabelsAssigneesAndMilestone(issueData, created, snapshot, detailsData)
+	})
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	returnErr.StatusCode = http.StatusCreated
+	return created, returnErr
+}
+
+func (g *GraphQLClient) EditIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	return g.withRepo(detailsData, false, func(snapshot *repoSnapshot) *Error {
+		nodeID, returnErr := snapshot.nodeID(issue)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		variables := map[string]interface{}{"id": nodeID, "body": issueData.Description}
+		edited, returnErr := g.mutateIssue("UpdateIssue", updateIssueMutation, "updateIssue", variables, snapshot, detailsData)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		// A desired state reopens a closed issue, or closes an open one
+		switch {
+		case issueData.State == "open" && edited.State != "open":
+			edited, returnErr = g.mutateIssue("ReopenIssue", reopenIssueMutation, "reopenIssue", map[string]interface{}{"id": nodeID}, snapshot, detailsData)
+		case issueData.State == "closed" && edited.State != "closed":
+			variables := map[string]interface{}{"id": nodeID, "stateReason": closedStateReason(issueData.StateReason)}
+			edited, returnErr = g.mutateIssue("CloseIssue", closeIssueMutation, "closeIssue", variables, snapshot, detailsData)
+		}
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		if returnErr := g.setLabelsAssigneesAndMilestone(issueData, edited, snapshot, detailsData); returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		*issue = *edited
+		return &Error{StatusCode: http.StatusOK}
+	})
+}
+
+func (g *GraphQLClient) CloseIssue(issueData *Issue, issue *Issue, detailsData *Details) *Error {
+	return g.withRepo(detailsData, false, func(snapshot *repoSnapshot) *Error {
+		nodeID, returnErr := snapshot.nodeID(issue)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		variables := map[string]interface{}{"id": nodeID, "stateReason": closedStateReason(issueData.StateReason)}
+		closed, returnErr := g.mutateIssue("CloseIssue", closeIssueMutation, "closeIssue", variables, snapshot, detailsData)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		*issue = *closed
+		return &Error{StatusCode: http.StatusOK}
+	})
+}
+
+// SyncProjectItem adds the issue to the project like the GithubClient does, each of its requests waiting for the
+// rate limit to allow it
+func (g *GraphQLClient) SyncProjectItem(issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error) {
+	return syncProjectItem(func(operation, query string, variables map[string]interface{}, out interface{}, detailsData *Details) *Error {
+		return g.query(operation, query, projectRequestCosts[operation], variables, out, detailsData)
+	}, issue, project, detailsData)
+}
+
+// withRepo runs fn with the issues of the repo of detailsData, querying them if they are older than CacheTTL
+// or refresh is set
+func (g *GraphQLClient) withRepo(detailsData *Details, refresh bool, fn func(*repoSnapshot) *Error) *Error {
+	owner, name, returnErr := repoOfDetails(detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	g.mu.Lock()
+	key := repoKey(detailsData)
+	snapshot, ok := g.repos[key]
+	if !ok {
+		snapshot = &repoSnapshot{}
+		g.repos[key] = snapshot
+	}
+	g.mu.Unlock()
+
+	snapshot.mu.Lock()
+	defer snapshot.mu.Unlock()
+	if refresh || snapshot.fetched.IsZero() || g.clock().Sub(snapshot.fetched) >= g.CacheTTL {
+		if returnErr := g.queryRepo(owner, name, snapshot, detailsData); returnErr.ErrorCode != nil {
+			return returnErr
+		}
+	}
+	return fn(snapshot)
+}
+
+// forget drops the snapshots of the repo of detailsData for every token, so it is queried again on its next use
+func (g *GraphQLClient) forget(detailsData *Details) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.repos {
+		if strings.HasPrefix(key, repoURL(detailsData)+" ") {
+			delete(g.repos, key)
+		}
+	}
+}
+
+// repoKey identifies the snapshot of the repo of detailsData by the repo and a hash of the token, the issues read
+// with a token are only served to the calls made with the same token
+func repoKey(detailsData *Details) string {
+	sum := sha256.Sum256([]byte(detailsData.Token))
+	return repoURL(detailsData) + " " + hex.EncodeToString(sum[:])
+}
+
+// repoURL identifies the repo of detailsData, by its api url including the host
+func repoURL(detailsData *Details) string {
+	return strings.ToLower(strings.TrimSuffix(detailsData.ApiURL, "/issues"))
+}
+
+// queryRepo replaces the issues of the snapshot by all issues of the repo, page by page
+func (g *GraphQLClient) queryRepo(owner, name string, snapshot *repoSnapshot, detailsData *Details) *Error {
+	var issues []Issue
+	variables := map[string]interface{}{"owner": owner, "name": name, "after": nil}
+	for {
+		var page struct {
+			Repository *struct {
+				ID     string `json:"id"`
+				Issues struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []graphqlIssue `json:"nodes"`
+				} `json:"issues"`
+			} `json:"repository"`
+			RateLimit *struct {
+				Remaining int    `json:"remaining"`
+				ResetAt   string `json:"resetAt"`
+			} `json:"rateLimit"`
+		}
+		if returnErr := g.query("RepositoryIssues", repositoryIssuesQuery, issuesQueryCost, variables, &page, detailsData); returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		if page.RateLimit != nil {
+			resetAt, _ := time.Parse(time.RFC3339, page.RateLimit.ResetAt)
+			g.observe(page.RateLimit.Remaining, resetAt)
+		}
+		if page.Repository == nil {
+			return &Error{ErrorCode: fmt.Errorf("repository %s/%s not found", owner, name), Message: "Listing GitHub issues failed: Not Found", StatusCode: http.StatusNotFound}
+		}
+		snapshot.id = page.Repository.ID
+		for i := range page.Repository.Issues.Nodes {
+			issues = append(issues, page.Repository.Issues.Nodes[i].issue())
+		}
+		if !page.Repository.Issues.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = page.Repository.Issues.PageInfo.EndCursor
+	}
+	snapshot.issues = issues
+	snapshot.fetched = g.clock()
+	return &Error{}
+}
+
+// mutateIssue runs an issue mutation, and stores the returned issue in the snapshot
+func (g *GraphQLClient) mutateIssue(operation, mutation, field string, variables map[string]interface{}, snapshot *repoSnapshot, detailsData *Details) (*Issue, *Error) {
+	var result map[string]struct {
+		Issue graphqlIssue `json:"issue"`
+	}
+	if returnErr := g.query(operation, mutation, mutationCost, variables, &result, detailsData); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	returned := result[field].Issue
+	issue := returned.issue()
+	snapshot.store(issue)
+	return copyIssue(issue), &Error{StatusCode: http.StatusOK}
+}
+
+// setLabelsAssigneesAndMilestone applies the managed labels, assignees and milestone of issueData through the REST
+// API, as the GraphQL mutations take their node ids rather than their names and numbers
+func (g *GraphQLClient) setLabelsAssigneesAndMilestone(issueData *Issue, issue *Issue, snapshot *repoSnapshot, detailsData *Details) *Error {
+	if (issueData.Labels == nil || sameStrings(issueData.Labels, issue.Labels)) &&
+		(issueData.Assignees == nil || sameStrings(issueData.Assignees, issue.Assignees)) &&
+		(issueData.Milestone == 0 || issueData.Milestone == issue.Milestone) {
+		return &Error{}
+	}
+	desired := &Issue{Description: issue.Description, Labels: issueData.Labels, Assignees: issueData.Assignees, Milestone: issueData.Milestone}
+	if returnErr := g.GithubClient.EditIssue(desired, issue, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	snapshot.store(*copyIssue(*issue))
+	return &Error{}
+}
+
+// query sends a GraphQL request once the rate limit allows its cost, and records the remaining points
+func (g *GraphQLClient) query(operation, document string, cost int, variables map[string]interface{}, out interface{}, detailsData *Details) *Error {
+	if returnErr := g.reserve(cost); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	header, returnErr := g.graphqlRequest(operation, document, variables, out, detailsData)
+	if header != nil && header.Get("X-RateLimit-Remaining") != "" {
+		remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+		reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+		g.observe(remaining, time.Unix(reset, 0))
+	}
+	return returnErr
+}
+
+// reserve fails if the remaining points don't cover the cost and the reserve until the rate limit resets,
+// otherwise it deducts the cost until the next response reports the actual points
+func (g *GraphQLClient) reserve(cost int) *Error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.remaining >= 0 && g.remaining-cost < g.MinRateLimitRemaining && g.clock().Before(g.resetAt) {
+		return &Error{
+			ErrorCode:  fmt.Errorf("GitHub GraphQL rate limit exhausted"),
+			Message:    fmt.Sprintf("GitHub GraphQL rate limit exhausted, %d points remaining, resets at %s", g.remaining, g.resetAt.Format(time.RFC3339)),
+			StatusCode: http.StatusForbidden,
+		}
+	}
+	if g.remaining >= 0 {
+		// -1 means unknown, a reservation beyond the remaining points leaves none rather than that
+		g.remaining -= cost
+		if g.remaining < 0 {
+			g.remaining = 0
+		}
+	}
+	return &Error{}
+}
+
+// observe records the rate limit reported by GitHub
+func (g *GraphQLClient) observe(remaining int, resetAt time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remaining = remaining
+	g.resetAt = resetAt
+}
+
+func (g *GraphQLClient) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
+// nodeID returns the node id of the issue, from the snapshot if the issue doesn't have it
+func (s *repoSnapshot) nodeID(issue *Issue) (string, *Error) {
+	if issue.NodeID != "" {
+		return issue.NodeID, &Error{}
+	}
+	for _, stored := range s.issues {
+		if stored.Number == issue.Number {
+			return stored.NodeID, &Error{}
+		}
+	}
+	return "", &Error{ErrorCode: fmt.Errorf("issue #%d not found", issue.Number), Message: "Editing GitHub issue failed: Not Found", StatusCode: http.StatusNotFound}
+}
+
+// store replaces the issue in the snapshot, or adds it as the newest one
+func (s *repoSnapshot) store(issue Issue) {
+	for i := range s.issues {
+		if s.issues[i].Number == issue.Number {
+			s.issues[i] = issue
+			return
+		}
+	}
+	s.issues = append([]Issue{issue}, s.issues...)
+}
+
+// closedStateReason returns the GraphQL enum value of a REST state reason
+func closedStateReason(reason string) string {
+	if reason == "not_planned" {
+		return "NOT_PLANNED"
+	}
+	return "COMPLETED"
+}
+
+// repoOfDetails returns the owner and name of the repo from the REST api url of its issues
+func repoOfDetails(detailsData *Details) (string, string, *Error) {
+	parts := strings.Split(strings.TrimSuffix(detailsData.ApiURL, "/issues"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "repos" {
+		return "", "", &Error{ErrorCode: fmt.Errorf("malformed api url %q", detailsData.ApiURL), Message: "Malformed api url " + detailsData.ApiURL}
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], &Error{}
+}
+
+// sameStrings reports whether a and b hold the same strings, in any order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		if counts[s] == 0 {
+			return false
+		}
+		counts[s]--
+	}
+	return true
+}
+
+func copyIssue(issue Issue) *Issue {
+	issue.Labels = append([]string(nil), issue.Labels...)
+	issue.Assignees = append([]string(nil), issue.Assignees...)
+	issue.ProjectItems = append([]ProjectItem(nil), issue.ProjectItems...)
+	return &issue
+}
+
+// graphql sends a GraphQL request to the GitHub API, and decodes its data into out
+func (g *GithubClient) graphql(operation, query string, variables map[string]interface{}, out interface{}, detailsData *Details) *Error {
+	_, returnErr := g.graphqlRequest(operation, query, variables, out, detailsData)
+	return returnErr
+}
+
+// graphqlRequest is graphql returning the response headers as well, nil if no response was received
+func (g *GithubClient) graphqlRequest(operation, query string, variables map[string]interface{}, out interface{}, detailsData *Details) (http.Header, *Error) {
+	payload := map[string]interface{}{"operationName": operation, "query": query, "variables": variables}
+	body, resp, returnErr := g.doRequest("POST", g.graphqlURL(detailsData), payload, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Header, responseError("GraphQL request "+operation+" failed with response: \n", resp, body)
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return resp.Header, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		statusCode := resp.StatusCode
+		if result.Errors[0].Type == "NOT_FOUND" {
+			// Reported like the REST API does, so a missing node can be told apart from other errors
+			statusCode = http.StatusNotFound
+		}
+		return resp.Header, &Error{
+			ErrorCode:  fmt.Errorf("GraphQL request %s failed: %s", operation, messages[0]),
+			Message:    "GraphQL request " + operation + " failed with errors: \n" + strings.Join(messages, "\n"),
+			StatusCode: statusCode,
+		}
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return resp.Header, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return resp.Header, &Error{StatusCode: resp.StatusCode}
+}
+
+// graphqlURL returns the GraphQL endpoint next to the REST API root of the repo of detailsData, on github.com
+// or an enterprise server
+func (g *GithubClient) graphqlURL(detailsData *Details) string {
+	baseURL := g.BaseURL
+	if i := strings.LastIndex(detailsData.ApiURL, "/repos/"); i >= 0 {
+		baseURL = detailsData.ApiURL[:i]
+	}
+	if strings.HasSuffix(baseURL, "/api/v3") {
+		return strings.TrimSuffix(baseURL, "/v3") + "/graphql"
+	}
+	return baseURL + "/graphql"
+}
+
+// NewGraphQLClient returns a GraphQLClient reusing the issues of a repo for 30 seconds
+func NewGraphQLClient() *GraphQLClient {
+	return &GraphQLClient{
+		GithubClient:          *NewGithubClient(),
+		CacheTTL:              30 * time.Second,
+		MinRateLimitRemaining: 100,
+		repos:                 map[string]*repoSnapshot{},
+		remaining:             -1,
+	}
+}
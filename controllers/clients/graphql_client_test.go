@@ -0,0 +1,251 @@
+package clients
+
+import (
+	"fmt"
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestGraphQLClient returns a GraphQLClient talking to a fresh fake GitHub server, with a controllable clock
+func newTestGraphQLClient(t *testing.T) (*GraphQLClient, *fakegithub.Server, *time.Time) {
+	server := fakegithub.NewServer()
+	t.Cleanup(server.Close)
+	graphqlClient := NewGraphQLClient()
+	graphqlClient.BaseURL = server.URL
+	now := time.Now()
+	graphqlClient.now = func() time.Time { return now }
+	return graphqlClient, server, &now
+}
+
+// operations returns the number of GraphQL requests sent to the server for the operation
+func operations(server *fakegithub.Server, operation string) int {
+	count := 0
+	for _, req := range server.Requests() {
+		if strings.Contains(req.Body, `"operationName":"`+operation+`"`) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGraphQLClientFindIssue(t *testing.T) {
+	// Given a repo with more issues than fit in a single page
+	graphqlClient, server, now := newTestGraphQLClient(t)
+	for i := 1; i <= 150; i++ {
+		server.AddIssue(testRepo, fakegithub.Issue{Title: fmt.Sprintf("title%d", i), Body: "body"})
+	}
+	server.AddIssue(testRepo, fakegithub.Issue{
+		Title:     "labeled",
+		Labels:    []fakegithub.Label{{Name: "bug"}},
+		Assignees: []fakegithub.User{{Login: "octocat"}},
+		Comments:  2,
+	})
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title10", "")
+
+	// When finding issues on both pages, then they are found by a single query of the repo
+	issue, returnErr := graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue == nil || issue.Number != 10 || issue.Description != "body" || issue.State != "open" || issue.NodeID == "" {
+		t.Errorf("Expected issue 10 but got %+v", issue)
+	}
+	issueData.Title = "labeled"
+	issue, _ = graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if issue == nil || issue.Comments != 2 || len(issue.Labels) != 1 || issue.Labels[0] != "bug" ||
+		len(issue.Assignees) != 1 || issue.Assignees[0] != "octocat" {
+		t.Errorf("Expected the labels, assignees and comments count but got %+v", issue)
+	}
+	if got := operations(server, "RepositoryIssues"); got != 2 {
+		t.Errorf("Expected the 2 pages to be queried once but got %d requests", got)
+	}
+
+	// When the cache expires, then the repo is queried again
+	*now = now.Add(graphqlClient.CacheTTL)
+	graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if got := operations(server, "RepositoryIssues"); got != 4 {
+		t.Errorf("Expected the repo to be queried again but got %d requests", got)
+	}
+
+	// When finding an issue created since the repo was queried, then the repo is queried again to find it
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "created elsewhere"})
+	issueData.Title = "created elsewhere"
+	issue, returnErr = graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if issue == nil || returnErr.ErrorCode != nil || operations(server, "RepositoryIssues") != 6 {
+		t.Errorf("Expected the new issue to be found but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+
+	// When finding a missing issue, then nil is returned
+	issueData.Title = "missing"
+	if issue, returnErr = graphqlClient.FindIssue(repoData, issueData, detailsData); issue != nil || returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil issue but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+}
+
+func TestGraphQLClientTokens(t *testing.T) {
+	// Given a repo queried with a token
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title1", "")
+	detailsData.Token = "team-a"
+	if issue, _ := graphqlClient.FindIssue(repoData, issueData, detailsData); issue == nil {
+		t.Fatalf("Expected issue 1")
+	}
+
+	// When finding the issue with another token, then the repo is queried with that token
+	other := *detailsData
+	other.Token = "team-b"
+	graphqlClient.FindIssue(repoData, issueData, &other)
+	requests := server.Requests()
+	if got := operations(server, "RepositoryIssues"); got != 2 || !strings.HasSuffix(requests[len(requests)-1].Header.Get("Authorization"), "team-b") {
+		t.Errorf("Expected a query per token but got %d requests", got)
+	}
+}
+
+func TestGraphQLClientGetIssue(t *testing.T) {
+	// Given a cached repo, and an issue created after it was queried
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	repoData, _, detailsData := graphqlClient.InitDataStructs(testRepo, "", "")
+	if _, returnErr := graphqlClient.GetIssue(repoData, 1, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title2"})
+
+	// When getting the new issue, then the repo is queried again
+	issue, returnErr := graphqlClient.GetIssue(repoData, 2, detailsData)
+	if returnErr.ErrorCode != nil || issue.Title != "title2" {
+		t.Errorf("Expected issue 2 but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+
+	// When getting a missing issue, then a not found error is returned
+	if _, returnErr = graphqlClient.GetIssue(repoData, 7, detailsData); returnErr.ErrorCode == nil || returnErr.StatusCode != 404 {
+		t.Errorf("Expected a not found error but got %+v", returnErr)
+	}
+}
+
+func TestGraphQLClientLifecycle(t *testing.T) {
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title1", "body")
+
+	// When creating an issue, then it is found without querying the repo again
+	issue, returnErr := graphqlClient.CreateIssue(issueData, detailsData)
+	if returnErr.ErrorCode != nil || returnErr.StatusCode != 201 || issue.Number != 1 {
+		t.Fatalf("Expected issue 1 to be created but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+	found, _ := graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if found == nil || found.Number != 1 || operations(server, "RepositoryIssues") != 1 {
+		t.Errorf("Expected the created issue from the cache but got %+v", found)
+	}
+
+	// When editing it, then its body is changed
+	issueData.Description = "new body"
+	if returnErr = graphqlClient.EditIssue(issueData, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if stored := server.Issues(testRepo)[0]; stored.Body != "new body" || issue.Description != "new body" {
+		t.Errorf("Expected the new body but got %q", stored.Body)
+	}
+
+	// When closing it as not planned and reopening it, then its state follows
+	if returnErr = graphqlClient.CloseIssue(&Issue{StateReason: "not_planned"}, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issue.State != "closed" || issue.StateReason != "not_planned" || issue.ClosedAt == "" {
+		t.Errorf("Expected the issue closed as not planned but got %+v", issue)
+	}
+	reopen := *issueData
+	reopen.State = "open"
+	if returnErr = graphqlClient.EditIssue(&reopen, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if stored := server.Issues(testRepo)[0]; stored.State != "open" || issue.State != "open" || issue.StateReason != "reopened" {
+		t.Errorf("Expected the issue reopened but got %+v", issue)
+	}
+	found, _ = graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if found.State != "open" || found.Description != "new body" {
+		t.Errorf("Expected the cache to follow the mutations but got %+v", found)
+	}
+}
+
+func TestGraphQLClientLabelsAndAssignees(t *testing.T) {
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title1", "body")
+	issueData.Labels = []string{"bug"}
+
+	// When creating an issue with labels, then they are set through the REST API
+	issue, returnErr := graphqlClient.CreateIssue(issueData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if stored := server.Issues(testRepo)[0]; len(stored.Labels) != 1 || stored.Labels[0].Name != "bug" {
+		t.Errorf("Expected the label on the real issue but got %+v", stored.Labels)
+	}
+
+	// When editing its assignees, then the real issue and the cache follow
+	issueData.Assignees = []string{"octocat"}
+	if returnErr = graphqlClient.EditIssue(issueData, issue, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if stored := server.Issues(testRepo)[0]; len(stored.Assignees) != 1 || stored.Assignees[0].Login != "octocat" {
+		t.Errorf("Expected the assignee on the real issue but got %+v", stored.Assignees)
+	}
+	found, _ := graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if found == nil || len(found.Labels) != 1 || len(found.Assignees) != 1 {
+		t.Errorf("Expected the cache to hold the labels and assignees but got %+v", found)
+	}
+}
+
+func TestGraphQLClientRateLimit(t *testing.T) {
+	// Given a token with fewer points left than the reserve
+	graphqlClient, server, now := newTestGraphQLClient(t)
+	server.RateLimit = 50
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title1", "")
+	if _, returnErr := graphqlClient.FindIssue(repoData, issueData, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+
+	// When the repo has to be queried again, then it fails without sending the query
+	*now = now.Add(graphqlClient.CacheTTL)
+	_, returnErr := graphqlClient.FindIssue(repoData, issueData, detailsData)
+	if returnErr.ErrorCode == nil || returnErr.StatusCode != 403 || !strings.Contains(returnErr.Message, "rate limit") {
+		t.Errorf("Expected a rate limit error but got %+v", returnErr)
+	}
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("Expected no more requests but got %d", got-1)
+	}
+
+	// When the rate limit resets, then the query is sent again
+	*now = now.Add(2 * time.Hour)
+	if _, returnErr = graphqlClient.FindIssue(repoData, issueData, detailsData); returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil but got error: %s", returnErr.Message)
+	}
+}
+
+func TestGraphQLClientActivity(t *testing.T) {
+	// Given a closed issue with reactions and comments
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	server.AddIssue(testRepo, fakegithub.Issue{
+		Title:     "title1",
+		User:      fakegithub.User{Login: "octocat"},
+		Reactions: &fakegithub.Reactions{TotalCount: 3, PlusOne: 2, Rocket: 1},
+	})
+	repoData, issueData, detailsData := graphqlClient.InitDataStructs(testRepo, "title1", "")
+	graphqlClient.CreateComment(1, &Comment{Body: "first"}, detailsData)
+	issue, _ := graphqlClient.FindIssue(repoData, issueData, detailsData)
+	graphqlClient.CloseIssue(issueData, issue, detailsData)
+
+	// When finding it, then its activity is part of the query
+	if issue.Author != "octocat" || issue.CreatedAt == "" || issue.ClosedBy != "fake-user" {
+		t.Errorf("Expected the author and closer but got %+v", issue)
+	}
+	if issue.Comments != 1 || issue.LastCommenter != "fake-user" || issue.LastCommentedAt == "" {
+		t.Errorf("Expected the comment and its author but got %+v", issue)
+	}
+	if issue.Reactions == nil || issue.Reactions.TotalCount != 3 || issue.Reactions.PlusOne != 2 || issue.Reactions.Rocket != 1 {
+		t.Errorf("Expected the reactions but got %+v", issue.Reactions)
+	}
+}
@@ -0,0 +1,102 @@
+package clients
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Label structure declaration - a label of a repo
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// labelRequest is the payload of the label requests, NewName renames the label when editing it
+type labelRequest struct {
+	Name        string `json:"name,omitempty"`
+	NewName     string `json:"new_name,omitempty"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// GetLabel returns the label of the repo by its name, a missing label is an error with status 404
+func (g *GithubClient) GetLabel(name string, detailsData *Details) (*Label, *Error) {
+	body, resp, returnErr := g.doRequest("GET", labelURL(name, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("Getting GitHub label failed with response: \n", resp, body)
+	}
+	var label *Label
+	if err := json.Unmarshal(body, &label); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return label, &Error{StatusCode: resp.StatusCode}
+}
+
+func (g *GithubClient) CreateLabel(labelData *Label, detailsData *Details) (*Label, *Error) {
+	payload := labelRequest{Name: labelData.Name, Color: strings.TrimPrefix(labelData.Color, "#"), Description: labelData.Description}
+	body, resp, returnErr := g.doRequest("POST", labelsURL(detailsData), payload, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("Creating GitHub label failed with response: \n", resp, body)
+	}
+	var label *Label
+	if err := json.Unmarshal(body, &label); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return label, &Error{StatusCode: resp.StatusCode}
+}
+
+// EditLabel sets the color and description of the label, and renames it if labelData has another name
+func (g *GithubClient) EditLabel(labelData *Label, label *Label, detailsData *Details) *Error {
+	payload := labelRequest{Color: strings.TrimPrefix(labelData.Color, "#"), Description: labelData.Description}
+	if labelData.Name != label.Name {
+		payload.NewName = labelData.Name
+	}
+	body, resp, returnErr := g.doRequest("PATCH", labelURL(label.Name, detailsData), payload, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("Editing GitHub label failed with response: \n", resp, body)
+	}
+	json.Unmarshal(body, label)
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// DeleteLabel deletes the label from the repo and all its issues, a label that is already gone is not an error
+func (g *GithubClient) DeleteLabel(label *Label, detailsData *Details) *Error {
+	body, resp, returnErr := g.doRequest("DELETE", labelURL(label.Name, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return responseError("Deleting GitHub label failed with response: \n", resp, body)
+	}
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// labelsURL returns the url of the labels of the repo of detailsData
+func labelsURL(detailsData *Details) string {
+	return strings.TrimSuffix(detailsData.ApiURL, "/issues") + "/labels"
+}
+
+// labelURL returns the url of a label of the repo of detailsData by its name
+func labelURL(name string, detailsData *Details) string {
+	return labelsURL(detailsData) + "/" + url.PathEscape(name)
+}
+
+// SameLabel returns true if the label has the name, color and description of labelData, GitHub ignoring the
+// case of names and colors
+func SameLabel(labelData *Label, label *Label) bool {
+	return strings.EqualFold(labelData.Name, label.Name) &&
+		strings.EqualFold(strings.TrimPrefix(labelData.Color, "#"), strings.TrimPrefix(label.Color, "#")) &&
+		labelData.Description == label.Description
+}
@@ -0,0 +1,58 @@
+package clients
+
+import (
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+	"testing"
+)
+
+func TestGithubClientLabels(t *testing.T) {
+	// Given a repo with an issue labeled bug
+	githubClient, server := newTestGithubClient(t)
+	server.AddLabel(testRepo, fakegithub.Label{Name: "bug", Color: "d73a4a"})
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "title1", Labels: []fakegithub.Label{{Name: "bug"}}})
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+
+	// When getting a missing label, then it is not found
+	if _, returnErr := githubClient.GetLabel("good first issue", detailsData); returnErr.StatusCode != 404 {
+		t.Errorf("Expected a not found error but got %+v", returnErr)
+	}
+
+	// When creating it, then it is in the repo without the # of its color
+	created, returnErr := githubClient.CreateLabel(&Label{Name: "good first issue", Color: "#7057FF", Description: "Good for newcomers"}, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	got, returnErr := githubClient.GetLabel("Good First Issue", detailsData)
+	if returnErr.ErrorCode != nil || got.Color != "7057FF" || !SameLabel(created, got) {
+		t.Errorf("Expected the created label but got %+v, %v", got, returnErr.ErrorCode)
+	}
+	if _, returnErr = githubClient.CreateLabel(&Label{Name: "Bug"}, detailsData); returnErr.StatusCode != 422 {
+		t.Errorf("Expected the existing label to be rejected but got %+v", returnErr)
+	}
+
+	// When renaming bug, then the issue has the new name
+	label := &Label{Name: "bug", Color: "d73a4a"}
+	if returnErr = githubClient.EditLabel(&Label{Name: "kind/bug", Color: "d73a4a", Description: "Something isn't working"}, label, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if label.Name != "kind/bug" || label.Description != "Something isn't working" {
+		t.Errorf("Expected the edited label but got %+v", label)
+	}
+	if issues := server.Issues(testRepo); len(issues[0].Labels) != 1 || issues[0].Labels[0].Name != "kind/bug" {
+		t.Errorf("Expected the issue to have the renamed label but got %+v", issues[0].Labels)
+	}
+
+	// When deleting it, then it is gone from the repo and the issue, and deleting it again is not an error
+	if returnErr = githubClient.DeleteLabel(label, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if labels := server.Labels(testRepo); len(labels) != 1 || labels[0].Name != "good first issue" {
+		t.Errorf("Expected only the created label to be left but got %+v", labels)
+	}
+	if issues := server.Issues(testRepo); len(issues[0].Labels) != 0 {
+		t.Errorf("Expected the label to be removed from the issue but got %+v", issues[0].Labels)
+	}
+	if returnErr = githubClient.DeleteLabel(label, detailsData); returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil but got error: %s", returnErr.Message)
+	}
+}
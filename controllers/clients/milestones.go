@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Milestone structure declaration - a milestone of a repo
+type Milestone struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// State represents whether the milestone is open or closed
+	State string `json:"state"`
+	// DueOn represents the due date of the milestone as YYYY-MM-DD, none if empty
+	DueOn        string `json:"due_on"`
+	OpenIssues   int    `json:"open_issues"`
+	ClosedIssues int    `json:"closed_issues"`
+	HTMLURL      string `json:"html_url"`
+}
+
+// milestoneRequest is the payload of the create and edit milestone requests, a nil DueOn removes the due date
+type milestoneRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	State       string  `json:"state,omitempty"`
+	DueOn       *string `json:"due_on"`
+}
+
+func newMilestoneRequest(milestoneData *Milestone) milestoneRequest {
+	payload := milestoneRequest{Title: milestoneData.Title, Description: milestoneData.Description, State: milestoneData.State}
+	if milestoneData.DueOn != "" {
+		// GitHub keeps the date only, the time is required by the API
+		dueOn := milestoneData.DueOn + "T00:00:00Z"
+		payload.DueOn = &dueOn
+	}
+	return payload
+}
+
+// UnmarshalJSON reads a milestone of the REST API, with its due date as YYYY-MM-DD
+func (m *Milestone) UnmarshalJSON(data []byte) error {
+	type milestone Milestone
+	payload := struct {
+		*milestone
+		DueOn *string `json:"due_on"`
+	}{milestone: (*milestone)(m)}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	m.DueOn = ""
+	if payload.DueOn != nil && len(*payload.DueOn) >= len("2006-01-02") {
+		m.DueOn = (*payload.DueOn)[:len("2006-01-02")]
+	}
+	return nil
+}
+
+// FindMilestone returns the milestone of the repo with the title, open or closed, nil if there is none
+func (g *GithubClient) FindMilestone(title string, detailsData *Details) (*Milestone, *Error) {
+	apiURL := milestonesURL(detailsData) + "?state=all&per_page=100"
+	for apiURL != "" {
+		body, resp, returnErr := g.doRequest("GET", apiURL, nil, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, responseError("Listing GitHub milestones failed with response: \n", resp, body)
+		}
+		var milestones []Milestone
+		if err := json.Unmarshal(body, &milestones); err != nil {
+			return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body)}
+		}
+		for _, milestone := range milestones {
+			if milestone.Title == title {
+				return &milestone, &Error{StatusCode: resp.StatusCode}
+			}
+		}
+		apiURL = nextPage(resp)
+	}
+	return nil, &Error{}
+}
+
+// GetMilestone returns the milestone of the repo by its number, a missing milestone is an error with status 404
+func (g *GithubClient) GetMilestone(number int, detailsData *Details) (*Milestone, *Error) {
+	body, resp, returnErr := g.doRequest("GET", milestoneURL(number, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("Getting GitHub milestone failed with response: \n", resp, body)
+	}
+	var milestone *Milestone
+	if err := json.Unmarshal(body, &milestone); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return milestone, &Error{StatusCode: resp.StatusCode}
+}
+
+func (g *GithubClient) CreateMilestone(milestoneData *Milestone, detailsData *Details) (*Milestone, *Error) {
+	body, resp, returnErr := g.doRequest("POST", milestonesURL(detailsData), newMilestoneRequest(milestoneData), detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError("Creating GitHub milestone failed with response: \n", resp, body)
+	}
+	var milestone *Milestone
+	if err := json.Unmarshal(body, &milestone); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return milestone, &Error{StatusCode: resp.StatusCode}
+}
+
+// EditMilestone sets the title, description, due date and state of the milestone to those of milestoneData
+func (g *GithubClient) EditMilestone(milestoneData *Milestone, milestone *Milestone, detailsData *Details) *Error {
+	body, resp, returnErr := g.doRequest("PATCH", milestoneURL(milestone.Number, detailsData), newMilestoneRequest(milestoneData), detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError("Editing GitHub milestone failed with response: \n", resp, body)
+	}
+	json.Unmarshal(body, milestone)
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// DeleteMilestone deletes the milestone, its issues are left without one. A milestone that is already gone is
+// not an error
+func (g *GithubClient) DeleteMilestone(milestone *Milestone, detailsData *Details) *Error {
+	body, resp, returnErr := g.doRequest("DELETE", milestoneURL(milestone.Number, detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return responseError("Deleting GitHub milestone failed with response: \n", resp, body)
+	}
+	return &Error{StatusCode: resp.StatusCode}
+}
+
+// milestonesURL returns the url of the milestones of the repo of detailsData
+func milestonesURL(detailsData *Details) string {
+	return strings.TrimSuffix(detailsData.ApiURL, "/issues") + "/milestones"
+}
+
+// milestoneURL returns the url of a milestone of the repo of detailsData by its number
+func milestoneURL(number int, detailsData *Details) string {
+	return milestonesURL(detailsData) + "/" + fmt.Sprint(number)
+}
+
+// SameMilestone returns true if the milestone has the title, description, due date and state of milestoneData
+func SameMilestone(milestoneData *Milestone, milestone *Milestone) bool {
+	return milestoneData.Title == milestone.Title && milestoneData.Description == milestone.Description &&
+		milestoneData.DueOn == milestone.DueOn && milestoneData.State == milestone.State
+}
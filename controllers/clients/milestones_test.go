@@ -0,0 +1,77 @@
+package clients
+
+import (
+	"testing"
+)
+
+func TestGithubClientMilestones(t *testing.T) {
+	// Given a repo without milestones
+	githubClient, server := newTestGithubClient(t)
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+	if milestone, returnErr := githubClient.FindMilestone("v1.0", detailsData); returnErr.ErrorCode != nil || milestone != nil {
+		t.Fatalf("Expected no milestone but got %+v, %v", milestone, returnErr.ErrorCode)
+	}
+
+	// When creating one, then it's found by its title with its due date
+	milestoneData := &Milestone{Title: "v1.0", Description: "First release", DueOn: "2026-03-01", State: "open"}
+	created, returnErr := githubClient.CreateMilestone(milestoneData, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	found, returnErr := githubClient.FindMilestone("v1.0", detailsData)
+	if returnErr.ErrorCode != nil || found == nil || found.Number != created.Number || !SameMilestone(milestoneData, found) {
+		t.Errorf("Expected the created milestone but got %+v, %v", found, returnErr.ErrorCode)
+	}
+
+	// When an issue is created in it, then the milestone counts the issue
+	issue, returnErr := githubClient.CreateIssue(&Issue{Title: "title1", Milestone: created.Number}, detailsData)
+	if returnErr.ErrorCode != nil || issue.Milestone != created.Number {
+		t.Fatalf("Expected the issue in the milestone but got %+v, %v", issue, returnErr.ErrorCode)
+	}
+	got, returnErr := githubClient.GetMilestone(created.Number, detailsData)
+	if returnErr.ErrorCode != nil || got.OpenIssues != 1 {
+		t.Errorf("Expected a milestone with an open issue but got %+v, %v", got, returnErr.ErrorCode)
+	}
+
+	// When closing it without a due date, then both are changed
+	if returnErr = githubClient.EditMilestone(&Milestone{Title: "v1.0", State: "closed"}, got, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if got.State != "closed" || got.DueOn != "" || got.Description != "" {
+		t.Errorf("Expected the edited milestone but got %+v", got)
+	}
+
+	// When deleting it, then the issue is left without a milestone, and deleting it again is not an error
+	if returnErr = githubClient.DeleteMilestone(got, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if issues := server.Issues(testRepo); issues[0].Milestone != nil || len(server.Milestones(testRepo)) != 0 {
+		t.Errorf("Expected the milestone to be gone but got %+v", issues[0].Milestone)
+	}
+	if _, returnErr = githubClient.GetMilestone(got.Number, detailsData); returnErr.StatusCode != 404 {
+		t.Errorf("Expected a not found error but got %+v", returnErr)
+	}
+	if returnErr = githubClient.DeleteMilestone(got, detailsData); returnErr.ErrorCode != nil {
+		t.Errorf("Expected nil but got error: %s", returnErr.Message)
+	}
+}
+
+func TestGraphQLClientMilestone(t *testing.T) {
+	// Given a milestone
+	graphqlClient, _, _ := newTestGraphQLClient(t)
+	_, _, detailsData := graphqlClient.InitDataStructs(testRepo, "", "")
+	milestone, returnErr := graphqlClient.CreateMilestone(&Milestone{Title: "v1.0"}, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+
+	// When creating an issue in it, then the issue is read back with its milestone
+	issue, returnErr := graphqlClient.CreateIssue(&Issue{Title: "title1", Milestone: milestone.Number}, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	found, returnErr := graphqlClient.FindIssue(&Repo{}, &Issue{Title: "title1"}, detailsData)
+	if returnErr.ErrorCode != nil || issue.Milestone != milestone.Number || found.Milestone != milestone.Number {
+		t.Errorf("Expected the issue in milestone %d but got %+v, %+v", milestone.Number, issue, found)
+	}
+}
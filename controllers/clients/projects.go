@@ -0,0 +1,353 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Project structure declaration - the GitHub Projects (v2) board an issue belongs to, and its field values
+type Project struct {
+	// ID is the node id of the project, Owner and Number are used when it's empty
+	ID     string
+	Owner  string
+	Number int
+	// Status is the option of the single select field named Status
+	Status string
+	// Iteration is the title of an iteration of the project's iteration field, or @current
+	Iteration string
+	// Fields are the values of the other fields by field name
+	Fields map[string]string
+}
+
+// ProjectItem structure declaration - an issue on a project board
+type ProjectItem struct {
+	ID        string
+	ProjectID string
+}
+
+// CurrentIteration selects the iteration in progress as a Project's Iteration
+const CurrentIteration = "@current"
+
+// GraphQL documents of the Projects (v2) API
+const (
+	projectFieldsFragment = `fragment ProjectFields on ProjectV2 {
+  id
+  fields(first: 100) {
+    nodes {
+      ... on ProjectV2FieldCommon { id name dataType }
+      ... on ProjectV2SingleSelectField { options { id name } }
+      ... on ProjectV2IterationField { configuration { iterations { id title startDate duration } } }
+    }
+  }
+}`
+	projectByIDQuery = `query ProjectByID($id: ID!) {
+  node(id: $id) { ...ProjectFields }
+}
+` + projectFieldsFragment
+	projectByNumberQuery = `query ProjectByNumber($owner: String!, $number: Int!) {
+  repositoryOwner(login: $owner) { ... on ProjectV2Owner { projectV2(number: $number) { ...ProjectFields } } }
+}
+` + projectFieldsFragment
+	issueProjectItemsQuery = `query IssueProjectItems($id: ID!) {
+  node(id: $id) {
+    ... on Issue {
+      projectItems(first: 50) {
+        nodes {
+          id
+          project { id }
+          fieldValues(first: 50) {
+            nodes {
+              ... on ProjectV2ItemFieldSingleSelectValue { optionId field { ... on ProjectV2FieldCommon { id } } }
+              ... on ProjectV2ItemFieldIterationValue { iterationId field { ... on ProjectV2FieldCommon { id } } }
+              ... on ProjectV2ItemFieldTextValue { text field { ... on ProjectV2FieldCommon { id } } }
+              ... on ProjectV2ItemFieldNumberValue { number field { ... on ProjectV2FieldCommon { id } } }
+              ... on ProjectV2ItemFieldDateValue { date field { ... on ProjectV2FieldCommon { id } } }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+	addProjectItemMutation = `mutation AddProjectItem($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) { item { id } }
+}`
+	updateProjectItemFieldMutation = `mutation UpdateProjectItemField($project: ID!, $item: ID!, $field: ID!, $value: ProjectV2FieldValue!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: $value}) { projectV2Item { id } }
+}`
+)
+
+// Responses of the Projects (v2) API
+type projectV2 struct {
+	ID     string `json:"id"`
+	Fields struct {
+		Nodes []projectField `json:"nodes"`
+	} `json:"fields"`
+}
+
+type projectField struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	DataType string `json:"dataType"`
+	Options  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"options"`
+	Configuration struct {
+		Iterations []struct {
+			ID        string `json:"id"`
+			Title     string `json:"title"`
+			StartDate string `json:"startDate"`
+			Duration  int    `json:"duration"`
+		} `json:"iterations"`
+	} `json:"configuration"`
+}
+
+type projectItemNode struct {
+	ID      string `json:"id"`
+	Project struct {
+		ID string `json:"id"`
+	} `json:"project"`
+	FieldValues struct {
+		Nodes []struct {
+			OptionID    string   `json:"optionId"`
+			IterationID string   `json:"iterationId"`
+			Text        *string  `json:"text"`
+			Number      *float64 `json:"number"`
+			Date        *string  `json:"date"`
+			Field       struct {
+				ID string `json:"id"`
+			} `json:"field"`
+		} `json:"nodes"`
+	} `json:"fieldValues"`
+}
+
+// graphqlFunc sends a GraphQL request and decodes its data into out, so the project calls can be metered by the
+// GraphQLClient
+type graphqlFunc func(operation, query string, variables map[string]interface{}, out interface{}, detailsData *Details) *Error
+
+// SyncProjectItem adds the issue to the project if it isn't on it yet, and sets the field values that differ
+func (g *GithubClient) SyncProjectItem(issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error) {
+	return syncProjectItem(g.graphql, issue, project, detailsData)
+}
+
+// syncProjectItem is SyncProjectItem sending its requests through graphql
+func syncProjectItem(graphql graphqlFunc, issue *Issue, project *Project, detailsData *Details) (*ProjectItem, *Error) {
+	if issue.NodeID == "" {
+		return nil, &Error{ErrorCode: fmt.Errorf("issue #%d has no node id", issue.Number), Message: "Adding the issue to a project requires its node id"}
+	}
+	board, returnErr := getProject(graphql, project, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	desired, returnErr := projectFieldValues(board, project, time.Now())
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+
+	// Find the item of the issue on the board, with its current values
+	var items struct {
+		Node struct {
+			ProjectItems struct {
+				Nodes []projectItemNode `json:"nodes"`
+			} `json:"projectItems"`
+		} `json:"node"`
+	}
+	returnErr = graphql("IssueProjectItems", issueProjectItemsQuery, map[string]interface{}{"id": issue.NodeID}, &items, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	item := &ProjectItem{ProjectID: board.ID}
+	current := map[string]string{}
+	for _, node := range items.Node.ProjectItems.Nodes {
+		if node.Project.ID != board.ID {
+			continue
+		}
+		item.ID = node.ID
+		for _, value := range node.FieldValues.Nodes {
+			current[value.Field.ID] = fieldValueKey(value.OptionID, value.IterationID, value.Text, value.Number, value.Date)
+		}
+	}
+	if item.ID == "" {
+		var added struct {
+			AddProjectV2ItemByID struct {
+				Item struct {
+					ID string `json:"id"`
+				} `json:"item"`
+			} `json:"addProjectV2ItemById"`
+		}
+		variables := map[string]interface{}{"project": board.ID, "content": issue.NodeID}
+		returnErr = graphql("AddProjectItem", addProjectItemMutation, variables, &added, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		item.ID = added.AddProjectV2ItemByID.Item.ID
+	}
+
+	for fieldID, value := range desired {
+		if current[fieldID] == fieldValueKey(stringValue(value, "singleSelectOptionId"), stringValue(value, "iterationId"),
+			stringPointer(value, "text"), numberPointer(value), stringPointer(value, "date")) {
+			continue
+		}
+		variables := map[string]interface{}{"project": board.ID, "item": item.ID, "field": fieldID, "value": value}
+		returnErr = graphql("UpdateProjectItemField", updateProjectItemFieldMutation, variables, &struct{}{}, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+	}
+	return item, &Error{}
+}
+
+// getProject returns the project with its fields, by its node id or by its owner and number
+func getProject(graphql graphqlFunc, project *Project, detailsData *Details) (*projectV2, *Error) {
+	if project.ID != "" {
+		var byID struct {
+			Node *projectV2 `json:"node"`
+		}
+		returnErr := graphql("ProjectByID", projectByIDQuery, map[string]interface{}{"id": project.ID}, &byID, detailsData)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		if byID.Node == nil || byID.Node.ID == "" {
+			return nil, &Error{ErrorCode: fmt.Errorf("project %s not found", project.ID), Message: "Could not resolve to a ProjectV2 with the id " + project.ID}
+		}
+		return byID.Node, &Error{}
+	}
+	var byNumber struct {
+		RepositoryOwner *struct {
+			ProjectV2 *projectV2 `json:"projectV2"`
+		} `json:"repositoryOwner"`
+	}
+	variables := map[string]interface{}{"owner": project.Owner, "number": project.Number}
+	returnErr := graphql("ProjectByNumber", projectByNumberQuery, variables, &byNumber, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if byNumber.RepositoryOwner == nil || byNumber.RepositoryOwner.ProjectV2 == nil {
+		name := project.Owner + "/" + strconv.Itoa(project.Number)
+		return nil, &Error{ErrorCode: fmt.Errorf("project %s not found", name), Message: "Could not resolve to a ProjectV2 with the number " + name}
+	}
+	return byNumber.RepositoryOwner.ProjectV2, &Error{}
+}
+
+// projectFieldValues resolves the desired values of a project to ProjectV2FieldValue inputs by field id
+func projectFieldValues(board *projectV2, project *Project, now time.Time) (map[string]map[string]interface{}, *Error) {
+	wanted := map[string]string{}
+	for name, value := range project.Fields {
+		wanted[name] = value
+	}
+	if project.Status != "" {
+		wanted["Status"] = project.Status
+	}
+	values := map[string]map[string]interface{}{}
+	for name, value := range wanted {
+		field := findProjectField(board, func(f projectField) bool { return strings.EqualFold(f.Name, name) })
+		if field == nil {
+			return nil, projectError("project has no field %q", name)
+		}
+		resolved, returnErr := resolveFieldValue(field, value, now)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		values[field.ID] = resolved
+	}
+	if project.Iteration != "" {
+		field := findProjectField(board, func(f projectField) bool { return f.DataType == "ITERATION" })
+		if field == nil {
+			return nil, projectError("project has no iteration field")
+		}
+		resolved, returnErr := resolveFieldValue(field, project.Iteration, now)
+		if returnErr.ErrorCode != nil {
+			return nil, returnErr
+		}
+		values[field.ID] = resolved
+	}
+	return values, &Error{}
+}
+
+func resolveFieldValue(field *projectField, value string, now time.Time) (map[string]interface{}, *Error) {
+	switch field.DataType {
+	case "SINGLE_SELECT":
+		for _, option := range field.Options {
+			if strings.EqualFold(option.Name, value) {
+				return map[string]interface{}{"singleSelectOptionId": option.ID}, &Error{}
+			}
+		}
+		return nil, projectError("field %q has no option %q", field.Name, value)
+	case "ITERATION":
+		for _, iteration := range field.Configuration.Iterations {
+			if value == CurrentIteration {
+				start, err := time.Parse("2006-01-02", iteration.StartDate)
+				if err == nil && !now.Before(start) && now.Before(start.AddDate(0, 0, iteration.Duration)) {
+					return map[string]interface{}{"iterationId": iteration.ID}, &Error{}
+				}
+			} else if strings.EqualFold(iteration.Title, value) {
+				return map[string]interface{}{"iterationId": iteration.ID}, &Error{}
+			}
+		}
+		return nil, projectError("field %q has no iteration %q", field.Name, value)
+	case "NUMBER":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, projectError("field %q expects a number, got %q", field.Name, value)
+		}
+		return map[string]interface{}{"number": number}, &Error{}
+	case "DATE":
+		return map[string]interface{}{"date": value}, &Error{}
+	case "TEXT":
+		return map[string]interface{}{"text": value}, &Error{}
+	}
+	return nil, projectError("field %q of type %s can't be set", field.Name, field.DataType)
+}
+
+func findProjectField(board *projectV2, match func(projectField) bool) *projectField {
+	for i := range board.Fields.Nodes {
+		if board.Fields.Nodes[i].ID != "" && match(board.Fields.Nodes[i]) {
+			return &board.Fields.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func projectError(format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{ErrorCode: err, Message: "Setting the project fields failed: \n" + err.Error()}
+}
+
+// fieldValueKey returns a comparable form of a field value
+func fieldValueKey(optionID, iterationID string, text *string, number *float64, date *string) string {
+	switch {
+	case optionID != "":
+		return "option:" + optionID
+	case iterationID != "":
+		return "iteration:" + iterationID
+	case text != nil:
+		return "text:" + *text
+	case number != nil:
+		return "number:" + strconv.FormatFloat(*number, 'g', -1, 64)
+	case date != nil:
+		return "date:" + *date
+	}
+	return ""
+}
+
+func stringValue(value map[string]interface{}, key string) string {
+	s, _ := value[key].(string)
+	return s
+}
+
+func stringPointer(value map[string]interface{}, key string) *string {
+	if s, ok := value[key].(string); ok {
+		return &s
+	}
+	return nil
+}
+
+func numberPointer(value map[string]interface{}) *float64 {
+	if n, ok := value["number"].(float64); ok {
+		return &n
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package clients
+
+import (
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestProject seeds a project with a Status, an iteration, a text and a number field
+func newTestProject(server *fakegithub.Server) fakegithub.Project {
+	today := time.Now().UTC()
+	return server.AddProject(fakegithub.Project{
+		Owner:  "arielireni",
+		Number: 3,
+		Fields: []fakegithub.ProjectField{
+			{Name: "Title", DataType: "TITLE"},
+			{Name: "Status", DataType: "SINGLE_SELECT", Options: []fakegithub.ProjectOption{{Name: "Todo"}, {Name: "In Progress"}, {Name: "Done"}}},
+			{Name: "Sprint", DataType: "ITERATION", Iterations: []fakegithub.ProjectIteration{
+				{Title: "Sprint 1", StartDate: today.AddDate(0, 0, -20).Format("2006-01-02"), Duration: 14},
+				{Title: "Sprint 2", StartDate: today.AddDate(0, 0, -6).Format("2006-01-02"), Duration: 14},
+			}},
+			{Name: "Team", DataType: "TEXT"},
+			{Name: "Estimate", DataType: "NUMBER"},
+		},
+	})
+}
+
+// updates returns the number of field updates sent to the server
+func updates(server *fakegithub.Server) int {
+	count := 0
+	for _, req := range server.Requests() {
+		if strings.Contains(req.Body, `"operationName":"UpdateProjectItemField"`) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGithubClientSyncProjectItem(t *testing.T) {
+	// Given an issue and a project
+	githubClient, server := newTestGithubClient(t)
+	seeded := server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	board := newTestProject(server)
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+	issue := &Issue{Number: seeded.Number, NodeID: seeded.NodeID}
+	project := &Project{
+		Owner:     "arielireni",
+		Number:    3,
+		Status:    "in progress",
+		Iteration: CurrentIteration,
+		Fields:    map[string]string{"Team": "platform", "Estimate": "3"},
+	}
+
+	// When syncing it by the owner and number of the project, then it is added with the field values
+	item, returnErr := githubClient.SyncProjectItem(issue, project, detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	stored, _ := server.Project(board.ID)
+	if len(stored.Items) != 1 || stored.Items[0].ID != item.ID || stored.Items[0].ContentID != seeded.NodeID || item.ProjectID != board.ID {
+		t.Fatalf("Expected the issue on the project but got %+v, item %+v", stored.Items, item)
+	}
+	values := stored.Items[0].Values
+	if values[board.Fields[1].ID]["singleSelectOptionId"] != board.Fields[1].Options[1].ID {
+		t.Errorf("Expected the status In Progress but got %v", values[board.Fields[1].ID])
+	}
+	if values[board.Fields[2].ID]["iterationId"] != board.Fields[2].Iterations[1].ID {
+		t.Errorf("Expected the current iteration but got %v", values[board.Fields[2].ID])
+	}
+	if values[board.Fields[3].ID]["text"] != "platform" || values[board.Fields[4].ID]["number"] != float64(3) {
+		t.Errorf("Expected the text and number values but got %v", values)
+	}
+	if updates(server) != 4 {
+		t.Errorf("Expected 4 field updates but got %d", updates(server))
+	}
+
+	// When syncing it again by the project id, then nothing is changed
+	project.ID, project.Owner, project.Number = board.ID, "", 0
+	again, returnErr := githubClient.SyncProjectItem(issue, project, detailsData)
+	if returnErr.ErrorCode != nil || again.ID != item.ID {
+		t.Fatalf("Expected the same item but got %+v, %v", again, returnErr.ErrorCode)
+	}
+	if updates(server) != 4 {
+		t.Errorf("Expected no more field updates but got %d", updates(server)-4)
+	}
+
+	// When a value changes, then only it is updated
+	project.Status = "Done"
+	if _, returnErr = githubClient.SyncProjectItem(issue, project, detailsData); returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if updates(server) != 5 {
+		t.Errorf("Expected a single field update but got %d", updates(server)-4)
+	}
+}
+
+func TestGithubClientSyncProjectItemErrors(t *testing.T) {
+	githubClient, server := newTestGithubClient(t)
+	seeded := server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	board := newTestProject(server)
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+	issue := &Issue{Number: seeded.Number, NodeID: seeded.NodeID}
+
+	cases := map[string]*Project{
+		"missing project": {Owner: "arielireni", Number: 4},
+		"missing id":      {ID: "PVT_missing"},
+		"missing option":  {ID: board.ID, Status: "Blocked"},
+		"missing field":   {ID: board.ID, Fields: map[string]string{"Priority": "P1"}},
+		"bad number":      {ID: board.ID, Fields: map[string]string{"Estimate": "three"}},
+		"bad iteration":   {ID: board.ID, Iteration: "Sprint 9"},
+	}
+	for name, project := range cases {
+		if _, returnErr := githubClient.SyncProjectItem(issue, project, detailsData); returnErr.ErrorCode == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if stored, _ := server.Project(board.ID); len(stored.Items) != 0 {
+		t.Errorf("Expected the issue not to be added but got %+v", stored.Items)
+	}
+}
+
+func TestGraphQLClientSyncProjectItemRateLimit(t *testing.T) {
+	// Given a token whose points cover the project query but not the items of the issue on top of the reserve
+	graphqlClient, server, _ := newTestGraphQLClient(t)
+	server.RateLimit = 120
+	seeded := server.AddIssue(testRepo, fakegithub.Issue{Title: "title1"})
+	board := newTestProject(server)
+	_, _, detailsData := graphqlClient.InitDataStructs(testRepo, "", "")
+	issue := &Issue{Number: seeded.Number, NodeID: seeded.NodeID}
+
+	// When syncing it, then every request is metered and it fails before querying the items
+	_, returnErr := graphqlClient.SyncProjectItem(issue, &Project{ID: board.ID, Status: "Todo"}, detailsData)
+	if returnErr.ErrorCode == nil || !strings.Contains(returnErr.Message, "rate limit") {
+		t.Fatalf("Expected a rate limit error but got %+v", returnErr)
+	}
+	if operations(server, "ProjectByID") != 1 || operations(server, "IssueProjectItems") != 0 {
+		t.Errorf("Expected only the project to be queried but got %d requests", len(server.Requests()))
+	}
+	if graphqlClient.remaining < 0 {
+		t.Errorf("Expected the remaining points to be known but got %d", graphqlClient.remaining)
+	}
+}
+
+func TestGithubClientGraphqlURL(t *testing.T) {
+	githubClient := NewGithubClient()
+	_, _, detailsData := githubClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	if url := githubClient.graphqlURL(detailsData); url != "https://api.github.com/graphql" {
+		t.Errorf("Expected the github.com endpoint but got %s", url)
+	}
+	_, _, detailsData = githubClient.InitDataStructs("https://github.example.com/arielireni/Issues-Example", "", "")
+	if url := githubClient.graphqlURL(detailsData); url != "https://github.example.com/api/graphql" {
+		t.Errorf("Expected the enterprise endpoint of the repo but got %s", url)
+	}
+	githubClient.BaseURL = "https://github.example.com/api/v3"
+	if url := githubClient.graphqlURL(&Details{}); url != "https://github.example.com/api/graphql" {
+		t.Errorf("Expected the enterprise endpoint but got %s", url)
+	}
+}
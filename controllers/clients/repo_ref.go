@@ -0,0 +1,35 @@
+package clients
+
+import (
+	"github.com/arielireni/example-operator/pkg/reporef"
+	"net/url"
+	"strings"
+)
+
+// initDataStructs is the InitDataStructs shared by the clients, a malformed repo is kept as it is in the
+// api url so the requests fail with its name. The default token is only given to the repos under baseURL, an
+// enterprise repo needs its own credentials
+func initDataStructs(baseURL, repo, title, body, token string) (*Repo, *Issue, *Details) {
+	repoData := Repo{}
+	apiURL := baseURL + "/repos/" + repo + "/issues"
+	if ref, err := reporef.Parse(repo); err == nil {
+		repoData = Repo{Owner: ref.Owner, Repo: ref.Repo}
+		apiURL = ref.IssuesURL(baseURL)
+	}
+	if !sameHost(apiURL, baseURL) {
+		token = ""
+	}
+	issueData := Issue{Title: title, Description: body}
+	detailsData := Details{ApiURL: apiURL, Token: token}
+	return &repoData, &issueData, &detailsData
+}
+
+// sameHost returns true if both urls are on the same host and port
+func sameHost(a, b string) bool {
+	u, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	v, err := url.Parse(b)
+	return err == nil && strings.EqualFold(u.Host, v.Host)
+}
@@ -0,0 +1,23 @@
+package clients
+
+import (
+	"testing"
+)
+
+func TestInitDataStructs(t *testing.T) {
+	githubClient := NewGithubClient()
+
+	// The owner comes before the repo, and an enterprise repo is reached through its own API
+	repoData, _, detailsData := githubClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	if repoData.Owner != "arielireni" || repoData.Repo != "Issues-Example" || detailsData.ApiURL != "https://api.github.com/repos/arielireni/issues-example/issues" {
+		t.Errorf("Expected arielireni/Issues-Example on github.com but got %+v, %s", repoData, detailsData.ApiURL)
+	}
+	_, _, detailsData = githubClient.InitDataStructs("git@github.example.com:arielireni/Issues-Example.git", "", "")
+	if detailsData.ApiURL != "https://github.example.com/api/v3/repos/arielireni/issues-example/issues" {
+		t.Errorf("Expected the enterprise api url but got %s", detailsData.ApiURL)
+	}
+	_, _, detailsData = githubClient.InitDataStructs("http://github.example.com:8080/arielireni/Issues-Example", "", "")
+	if detailsData.ApiURL != "http://github.example.com:8080/api/v3/repos/arielireni/issues-example/issues" {
+		t.Errorf("Expected the enterprise api url with its scheme and port but got %s", detailsData.ApiURL)
+	}
+}
@@ -0,0 +1,54 @@
+package clients
+
+import (
+	"strings"
+)
+
+// Repository structure declaration - a repo as seen with the token of the requests
+type Repository struct {
+	FullName  string
+	Private   bool
+	HasIssues bool
+	// Permission represents the access of the token to the repo: admin, maintain, write, triage or read
+	Permission string
+	// OpenIssues counts the open issues, without the pull requests
+	OpenIssues int
+}
+
+const repositoryQuery = `query Repository($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    nameWithOwner isPrivate hasIssuesEnabled viewerPermission
+    issues(states: OPEN) { totalCount }
+  }
+}`
+
+// GetRepository returns the repo of detailsData, with the permission of the token on it. It's a GraphQL query for
+// both clients, as the REST API counts the pull requests among the open issues
+func (g *GithubClient) GetRepository(detailsData *Details) (*Repository, *Error) {
+	owner, name, returnErr := repoOfDetails(detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	var result struct {
+		Repository struct {
+			NameWithOwner    string `json:"nameWithOwner"`
+			IsPrivate        bool   `json:"isPrivate"`
+			HasIssuesEnabled bool   `json:"hasIssuesEnabled"`
+			ViewerPermission string `json:"viewerPermission"`
+			Issues           struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"issues"`
+		} `json:"repository"`
+	}
+	variables := map[string]interface{}{"owner": owner, "name": name}
+	if returnErr := g.graphql("Repository", repositoryQuery, variables, &result, detailsData); returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	return &Repository{
+		FullName:   result.Repository.NameWithOwner,
+		Private:    result.Repository.IsPrivate,
+		HasIssues:  result.Repository.HasIssuesEnabled,
+		Permission: strings.ToLower(result.Repository.ViewerPermission),
+		OpenIssues: result.Repository.Issues.TotalCount,
+	}, &Error{}
+}
@@ -0,0 +1,32 @@
+package clients
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/arielireni/example-operator/controllers/clients/fakegithub"
+)
+
+func TestGithubClientGetRepository(t *testing.T) {
+	// Given a repo with an open and a closed issue, on which the token can triage
+	githubClient, server := newTestGithubClient(t)
+	server.Permission = "TRIAGE"
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "open"})
+	server.AddIssue(testRepo, fakegithub.Issue{Title: "closed", State: "closed"})
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+
+	// Then the repo reports the permission and counts the open issue only
+	repository, returnErr := githubClient.GetRepository(detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if repository.Permission != "triage" || repository.OpenIssues != 1 || !repository.HasIssues || repository.FullName != "arielireni/issues-example" {
+		t.Errorf("Expected triage on a repo with 1 open issue but got %+v", repository)
+	}
+
+	// And a repo out of reach of the token is reported with the response status
+	server.Token = "other"
+	if _, returnErr := githubClient.GetRepository(detailsData); returnErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected %d but got %+v", http.StatusUnauthorized, returnErr)
+	}
+}
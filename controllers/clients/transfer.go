@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"net/http"
+)
+
+const repositoryIDQuery = `query RepositoryID($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { id }
+}`
+
+const transferIssueMutation = `mutation TransferIssue($id: ID!, $repository: ID!) {
+  transferIssue(input: {issueId: $id, repositoryId: $repository}) { issue { number } }
+}`
+
+// TransferIssue moves the issue from the repo of detailsData to the repo of targetData, keeping its comments,
+// and sets its number in the new repo. Both repos must have the same owner
+func (g *GithubClient) TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error {
+	owner, name, returnErr := repoOfDetails(targetData)
+	if returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	var target struct {
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+	variables := map[string]interface{}{"owner": owner, "name": name}
+	if returnErr := g.graphql("RepositoryID", repositoryIDQuery, variables, &target, targetData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	var transferred struct {
+		TransferIssue struct {
+			Issue struct {
+				Number int `json:"number"`
+			} `json:"issue"`
+		} `json:"transferIssue"`
+	}
+	variables = map[string]interface{}{"id": issue.NodeID, "repository": target.Repository.ID}
+	if returnErr := g.graphql("TransferIssue", transferIssueMutation, variables, &transferred, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	issue.Number = transferred.TransferIssue.Issue.Number
+	return &Error{StatusCode: http.StatusOK}
+}
+
+// TransferIssue transfers the issue through the GithubClient, and drops the snapshots of both repos
+func (g *GraphQLClient) TransferIssue(issue *Issue, detailsData *Details, targetData *Details) *Error {
+	if returnErr := g.reserve(2 * mutationCost); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	returnErr := g.GithubClient.TransferIssue(issue, detailsData, targetData)
+	g.forget(detailsData)
+	g.forget(targetData)
+	return returnErr
+}
@@ -0,0 +1,36 @@
+package clients
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// User structure declaration - the user a token belongs to
+type User struct {
+	Login string `json:"login"`
+}
+
+// GetUser returns the user the token of detailsData belongs to, on the host of the repo of detailsData
+func (g *GithubClient) GetUser(detailsData *Details) (*User, *Error) {
+	body, resp, returnErr := g.doRequest("GET", userURL(detailsData), nil, detailsData)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("Getting GitHub user failed with response: \n", resp, body)
+	}
+	var user *User
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, &Error{ErrorCode: err, Message: "Unmarshal failed with response: \n" + string(body), StatusCode: resp.StatusCode}
+	}
+	return user, &Error{StatusCode: resp.StatusCode}
+}
+
+// userURL returns the url of the authenticated user on the API of the repo of detailsData
+func userURL(detailsData *Details) string {
+	if i := strings.LastIndex(detailsData.ApiURL, "/repos/"); i >= 0 {
+		return detailsData.ApiURL[:i] + "/user"
+	}
+	return detailsData.ApiURL + "/user"
+}
@@ -0,0 +1,28 @@
+package clients
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGithubClientGetUser(t *testing.T) {
+	// Given a token of the fake user
+	githubClient, server := newTestGithubClient(t)
+	server.Login = "octocat"
+	_, _, detailsData := githubClient.InitDataStructs(testRepo, "", "")
+
+	// Then the user of the token is returned
+	user, returnErr := githubClient.GetUser(detailsData)
+	if returnErr.ErrorCode != nil {
+		t.Fatalf("Expected nil but got error: %s", returnErr.Message)
+	}
+	if user.Login != "octocat" {
+		t.Errorf("Expected octocat but got %+v", user)
+	}
+
+	// And a rejected token is reported with the response status
+	server.Token = "other"
+	if _, returnErr := githubClient.GetUser(detailsData); returnErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected %d but got %+v", http.StatusUnauthorized, returnErr)
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretReader returns the reader of the credentials Secrets, the API reader of the manager so that Secrets are
+// read uncached with get access only, or c if there is none
+func secretReader(apiReader client.Reader, c client.Client) client.Reader {
+	if apiReader != nil {
+		return apiReader
+	}
+	return c
+}
+
+// WithCredentials sets the token of the spec.credentialsRef Secret of a GitHubIssue in detailsData, or of its
+// GitHubRepository's if it has none. The Secret of a GitHubRepository is in the repository's namespace. It's
+// exported for ghissuectl to manage the issue with the credentials the operator uses
+func WithCredentials(ctx context.Context, c client.Reader, ghIssue *examplev1alpha1.GitHubIssue, ghRepo *examplev1alpha1.GitHubRepository, detailsData *clients.Details) *clients.Error {
+	return credentialsOf(ctx, c, ghIssue.Namespace, ghIssue.Spec.CredentialsRef, ghIssue.Spec.Repo, ghRepo, detailsData)
+}
+
+// credentialsOf sets the token of the Secret referenced from an object of the namespace in detailsData, or of
+// its GitHubRepository's if ref is nil. The repository's token is only used on the repo of the repository, an
+// object setting another repo is managed with the token of the operator
+func credentialsOf(ctx context.Context, c client.Reader, namespace string, ref *examplev1alpha1.CredentialsReference, repo string, ghRepo *examplev1alpha1.GitHubRepository, detailsData *clients.Details) *clients.Error {
+	if ref != nil {
+		return readToken(ctx, c, namespace, ref, detailsData)
+	}
+	if ghRepo != nil && ghRepo.Spec.CredentialsRef != nil && repoKey(repo) == repoKey(repositoryRepo(ghRepo)) {
+		return readToken(ctx, c, ghRepo.Namespace, ghRepo.Spec.CredentialsRef, detailsData)
+	}
+	return &clients.Error{}
+}
+
+// readToken sets the token of the referenced Secret key in detailsData
+func readToken(ctx context.Context, c client.Reader, namespace string, ref *examplev1alpha1.CredentialsReference, detailsData *clients.Details) *clients.Error {
+	key := ref.Key
+	if key == "" {
+		key = "token"
+	}
+	secret := corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+		return &clients.Error{ErrorCode: err, Message: "Getting the credentials failed: " + err.Error()}
+	}
+	token, ok := secret.Data[key]
+	if !ok {
+		err := fmt.Errorf("secret %s has no key %s", ref.Name, key)
+		return &clients.Error{ErrorCode: err, Message: "Getting the credentials failed: " + err.Error()}
+	}
+	detailsData.Token = string(token)
+	return &clients.Error{}
+}
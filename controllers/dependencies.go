@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/arielireni/example-operator/pkg/reporef"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+	"time"
+)
+
+// ConditionBlocked is true while any of the GitHubIssue objects in spec.blockedBy is open
+const ConditionBlocked = "Blocked"
+
+// blockedByIndex indexes GitHubIssue objects by the namespace/name of their blockers
+const blockedByIndex = ".spec.blockedBy"
+
+// blockedByMarker starts the generated part of the body, everything from it on is rewritten by the reconciler
+const blockedByMarker = "<!-- generated: blocked-by -->"
+
+// blocker is a resolved reference of spec.blockedBy
+type blocker struct {
+	key string
+	// ghIssue is nil if the referenced object doesn't exist
+	ghIssue *examplev1alpha1.GitHubIssue
+}
+
+func (b blocker) open() bool {
+	return b.ghIssue == nil || b.ghIssue.Status.State != "closed"
+}
+
+// referenceKey returns the namespace/name a reference points to, relative to the referring object
+func referenceKey(ghIssue *examplev1alpha1.GitHubIssue, ref examplev1alpha1.IssueReference) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = ghIssue.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// repoName returns a repo as owner/repo, the way GitHub links the issues of other repos
+func repoName(repo string) string {
+	if ref, err := reporef.Parse(repo); err == nil {
+		return ref.String()
+	}
+	return repo
+}
+
+// issueRepo returns the repo of the real issue of a GitHubIssue, its spec.repo until it's created, as the repo
+// of a GitHubRepository is only resolved by its own reconcile
+func issueRepo(ghIssue *examplev1alpha1.GitHubIssue) string {
+	if ghIssue.Status.Repo != "" {
+		return ghIssue.Status.Repo
+	}
+	return ghIssue.Spec.Repo
+}
+
+// resolveBlockers gets the GitHubIssue objects of spec.blockedBy
+func (r *GitHubIssueReconciler) resolveBlockers(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue) ([]blocker, error) {
+	return blockersOf(ctx, r.Client, ghIssue)
+}
+
+// blockersOf gets the GitHubIssue objects of spec.blockedBy with the reader
+func blockersOf(ctx context.Context, c client.Reader, ghIssue *examplev1alpha1.GitHubIssue) ([]blocker, error) {
+	var blockers []blocker
+	for _, ref := range ghIssue.Spec.BlockedBy {
+		key := referenceKey(ghIssue, ref)
+		dependency := &examplev1alpha1.GitHubIssue{}
+		if err := c.Get(ctx, key, dependency); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			dependency = nil
+		}
+		blockers = append(blockers, blocker{key: key.String(), ghIssue: dependency})
+	}
+	return blockers, nil
+}
+
+// withBlockedBy returns the body with the links to the blockers appended, by their resolved issue numbers
+func withBlockedBy(body string, blockers []blocker) string {
+	if len(blockers) == 0 {
+		return body
+	}
+	lines := []string{blockedByMarker, "**Blocked by:**"}
+	for _, b := range blockers {
+		switch {
+		case b.ghIssue == nil:
+			lines = append(lines, fmt.Sprintf("- %s (not found)", b.key))
+		case b.ghIssue.Status.Number == 0:
+			lines = append(lines, fmt.Sprintf("- %s (not created yet)", b.key))
+		default:
+			lines = append(lines, fmt.Sprintf("- %s#%d", repoName(issueRepo(b.ghIssue)), b.ghIssue.Status.Number))
+		}
+	}
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + strings.Join(lines, "\n")
+}
+
+// blockedCondition returns the Blocked condition for the blockers
+func blockedCondition(ghIssue *examplev1alpha1.GitHubIssue, blockers []blocker) metav1.Condition {
+	var open []string
+	for _, b := range blockers {
+		if b.open() {
+			open = append(open, b.key)
+		}
+	}
+	if len(open) == 0 {
+		return metav1.Condition{
+			Type:               ConditionBlocked,
+			Status:             metav1.ConditionFalse,
+			Reason:             "BlockersClosed",
+			Message:            "All the blocking issues are closed",
+			ObservedGeneration: ghIssue.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               ConditionBlocked,
+		Status:             metav1.ConditionTrue,
+		Reason:             "BlockersOpen",
+		Message:            "Blocked by " + strings.Join(open, ", "),
+		ObservedGeneration: ghIssue.Generation,
+	}
+}
+
+// setBlockedCondition reports the blockers in the status, removing the condition if there are none
+func setBlockedCondition(ghIssue *examplev1alpha1.GitHubIssue, blockers []blocker) {
+	if len(ghIssue.Spec.BlockedBy) == 0 {
+		// RemoveStatusCondition panics on an empty list in this apimachinery version
+		if meta.FindStatusCondition(ghIssue.Status.Conditions, ConditionBlocked) != nil {
+			meta.RemoveStatusCondition(&ghIssue.Status.Conditions, ConditionBlocked)
+		}
+		return
+	}
+	meta.SetStatusCondition(&ghIssue.Status.Conditions, blockedCondition(ghIssue, blockers))
+}
+
+// holdPlan returns the plan keeping the issue closed as not planned while it's blocked, and reopening it once
+// it isn't, nil if there is nothing to do
+func holdPlan(ghIssue *examplev1alpha1.GitHubIssue, blockers []blocker, issue *clients.Issue) *examplev1alpha1.IssuePlan {
+	if !ghIssue.Spec.CloseWhileBlocked || issue == nil || ghIssue.Spec.State == "closed" || isExpired(ghIssue, time.Now()) {
+		return nil
+	}
+	blocked := blockedCondition(ghIssue, blockers).Status == metav1.ConditionTrue
+	if blocked && issue.State != "closed" {
+		return &examplev1alpha1.IssuePlan{
+			Action:  PlanClose,
+			Changes: []examplev1alpha1.FieldChange{{Field: "state", From: issue.State, To: "closed (not planned)"}},
+		}
+	}
+	// Only the issues closed while blocked are reopened, not the ones closed as completed
+	if !blocked && issue.State == "closed" && issue.StateReason == "not_planned" {
+		return &examplev1alpha1.IssuePlan{
+			Action:  PlanReopen,
+			Changes: []examplev1alpha1.FieldChange{{Field: "state", From: issue.State, To: "open"}},
+		}
+	}
+	return nil
+}
+
+// indexBlockedBy returns the namespace/name of the blockers of a GitHubIssue, for blockedByIndex
+func indexBlockedBy(obj client.Object) []string {
+	ghIssue := obj.(*examplev1alpha1.GitHubIssue)
+	var keys []string
+	for _, ref := range ghIssue.Spec.BlockedBy {
+		keys = append(keys, referenceKey(ghIssue, ref).String())
+	}
+	return keys
+}
+
+// relatedIssues returns the GitHubIssue objects blocked by the given one and its parent,
+// so they are reconciled when it changes
+func (r *GitHubIssueReconciler) relatedIssues(obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	if ghIssue, ok := obj.(*examplev1alpha1.GitHubIssue); ok && ghIssue.Spec.ParentRef != nil {
+		requests = append(requests, reconcile.Request{NamespacedName: referenceKey(ghIssue, *ghIssue.Spec.ParentRef)})
+	}
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+	if err := r.Client.List(context.Background(), &ghIssues, client.MatchingFields{blockedByIndex: key}); err != nil {
+		r.Log.Info("failed to list the dependent issues", "error", err.Error())
+		return requests
+	}
+	for _, ghIssue := range ghIssues.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ghIssue.Namespace, Name: ghIssue.Name}})
+	}
+	return requests
+}
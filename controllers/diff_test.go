@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	// Equal texts have no diff
+	if diff := UnifiedDiff("a\nb\n", "a\nb\n", "from", "to"); diff != "" {
+		t.Errorf("Expected no diff but got %q", diff)
+	}
+
+	// A changed line is shown with its context
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	to := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+	expected := "--- from\n+++ to\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if diff := UnifiedDiff(from, to, "from", "to"); diff != expected {
+		t.Errorf("Expected %q but got %q", expected, diff)
+	}
+
+	// Adding to an empty text
+	expected = "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if diff := UnifiedDiff("", "a\nb", "from", "to"); diff != expected {
+		t.Errorf("Expected %q but got %q", expected, diff)
+	}
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// generatedMarker starts every generated part of the body, see blockedByMarker and taskListMarker
+const generatedMarker = "<!-- generated:"
+
+// DefaultEditCommentTemplate is the comment posted on the real issue when spec.commentOnEdit is set and
+// no other template is configured
+const DefaultEditCommentTemplate = `The issue was updated from its GitHubIssue object.
+{{- if .Diff}}
+
+` + "```diff" + `
+{{.Diff}}` + "```" + `
+{{- end}}
+{{- if .AddedLabels}}
+
+Added labels: {{join .AddedLabels ", "}}
+{{- end}}
+{{- if .RemovedLabels}}
+
+Removed labels: {{join .RemovedLabels ", "}}
+{{- end}}
+{{- if .AddedAssignees}}
+
+Assigned: {{join .AddedAssignees ", "}}
+{{- end}}
+{{- if .RemovedAssignees}}
+
+Unassigned: {{join .RemovedAssignees ", "}}
+{{- end}}
+`
+
+// EditSummary is the data the edit comment template is executed with
+type EditSummary struct {
+	// Title represents the title of the real issue
+	Title string
+	// Diff represents the unified diff of the body, without its generated parts
+	Diff             string
+	AddedLabels      []string
+	RemovedLabels    []string
+	AddedAssignees   []string
+	RemovedAssignees []string
+}
+
+// empty returns true if nothing a reader would notice changed
+func (s EditSummary) empty() bool {
+	return s.Diff == "" && len(s.AddedLabels) == 0 && len(s.RemovedLabels) == 0 &&
+		len(s.AddedAssignees) == 0 && len(s.RemovedAssignees) == 0
+}
+
+// ParseEditCommentTemplate parses the text of an edit comment template, with a join function for the lists
+func ParseEditCommentTemplate(text string) (*template.Template, error) {
+	return template.New("edit-comment").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+}
+
+// summarizeEdit returns what changed between the real issue before and after an edit
+func summarizeEdit(before, after *clients.Issue) EditSummary {
+	summary := EditSummary{
+		Title: after.Title,
+		Diff:  UnifiedDiff(authoredBody(before.Description), authoredBody(after.Description), "before", "after"),
+	}
+	summary.AddedLabels, summary.RemovedLabels = stringsChanges(before.Labels, after.Labels)
+	summary.AddedAssignees, summary.RemovedAssignees = stringsChanges(before.Assignees, after.Assignees)
+	return summary
+}
+
+// authoredBody returns the body without the parts generated by the reconciler
+func authoredBody(body string) string {
+	if i := strings.Index(body, generatedMarker); i >= 0 {
+		body = strings.TrimRight(body[:i], "\n")
+	}
+	return body
+}
+
+// stringsChanges returns the sorted strings of to missing from from, and the ones of from missing from to
+func stringsChanges(from, to []string) ([]string, []string) {
+	return missingFrom(from, to), missingFrom(to, from)
+}
+
+// missingFrom returns the sorted strings of b that a doesn't hold
+func missingFrom(a, b []string) []string {
+	held := map[string]bool{}
+	for _, s := range a {
+		held[s] = true
+	}
+	var missing []string
+	for _, s := range b {
+		if !held[s] {
+			missing = append(missing, s)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// commentOnEdit posts the summary of an edit of the real issue as a comment on it, if spec.commentOnEdit is set
+// and anything but the generated parts of the body changed. A comment that can't be posted is recorded in the
+// status, as the next sync finds nothing left to edit
+func (r *GitHubIssueReconciler) commentOnEdit(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, before, after *clients.Issue, detailsData *clients.Details) *clients.Error {
+	if !ghIssue.Spec.CommentOnEdit {
+		return &clients.Error{}
+	}
+	summary := summarizeEdit(before, after)
+	if summary.empty() {
+		return &clients.Error{}
+	}
+	tmpl := r.EditCommentTemplate
+	if tmpl == nil {
+		tmpl = defaultEditCommentTemplate
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, summary); err != nil {
+		return &clients.Error{ErrorCode: err, Message: "Rendering the edit comment failed: " + err.Error()}
+	}
+	_, returnErr := r.ClientFrame.CreateComment(after.Number, &clients.Comment{Body: body.String()}, detailsData)
+	if returnErr.ErrorCode != nil {
+		patch := client.MergeFrom(ghIssue.DeepCopy())
+		ghIssue.Status.PendingEditComment = body.String()
+		if err := r.Client.Status().Patch(ctx, ghIssue, patch); err != nil {
+			r.Log.Info("failed to record the pending edit comment", "error", err.Error())
+		}
+	}
+	return returnErr
+}
+
+// postPendingEditComment posts the edit comment recorded in the status, and clears it
+func (r *GitHubIssueReconciler) postPendingEditComment(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, issue *clients.Issue, detailsData *clients.Details) *clients.Error {
+	if ghIssue.Status.PendingEditComment == "" {
+		return &clients.Error{}
+	}
+	if _, returnErr := r.ClientFrame.CreateComment(issue.Number, &clients.Comment{Body: ghIssue.Status.PendingEditComment}, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	ghIssue.Status.PendingEditComment = ""
+	if err := r.Client.Status().Patch(ctx, ghIssue, patch); err != nil {
+		return &clients.Error{ErrorCode: err, Message: "Clearing the pending edit comment failed: " + err.Error()}
+	}
+	return &clients.Error{}
+}
+
+var defaultEditCommentTemplate = template.Must(ParseEditCommentTemplate(DefaultEditCommentTemplate))
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"time"
+)
+
+// ExpiryTime returns the time the real issue of a GitHubIssue is closed at, the earliest of spec.expiresAt
+// and spec.ttlAfterCreation, and false if it never expires
+func ExpiryTime(ghIssue *examplev1alpha1.GitHubIssue) (time.Time, bool) {
+	var expiry time.Time
+	if ghIssue.Spec.ExpiresAt != "" {
+		if expiresAt, err := time.Parse(time.RFC3339, ghIssue.Spec.ExpiresAt); err == nil {
+			expiry = expiresAt
+		}
+	}
+	if ttl := ghIssue.Spec.TTLAfterCreation; ttl != nil && !ghIssue.CreationTimestamp.IsZero() {
+		if afterCreation := ghIssue.CreationTimestamp.Add(ttl.Duration); expiry.IsZero() || afterCreation.Before(expiry) {
+			expiry = afterCreation
+		}
+	}
+	return expiry, !expiry.IsZero()
+}
+
+// isExpired returns true if the real issue of a GitHubIssue should be closed by now
+func isExpired(ghIssue *examplev1alpha1.GitHubIssue, now time.Time) bool {
+	expiry, ok := ExpiryTime(ghIssue)
+	return ok && !now.Before(expiry)
+}
+
+// deletionTime returns the time a GitHubIssue is deleted at by spec.ttlSecondsAfterClosed,
+// and false if its real issue isn't closed or it has no such ttl
+func deletionTime(ghIssue *examplev1alpha1.GitHubIssue, issue *clients.Issue) (time.Time, bool) {
+	if ghIssue.Spec.TTLSecondsAfterClosed == nil || issue == nil || issue.State != "closed" {
+		return time.Time{}, false
+	}
+	closedAt, err := time.Parse(time.RFC3339, issue.ClosedAt)
+	if err != nil {
+		// Fall back to the last update, which is at least as late as the closing
+		if closedAt, err = time.Parse(time.RFC3339, issue.LastUpdateTimestamp); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return closedAt.Add(time.Duration(*ghIssue.Spec.TTLSecondsAfterClosed) * time.Second), true
+}
+
+// lifetimeRequeue returns how long to wait until the next expiry or deletion of a GitHubIssue, 0 if there is none
+// and true if the object should be deleted right away
+func lifetimeRequeue(ghIssue *examplev1alpha1.GitHubIssue, issue *clients.Issue, now time.Time) (time.Duration, bool) {
+	if deleteAt, ok := deletionTime(ghIssue, issue); ok {
+		if !now.Before(deleteAt) {
+			return 0, true
+		}
+		return deleteAt.Sub(now), false
+	}
+	if expiry, ok := ExpiryTime(ghIssue); ok && issue != nil && issue.State != "closed" && now.Before(expiry) {
+		return expiry.Sub(now), false
+	}
+	return 0, false
+}
@@ -22,23 +22,53 @@ import (
 	"github.com/arielireni/example-operator/controllers/clients"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"text/template"
+	"time"
 )
 
+// ConditionSynced is true when the real issue was successfully reconciled with the spec
+const ConditionSynced = "Synced"
+
+// AdoptAnnotation holds the number of an existing issue the GitHubIssue manages, instead of finding it by title
+const AdoptAnnotation = "example.training.redhat.com/issue-number"
+
+// ResyncAnnotation is set to the time a resync was requested, any change to it triggers a reconciliation
+const ResyncAnnotation = "example.training.redhat.com/resync-requested-at"
+
+// maxConditionMessage bounds the GitHub responses written into condition messages
+const maxConditionMessage = 1024
+
 // GitHubIssueReconciler reconciles a GitHubIssue object
 type GitHubIssueReconciler struct {
 	client.Client
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	ClientFrame clients.ClientFrame
+	// APIReader reads the credentials Secrets, the Client if nil
+	APIReader client.Reader
+	Recorder  record.EventRecorder
+	// DryRun makes the reconciler only plan the changes for every GitHubIssue
+	DryRun bool
+	// EditCommentTemplate renders the comments of spec.commentOnEdit, DefaultEditCommentTemplate if nil
+	EditCommentTemplate *template.Template
 }
 
 //+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissues,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissues/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissues/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubmilestones,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -69,36 +99,128 @@ func (r *GitHubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	log.Info("got the gh issue from api server", "gh-issue", ghIssue)
 
+	// Fill the defaults of the GitHubRepository of the issue, the issue of a deleted object is closed in the
+	// repo it's in even if the repository is gone first
+	ghRepo, returnErr := WithRepository(ctx, r.Client, &ghIssue)
+	if returnErr.ErrorCode != nil {
+		if ghIssue.DeletionTimestamp.IsZero() || ghIssue.Status.Repo == "" {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+		}
+		if ghIssue.Spec.Repo == "" {
+			ghIssue.Spec.Repo = ghIssue.Status.Repo
+		}
+	}
+
 	// Create a github request and create github issues by interacting with the github api
 	repoData, issueData, detailsData := r.ClientFrame.InitDataStructs(ghIssue.Spec.Repo, ghIssue.Spec.Title, ghIssue.Spec.Description)
-	issue, returnErr := r.ClientFrame.FindIssue(repoData, issueData, detailsData)
+	detailsData.Resource = req.NamespacedName.String()
+	if returnErr := WithCredentials(ctx, secretReader(r.APIReader, r.Client), &ghIssue, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+	}
+	issueData.Labels = ghIssue.Spec.Labels
+	issueData.Assignees = ghIssue.Spec.Assignees
+	// The milestone is left alone until it's ready, the milestone of a deleted object's issue doesn't matter anymore
+	milestoneWait := ""
+	if ghIssue.Spec.MilestoneRef != nil && ghIssue.DeletionTimestamp.IsZero() {
+		number, message, err := milestoneNumber(ctx, r.Client, &ghIssue)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if message != "" {
+			log.Info(message)
+		}
+		milestoneWait, issueData.Milestone = message, number
+	}
+	blockers, err := r.resolveBlockers(ctx, &ghIssue)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	issueData.Description = withBlockedBy(issueData.Description, blockers)
+	children, err := r.children(ctx, &ghIssue)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	issueData.Description = withTaskList(issueData.Description, children)
+	// Move the real issue to the new repo before looking for it there
+	if repoChanged(&ghIssue) && ghIssue.DeletionTimestamp.IsZero() && !r.isDryRun(&ghIssue) {
+		if returnErr := r.rehome(ctx, &ghIssue, detailsData); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+		}
+	}
+	issue, returnErr := FindRealIssue(r.ClientFrame, &ghIssue, repoData, issueData, detailsData)
+	projectItemID := ""
 
 	if returnErr.ErrorCode != nil {
 		log.Info(returnErr.Message)
-		return ctrl.Result{}, returnErr.ErrorCode
+		return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+	} else if r.isDryRun(&ghIssue) {
+		// Only plan the changes, without mutating the real issue
+		return r.reconcileDryRun(ctx, &ghIssue, issueData, issue)
 	} else {
+		// Post the comment of a previous edit first, the edit itself went through
+		if issue != nil {
+			if returnErr = r.postPendingEditComment(ctx, &ghIssue, issue, detailsData); returnErr.ErrorCode != nil {
+				log.Info(returnErr.Message)
+				return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+			}
+		}
 		// Create new issue or update if needed
-		if issue == nil {
-			issue, returnErr = r.ClientFrame.CreateIssue(issueData, detailsData)
+		plan := ComputePlan(&ghIssue, issueData, issue)
+		// Closing a deleted object's issue is left to the deletion behavior
+		if plan.Action == PlanCreate || plan.Action == PlanEdit || (plan.Action == PlanClose && ghIssue.DeletionTimestamp.IsZero()) {
+			var before clients.Issue
+			if issue != nil {
+				before = *issue
+			}
+			issue, returnErr = ApplyPlan(r.ClientFrame, plan, issueData, issue, detailsData)
 			if returnErr.ErrorCode != nil {
 				log.Info(returnErr.Message)
-				log.Info("tried to create issue but got an error")
-				return ctrl.Result{}, returnErr.ErrorCode
+				log.Info("tried to " + plan.Action + " issue but got an error")
+				return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
 			}
-		} else {
-			if (issueData.Description != issue.Description) && (issue.State != "closed") {
-				returnErr = r.ClientFrame.EditIssue(issueData, issue, detailsData)
-				if returnErr.ErrorCode != nil {
+			if plan.Action == PlanEdit {
+				if returnErr = r.commentOnEdit(ctx, &ghIssue, &before, issue, detailsData); returnErr.ErrorCode != nil {
 					log.Info(returnErr.Message)
-					return ctrl.Result{}, returnErr.ErrorCode
+					return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
 				}
 			}
+			if plan.Action == PlanClose && isExpired(&ghIssue, time.Now()) && r.Recorder != nil {
+				r.Recorder.Event(&ghIssue, "Normal", "Expired", "Closed the issue at its expiry")
+			}
+		}
+		// Close the issue left in the previous repo, once its replacement exists
+		if repoChanged(&ghIssue) && issue != nil && ghIssue.DeletionTimestamp.IsZero() && ghIssue.Spec.RepoChangePolicy != RepoChangeTransfer {
+			if returnErr = r.closeMovedIssue(&ghIssue, issue, detailsData); returnErr.ErrorCode != nil {
+				log.Info(returnErr.Message)
+				return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+			}
+		}
+		// Keep the issue closed as not planned while it's blocked
+		if hold := holdPlan(&ghIssue, blockers, issue); hold != nil && ghIssue.DeletionTimestamp.IsZero() {
+			held := *issueData
+			if hold.Action == PlanClose {
+				held.StateReason = "not_planned"
+			}
+			issue, returnErr = ApplyPlan(r.ClientFrame, hold, &held, issue, detailsData)
+			if returnErr.ErrorCode != nil {
+				log.Info(returnErr.Message)
+				return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+			}
 		}
 		// Deletion behavior
 		stopReconcile, delErr := r.DeletionBehavior(&ghIssue, ctx, issueData, issue, detailsData)
 		if stopReconcile == true {
 			return ctrl.Result{}, delErr
 		}
+		// Add the issue to its project board
+		projectItemID, returnErr = r.syncProject(&ghIssue, issue, detailsData)
+		if returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghIssue, returnErr)
+		}
 	}
 
 	// Update the state of the issue instance by the real clients issue state
@@ -108,7 +230,15 @@ func (r *GitHubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if issue != nil {
 		ghIssue.Status.State = issue.State
 		ghIssue.Status.LastUpdateTimestamp = issue.LastUpdateTimestamp
+		ghIssue.Status.Number = issue.Number
+		ghIssue.Status.Repo = ghIssue.Spec.Repo
+		ghIssue.Status.Activity = activityOf(issue)
 	}
+	ghIssue.Status.Plan = nil
+	ghIssue.Status.ProjectItemID = projectItemID
+	setBlockedCondition(&ghIssue, blockers)
+	setCompletion(&ghIssue, children)
+	meta.SetStatusCondition(&ghIssue.Status.Conditions, syncedCondition(&ghIssue, milestoneWait))
 
 	err = r.Client.Status().Patch(ctx, &ghIssue, patch)
 
@@ -116,27 +246,92 @@ func (r *GitHubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Wake up for the expiry of the issue, or the deletion of the object once the issue is closed
+	requeueAfter, deleteNow := lifetimeRequeue(&ghIssue, issue, time.Now())
+	if deleteNow {
+		log.Info("deleting the object, its issue was closed for ttlSecondsAfterClosed")
+		if r.Recorder != nil {
+			r.Recorder.Event(&ghIssue, "Normal", "Expired", "The issue was closed for ttlSecondsAfterClosed, deleting")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(r.Client.Delete(ctx, &ghIssue))
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *GitHubIssueReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Dependent issues and parents are reconciled when a blocking issue or a child changes
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubIssue{}, blockedByIndex, indexBlockedBy); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubIssue{}, parentRefIndex, indexParentRef); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubIssue{}, repositoryRefIndex, indexRepositoryRef); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubIssue{}, milestoneRefIndex, indexMilestoneRef); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&examplev1alpha1.GitHubIssue{}).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubIssue{}}, handler.EnqueueRequestsFromMapFunc(r.relatedIssues)).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubRepository{}}, handler.EnqueueRequestsFromMapFunc(objectsOfRepository(r.Client, r.Log, &examplev1alpha1.GitHubIssueList{}))).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubMilestone{}}, handler.EnqueueRequestsFromMapFunc(r.issuesOfMilestone)).
 		Complete(r)
 }
 
+// syncFailed reports the GitHub error in the Synced condition, and returns it for a retry
+func (r *GitHubIssueReconciler) syncFailed(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, returnErr *clients.Error) error {
+	message := returnErr.Message
+	if len(message) > maxConditionMessage {
+		message = message[:maxConditionMessage]
+	}
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	meta.SetStatusCondition(&ghIssue.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             "GitHubError",
+		Message:            message,
+		ObservedGeneration: ghIssue.Generation,
+	})
+	if err := r.Client.Status().Patch(ctx, ghIssue, patch); err != nil {
+		r.Log.Info("failed to report the sync error in the status", "error", err.Error())
+	}
+	return returnErr.ErrorCode
+}
+
+// reconcileDryRun records the planned changes in the status and as an event, without calling
+// CreateIssue, EditIssue or CloseIssue
+func (r *GitHubIssueReconciler) reconcileDryRun(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, issueData *clients.Issue, issue *clients.Issue) (ctrl.Result, error) {
+	plan := ComputePlan(ghIssue, issueData, issue)
+	r.Log.Info(planMessage(plan), "name-of-gh-issue", ghIssue.Name)
+	if r.Recorder != nil {
+		r.Recorder.Event(ghIssue, "Normal", "DryRun", planMessage(plan))
+	}
+
+	if !ghIssue.ObjectMeta.DeletionTimestamp.IsZero() {
+		// The real issue is left open, so just let the object go
+		if containsString(ghIssue.GetFinalizers(), finalizerName) {
+			return ctrl.Result{}, r.updateFinalizers(ctx, ghIssue, controllerutil.RemoveFinalizer)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	ghIssue.Status.Plan = plan
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, ghIssue, patch)
+}
+
 // DeletionBehavior implement recommended deletion behavior, and will return true if we need to stop reconcilation
 func (r *GitHubIssueReconciler) DeletionBehavior(ghIssue *examplev1alpha1.GitHubIssue, ctx context.Context, issueData *clients.Issue, issue *clients.Issue, detailsData *clients.Details) (bool, error) {
-	finalizerName := "example.training.redhat.com/finalizer"
 	// examine DeletionTimestamp to determine if object is under deletion
 	if ghIssue.ObjectMeta.DeletionTimestamp.IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
 		// then lets add the finalizer and update the object. This is equivalent
 		// registering our finalizer.
 		if !containsString(ghIssue.GetFinalizers(), finalizerName) {
-			controllerutil.AddFinalizer(ghIssue, finalizerName)
-			if err := r.Update(ctx, ghIssue); err != nil {
+			if err := r.updateFinalizers(ctx, ghIssue, controllerutil.AddFinalizer); err != nil {
 				return true, err
 			}
 		}
@@ -153,8 +348,7 @@ func (r *GitHubIssueReconciler) DeletionBehavior(ghIssue *examplev1alpha1.GitHub
 			}
 
 			// remove our finalizer from the list and update it.
-			controllerutil.RemoveFinalizer(ghIssue, finalizerName)
-			if err := r.Update(ctx, ghIssue); err != nil {
+			if err := r.updateFinalizers(ctx, ghIssue, controllerutil.RemoveFinalizer); err != nil {
 				return true, err
 			}
 		}
@@ -165,11 +359,22 @@ func (r *GitHubIssueReconciler) DeletionBehavior(ghIssue *examplev1alpha1.GitHub
 	return false, nil
 }
 
+// updateFinalizers adds or removes the finalizer, patching nothing else as the spec may hold the defaults of a
+// GitHubRepository
+func (r *GitHubIssueReconciler) updateFinalizers(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, update func(controllerutil.Object, string)) error {
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	update(ghIssue, finalizerName)
+	return r.Patch(ctx, ghIssue, patch)
+}
+
 // Functions to handle deletion with finalizer
 func (r *GitHubIssueReconciler) deleteExternalResources(issueData *clients.Issue, issue *clients.Issue, detailsData *clients.Details) error {
 	return r.ClientFrame.CloseIssue(issueData, issue, detailsData).ErrorCode
 }
 
+// finalizerName makes the deletion of a GitHubIssue wait until the real issue is closed
+const finalizerName = "example.training.redhat.com/finalizer"
+
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
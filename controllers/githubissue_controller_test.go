@@ -5,12 +5,19 @@ import (
 	"fmt"
 	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
 	"github.com/arielireni/example-operator/controllers/clients"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Create issue tests
@@ -30,11 +37,14 @@ func TestSuccessfulCreate(t *testing.T) {
 	}
 
 	// When creating a real issue
-	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+	_, err := r.Reconcile(context.Background(), testRequest)
 
 	// Then reconcile returns ctrl.Result{} and no error
 	if err != nil {
-		t.Errorf("Expected nil but got error")
+		t.Errorf("Expected nil but got error: %v", err)
+	}
+	if issues := fakeClient.Issues(); len(issues) != 1 || issues[0].Number != 1 || issues[0].State != "open" {
+		t.Errorf("Expected a single open issue but got %v", issues)
 	}
 }
 
@@ -52,7 +62,7 @@ func TestFailedCreate(t *testing.T) {
 		Scheme:      s,
 		ClientFrame: fakeClient,
 	}
-	_, err := r.Reconcile(context.Background(), ctrl.Request{})
+	_, err := r.Reconcile(context.Background(), testRequest)
 	// Then reconcile returns ctrl.Result{} and error
 	if err == nil {
 		t.Errorf("Expected error but got nil")
@@ -60,31 +70,854 @@ func TestFailedCreate(t *testing.T) {
 }
 
 func newFakeK8sClient() client.Client {
-	issue := examplev1alpha1.GitHubIssue{
-		Spec: examplev1alpha1.GitHubIssueSpec{
-			Repo:  "arielireni/Issues-Example",
-			Title: "title1",
-		},
-	}
+	issue := newTestGitHubIssue("")
 	objects := []runtime.Object{issue.DeepCopyObject()}
 	fakeK8sClient := fake.NewClientBuilder().WithRuntimeObjects(objects...).Build()
 	return fakeK8sClient
 }
 
+// newTestGitHubIssue returns a named ghIssue for title1 with the given description
+func newTestGitHubIssue(description string) *examplev1alpha1.GitHubIssue {
+	return &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "issue1",
+			Namespace: "default",
+		},
+		Spec: examplev1alpha1.GitHubIssueSpec{
+			Repo:        "arielireni/Issues-Example",
+			Title:       "title1",
+			Description: description,
+		},
+	}
+}
+
+// newTestReconciler returns a reconciler working with fakeClient and a fake k8s client holding objects
+func newTestReconciler(fakeClient clients.ClientFrame, objects ...runtime.Object) GitHubIssueReconciler {
+	s := scheme.Scheme
+	examplev1alpha1.AddToScheme(s)
+	return GitHubIssueReconciler{
+		Client:      fake.NewClientBuilder().WithRuntimeObjects(objects...).Build(),
+		Log:         ctrl.Log,
+		Scheme:      s,
+		ClientFrame: fakeClient,
+	}
+}
+
+var testRequest = ctrl.Request{NamespacedName: types.NamespacedName{Name: "issue1", Namespace: "default"}}
+
 // Edit issue tests
 func TestSuccessfulEdit(t *testing.T) {
-	t.Skip("unimplemented")
+	// Given a real issue whose description differs from the ghIssue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old"}}, true, nil)
+	r := newTestReconciler(fakeClient, newTestGitHubIssue("new"))
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the real issue gets the new description
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if fakeClient.CallsTo("EditIssue") != 1 {
+		t.Errorf("Expected a single edit but got calls %v", fakeClient.Calls())
+	}
+	if issues := fakeClient.Issues(); issues[0].Description != "new" {
+		t.Errorf("Expected description %q but got %q", "new", issues[0].Description)
+	}
 }
 
 func TestFailedEdit(t *testing.T) {
-	t.Skip("unimplemented")
+	// Given we fail to edit the real issue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old"}}, true, nil)
+	fakeClient.FailOn("EditIssue", fmt.Errorf("TestFailedEdit error"))
+	r := newTestReconciler(fakeClient, newTestGitHubIssue("new"))
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then reconcile returns error and the real issue is unchanged
+	if err == nil {
+		t.Errorf("Expected error but got nil")
+	}
+	if issues := fakeClient.Issues(); issues[0].Description != "old" {
+		t.Errorf("Expected description %q but got %q", "old", issues[0].Description)
+	}
+}
+
+func TestEditLabelsAndAssignees(t *testing.T) {
+	// Given a real issue whose labels and assignees differ from the ghIssue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body", Labels: []string{"bug"}}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.Labels = []string{"bug", "urgent"}
+	ghIssue.Spec.Assignees = []string{"octocat"}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the real issue gets them, without a comment as commentOnEdit isn't set
+	issue := fakeClient.Issues()[0]
+	if !reflect.DeepEqual(issue.Labels, []string{"bug", "urgent"}) || !reflect.DeepEqual(issue.Assignees, []string{"octocat"}) {
+		t.Errorf("Expected the labels and assignees of the spec but got %v, %v", issue.Labels, issue.Assignees)
+	}
+	if len(fakeClient.Comments()) != 0 {
+		t.Errorf("Expected no comment but got %+v", fakeClient.Comments())
+	}
+}
+
+func TestCommentOnEdit(t *testing.T) {
+	// Given a ghIssue commenting on its edits, whose description and labels differ from the real issue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old", Labels: []string{"bug", "wontfix"}}}, true, nil)
+	ghIssue := newTestGitHubIssue("new")
+	ghIssue.Spec.Labels = []string{"bug", "urgent"}
+	ghIssue.Spec.CommentOnEdit = true
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the edit is summarized in a comment on the real issue
+	comments := fakeClient.Comments()
+	if len(comments) != 1 {
+		t.Fatalf("Expected a single comment but got %+v", comments)
+	}
+	for _, expected := range []string{"-old\n+new\n", "Added labels: urgent", "Removed labels: wontfix"} {
+		if !strings.Contains(comments[0].Body, expected) {
+			t.Errorf("Expected %q in the comment but got %q", expected, comments[0].Body)
+		}
+	}
+}
+
+func TestCommentOnEditRetried(t *testing.T) {
+	// Given a ghIssue commenting on its edits, whose comment fails once the edit went through
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old"}}, true, nil)
+	fakeClient.FailOn("CreateComment", fmt.Errorf("comment failed"))
+	ghIssue := newTestGitHubIssue("new")
+	ghIssue.Spec.CommentOnEdit = true
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling, then the comment is recorded in the status
+	if _, err := r.Reconcile(context.Background(), testRequest); err == nil {
+		t.Fatalf("Expected the comment error")
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if !strings.Contains(got.Status.PendingEditComment, "-old\n+new\n") {
+		t.Fatalf("Expected the pending comment in the status but got %q", got.Status.PendingEditComment)
+	}
+
+	// When reconciling again with nothing left to edit, then the comment is posted once and cleared
+	fakeClient.FailOn("CreateComment", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+			t.Fatalf("Expected nil but got error: %v", err)
+		}
+	}
+	if comments := fakeClient.Comments(); len(comments) != 1 || !strings.Contains(comments[0].Body, "-old\n+new\n") {
+		t.Errorf("Expected the edit comment but got %+v", comments)
+	}
+	cleared := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &cleared)
+	if cleared.Status.PendingEditComment != "" {
+		t.Errorf("Expected no pending comment but got %q", cleared.Status.PendingEditComment)
+	}
+}
+
+func TestCommentOnEditTemplate(t *testing.T) {
+	// Given a configured edit comment template
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old"}}, true, nil)
+	ghIssue := newTestGitHubIssue("new")
+	ghIssue.Spec.CommentOnEdit = true
+	r := newTestReconciler(fakeClient, ghIssue)
+	tmpl, err := ParseEditCommentTemplate("{{.Title}} changed:\n{{.Diff}}")
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	r.EditCommentTemplate = tmpl
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the comment is rendered with it
+	comments := fakeClient.Comments()
+	if len(comments) != 1 || !strings.HasPrefix(comments[0].Body, "title1 changed:\n--- before\n+++ after\n") {
+		t.Errorf("Expected a comment rendered by the template but got %+v", comments)
+	}
+}
+
+// Repo change tests
+// newMovedGitHubIssue returns a ghIssue whose real issue #1 is in another repo than its spec, per the status
+func newMovedGitHubIssue(fakeClient *clients.FakeClient, policy string) *examplev1alpha1.GitHubIssue {
+	_, issueData, detailsData := fakeClient.InitDataStructs("arielireni/Old-Issues", "title1", "body")
+	fakeClient.CreateIssue(issueData, detailsData)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.RepoChangePolicy = policy
+	ghIssue.Status = examplev1alpha1.GitHubIssueStatus{Repo: "arielireni/Old-Issues", Number: 1, State: "open"}
+	return ghIssue
+}
+
+func TestRepoChangeRecreate(t *testing.T) {
+	// Given a ghIssue moved to another repo with the Recreate policy
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r := newTestReconciler(fakeClient, newMovedGitHubIssue(fakeClient, RepoChangeRecreate))
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then a new issue is created, and the old one is closed with a link to it
+	issues := fakeClient.Issues()
+	if len(issues) != 2 || issues[0].State != "closed" || issues[0].StateReason != "not_planned" || issues[1].State != "open" {
+		t.Fatalf("Expected the old issue closed and a new one but got %+v", issues)
+	}
+	comments := fakeClient.Comments()
+	if len(comments) != 1 || comments[0].Number != 1 || comments[0].Body != "Moved to arielireni/Issues-Example#2." {
+		t.Errorf("Expected a link to the new issue on the old one but got %+v", comments)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.Repo != "arielireni/Issues-Example" || got.Status.Number != 2 {
+		t.Errorf("Expected the new issue in the status but got %s#%d", got.Status.Repo, got.Status.Number)
+	}
+}
+
+func TestRepoChangeRecreateRetried(t *testing.T) {
+	// Given a ghIssue moved with the Recreate policy whose old issue fails to be closed
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	fakeClient.FailOn("CloseIssue", fmt.Errorf("close failed"))
+	r := newTestReconciler(fakeClient, newMovedGitHubIssue(fakeClient, RepoChangeRecreate))
+
+	// When reconciling, then nothing is posted on the old issue
+	if _, err := r.Reconcile(context.Background(), testRequest); err == nil {
+		t.Fatalf("Expected the close error")
+	}
+	if comments := fakeClient.Comments(); len(comments) != 0 {
+		t.Fatalf("Expected no comment before the close but got %+v", comments)
+	}
+
+	// When reconciling again, then the link is posted once
+	fakeClient.FailOn("CloseIssue", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+			t.Fatalf("Expected nil but got error: %v", err)
+		}
+	}
+	if comments := fakeClient.Comments(); len(comments) != 1 || comments[0].Body != "Moved to arielireni/Issues-Example#2." {
+		t.Errorf("Expected a single link to the new issue but got %+v", comments)
+	}
+}
+
+func TestRepoChangeTransfer(t *testing.T) {
+	// Given a ghIssue moved to another repo with the Transfer policy
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r := newTestReconciler(fakeClient, newMovedGitHubIssue(fakeClient, RepoChangeTransfer))
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the issue is transferred rather than recreated
+	if fakeClient.CallsTo("TransferIssue") != 1 || fakeClient.CallsTo("CreateIssue") != 1 {
+		t.Errorf("Expected a single transfer but got calls %v", fakeClient.Calls())
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if issues := fakeClient.Issues(); len(issues) != 1 || got.Status.Repo != "arielireni/Issues-Example" || got.Status.Number != issues[0].Number {
+		t.Errorf("Expected the transferred issue in the status but got %s#%d", got.Status.Repo, got.Status.Number)
+	}
 }
 
 // Close issue tests
 func TestSuccessfulClose(t *testing.T) {
-	t.Skip("unimplemented")
+	// Given a ghIssue being deleted while its real issue is open
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	now := metav1.Now()
+	ghIssue.DeletionTimestamp = &now
+	ghIssue.Finalizers = []string{finalizerName}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the real issue is closed and the finalizer is removed
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if issues := fakeClient.Issues(); issues[0].State != "closed" {
+		t.Errorf("Expected state closed but got %q", issues[0].State)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	if err := r.Client.Get(context.Background(), testRequest.NamespacedName, &got); err == nil && len(got.Finalizers) != 0 {
+		t.Errorf("Expected no finalizers but got %v", got.Finalizers)
+	}
 }
 
 func TestFailedClose(t *testing.T) {
-	t.Skip("unimplemented")
+	// Given we fail to close the real issue of a ghIssue being deleted
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	fakeClient.FailOn("CloseIssue", fmt.Errorf("TestFailedClose error"))
+	ghIssue := newTestGitHubIssue("body")
+	now := metav1.Now()
+	ghIssue.DeletionTimestamp = &now
+	ghIssue.Finalizers = []string{finalizerName}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then reconcile returns error and the finalizer is kept for a retry
+	if err == nil {
+		t.Errorf("Expected error but got nil")
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	if err := r.Client.Get(context.Background(), testRequest.NamespacedName, &got); err != nil || len(got.Finalizers) != 1 {
+		t.Errorf("Expected the finalizer to be kept but got %v, %v", got.Finalizers, err)
+	}
+}
+
+func TestCloseBySpecState(t *testing.T) {
+	// Given a ghIssue whose desired state is closed while its real issue is open
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.State = "closed"
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the real issue is closed and the status reports it
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if issues := fakeClient.Issues(); issues[0].State != "closed" {
+		t.Errorf("Expected state closed but got %q", issues[0].State)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.State != "closed" {
+		t.Errorf("Expected status state closed but got %q", got.Status.State)
+	}
+}
+
+// Expiry tests
+func TestExpiredIssueIsClosed(t *testing.T) {
+	// Given a ghIssue that expired while its real issue is open
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.ExpiresAt = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the real issue is closed
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if issues := fakeClient.Issues(); issues[0].State != "closed" {
+		t.Errorf("Expected state closed but got %q", issues[0].State)
+	}
+}
+
+func TestExpiryRequeue(t *testing.T) {
+	// Given a ghIssue expiring an hour after its creation
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.CreationTimestamp = metav1.Now()
+	ghIssue.Spec.TTLAfterCreation = &metav1.Duration{Duration: time.Hour}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	result, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the issue is left open, and the reconciler wakes up at the expiry
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if fakeClient.CallsTo("CloseIssue") != 0 {
+		t.Errorf("Expected no close but got calls %v", fakeClient.Calls())
+	}
+	if result.RequeueAfter <= 59*time.Minute || result.RequeueAfter > time.Hour {
+		t.Errorf("Expected to requeue in an hour but got %v", result.RequeueAfter)
+	}
+}
+
+func TestDeleteAfterClosed(t *testing.T) {
+	// Given a ghIssue whose real issue was closed longer than ttlSecondsAfterClosed ago
+	closedAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body", State: "closed", ClosedAt: closedAt}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ttl := int64(60)
+	ghIssue.Spec.TTLSecondsAfterClosed = &ttl
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the object is deleted, its finalizer lets it go as the issue is already closed
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	err = r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if err == nil && got.DeletionTimestamp.IsZero() {
+		t.Errorf("Expected the object to be deleted")
+	}
+}
+
+// Dependency tests
+func newTestBlocker(state string) *examplev1alpha1.GitHubIssue {
+	blocker := &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{Name: "blocker", Namespace: "default"},
+		Spec:       examplev1alpha1.GitHubIssueSpec{Repo: "arielireni/Issues-Example", Title: "blocker"},
+	}
+	blocker.Status.Number = 5
+	blocker.Status.State = state
+	return blocker
+}
+
+func TestBlockedIssueIsHeldClosed(t *testing.T) {
+	// Given a ghIssue blocked by an open issue, to be kept closed while blocked
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.BlockedBy = []examplev1alpha1.IssueReference{{Name: "blocker"}}
+	ghIssue.Spec.CloseWhileBlocked = true
+	r := newTestReconciler(fakeClient, ghIssue, newTestBlocker("open"))
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the body links the blocker, and the issue is closed as not planned
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	issue := fakeClient.Issues()[0]
+	if !strings.Contains(issue.Description, "- arielireni/Issues-Example#5") {
+		t.Errorf("Expected the body to link the blocker but got %q", issue.Description)
+	}
+	if issue.State != "closed" || issue.StateReason != "not_planned" {
+		t.Errorf("Expected closed as not planned but got %q (%q)", issue.State, issue.StateReason)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionBlocked) {
+		t.Errorf("Expected Blocked to be true but got %v", got.Status.Conditions)
+	}
+}
+
+func TestUnblockedIssueIsReopened(t *testing.T) {
+	// Given a ghIssue held closed while its blocker was open, and the blocker is now closed
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "body", State: "closed", StateReason: "not_planned"}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.BlockedBy = []examplev1alpha1.IssueReference{{Name: "blocker"}}
+	ghIssue.Spec.CloseWhileBlocked = true
+	r := newTestReconciler(fakeClient, ghIssue, newTestBlocker("closed"))
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the issue is reopened and no longer blocked
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if issue := fakeClient.Issues()[0]; issue.State != "open" {
+		t.Errorf("Expected state open but got %q", issue.State)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionBlocked); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("Expected Blocked to be false but got %v", condition)
+	}
+}
+
+// Parent tests
+func TestParentTaskList(t *testing.T) {
+	// Given a parent ghIssue with an open and a closed child
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "epic"}}, true, nil)
+	parent := newTestGitHubIssue("epic")
+	children := []*examplev1alpha1.GitHubIssue{}
+	for i, state := range []string{"open", "closed"} {
+		child := &examplev1alpha1.GitHubIssue{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("child%d", i), Namespace: "default"},
+			Spec: examplev1alpha1.GitHubIssueSpec{
+				Repo:      "arielireni/Issues-Example",
+				Title:     fmt.Sprintf("child %d", i),
+				ParentRef: &examplev1alpha1.IssueReference{Name: "issue1"},
+			},
+		}
+		child.Status.Number = 10 + i
+		child.Status.State = state
+		children = append(children, child)
+	}
+	r := newTestReconciler(fakeClient, parent, children[0], children[1])
+
+	// When reconciling the parent
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then its body ticks the closed child, and its status reports half of them completed
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	body := fakeClient.Issues()[0].Description
+	if !strings.Contains(body, "- [ ] arielireni/Issues-Example#10") || !strings.Contains(body, "- [x] arielireni/Issues-Example#11") {
+		t.Errorf("Expected a task list of the children but got %q", body)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.Children != 2 || got.Status.Completion == nil || *got.Status.Completion != 50 {
+		t.Errorf("Expected 2 children 50%% completed but got %d, %v", got.Status.Children, got.Status.Completion)
+	}
+}
+
+// Project tests
+func TestProjectItem(t *testing.T) {
+	// Given a ghIssue on a project board
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.Project = &examplev1alpha1.ProjectSpec{ID: "PVT_1", Status: "Todo", Fields: map[string]string{"Team": "platform"}}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling it
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then the created issue is added to the project with its values, and the item is reported in the status
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	items := fakeClient.ProjectItems()
+	if len(items) != 1 || items[0].ProjectID != "PVT_1" || items[0].Project.Status != "Todo" || items[0].Project.Fields["Team"] != "platform" {
+		t.Fatalf("Expected the issue on the project but got %+v", items)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.ProjectItemID != items[0].ID {
+		t.Errorf("Expected the project item %s in the status but got %q", items[0].ID, got.Status.ProjectItemID)
+	}
+}
+
+func TestProjectItemError(t *testing.T) {
+	// Given a ghIssue whose project can't be updated
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	fakeClient.FailOn("SyncProjectItem", fmt.Errorf("project not found"))
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.Project = &examplev1alpha1.ProjectSpec{Owner: "arielireni", Number: 3}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling it, then the error is returned and reported in the Synced condition
+	if _, err := r.Reconcile(context.Background(), testRequest); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	synced := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced)
+	if synced == nil || synced.Status != metav1.ConditionFalse {
+		t.Errorf("Expected a false Synced condition but got %+v", synced)
+	}
+}
+
+// Activity tests
+func TestActivityStatus(t *testing.T) {
+	// Given a closed real issue with comments and reactions
+	fakeClient := clients.NewFakeClient([]clients.Issue{{
+		Title:           "title1",
+		Description:     "body",
+		State:           "closed",
+		ClosedAt:        "2021-06-02T10:00:00Z",
+		ClosedBy:        "hubot",
+		Author:          "octocat",
+		CreatedAt:       "2021-06-01T10:00:00Z",
+		Comments:        4,
+		LastCommenter:   "monalisa",
+		LastCommentedAt: "2021-06-02T09:00:00Z",
+		Reactions:       &clients.Reactions{TotalCount: 2, PlusOne: 1, Eyes: 1},
+	}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Spec.State = "closed"
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling it
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the conversation is summarized in the status
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	want := examplev1alpha1.IssueActivity{
+		Author:          "octocat",
+		CreatedAt:       "2021-06-01T10:00:00Z",
+		ClosedAt:        "2021-06-02T10:00:00Z",
+		ClosedBy:        "hubot",
+		Comments:        4,
+		LastCommenter:   "monalisa",
+		LastCommentedAt: "2021-06-02T09:00:00Z",
+		Reactions:       &examplev1alpha1.ReactionCounts{Total: 2, ThumbsUp: 1, Eyes: 1},
+	}
+	if got.Status.Activity == nil || !reflect.DeepEqual(*got.Status.Activity, want) {
+		t.Errorf("Expected the activity %+v but got %+v", want, got.Status.Activity)
+	}
+}
+
+// Adopt issue tests
+func TestAdoptIssue(t *testing.T) {
+	// Given a ghIssue adopting an existing issue with another title
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "other title", Description: "body", Number: 7}}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Annotations = map[string]string{AdoptAnnotation: "7"}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then no issue is created and the status reports the adopted issue
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if fakeClient.CallsTo("CreateIssue") != 0 {
+		t.Errorf("Expected no issue to be created but got calls %v", fakeClient.Calls())
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.Number != 7 || !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionSynced) {
+		t.Errorf("Expected synced issue 7 but got %+v", got.Status)
+	}
+}
+
+func TestAdoptMissingIssue(t *testing.T) {
+	// Given a ghIssue adopting an issue that does not exist
+	fakeClient := clients.NewFakeClient([]clients.Issue{}, true, nil)
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.Annotations = map[string]string{AdoptAnnotation: "7"}
+	r := newTestReconciler(fakeClient, ghIssue)
+
+	// When reconciling
+	_, err := r.Reconcile(context.Background(), testRequest)
+
+	// Then reconcile returns error, which is reported in the Synced condition
+	if err == nil {
+		t.Errorf("Expected error but got nil")
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("Expected Synced to be false but got %+v", condition)
+	}
+}
+
+// Dry-run tests
+func TestDryRunEdit(t *testing.T) {
+	// Given a ghIssue marked for dry-run whose description differs from the real issue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Description: "old"}}, true, nil)
+	s := scheme.Scheme
+	examplev1alpha1.AddToScheme(s)
+	ghIssue := examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dry-run",
+			Namespace:   "default",
+			Annotations: map[string]string{dryRunAnnotation: "true"},
+		},
+		Spec: examplev1alpha1.GitHubIssueSpec{
+			Repo:        "arielireni/Issues-Example",
+			Title:       "title1",
+			Description: "new",
+		},
+	}
+	fakeK8sClient := fake.NewClientBuilder().WithRuntimeObjects(ghIssue.DeepCopyObject()).Build()
+	r := GitHubIssueReconciler{
+		Client:      fakeK8sClient,
+		Log:         ctrl.Log,
+		Scheme:      s,
+		ClientFrame: fakeClient,
+	}
+
+	// When reconciling
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "dry-run", Namespace: "default"}}
+	_, err := r.Reconcile(context.Background(), req)
+
+	// Then the plan is an edit of the body, and the object is left without a finalizer
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	if err := fakeK8sClient.Get(context.Background(), req.NamespacedName, &got); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if got.Status.Plan == nil || got.Status.Plan.Action != PlanEdit {
+		t.Errorf("Expected an edit plan but got %v", got.Status.Plan)
+	}
+	if len(got.Finalizers) != 0 {
+		t.Errorf("Expected no finalizers but got %v", got.Finalizers)
+	}
+}
+
+func TestDryRunPlan(t *testing.T) {
+	ghIssue := &examplev1alpha1.GitHubIssue{}
+	issueData := &clients.Issue{Title: "title1", Description: "body"}
+
+	// A missing issue is planned to be created
+	if plan := ComputePlan(ghIssue, issueData, nil); plan.Action != PlanCreate {
+		t.Errorf("Expected %s but got %s", PlanCreate, plan.Action)
+	}
+	// An identical issue needs no changes
+	if plan := ComputePlan(ghIssue, issueData, &clients.Issue{Title: "title1", Description: "body", State: "open"}); plan.Action != PlanNoop {
+		t.Errorf("Expected %s but got %s", PlanNoop, plan.Action)
+	}
+	// A deleted object closes the open issue
+	now := metav1.Now()
+	ghIssue.DeletionTimestamp = &now
+	if plan := ComputePlan(ghIssue, issueData, &clients.Issue{Title: "title1", State: "open"}); plan.Action != PlanClose {
+		t.Errorf("Expected %s but got %s", PlanClose, plan.Action)
+	}
+}
+
+// Credentials tests
+func TestCredentialsRef(t *testing.T) {
+	// Given a ghIssue whose credentials are in a Secret of its namespace
+	ghIssue := newTestGitHubIssue("description")
+	ghIssue.Spec.CredentialsRef = &examplev1alpha1.CredentialsReference{Name: "team-token", Key: "token"}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "team-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("secret")}}
+	r := newTestReconciler(clients.NewFakeClient(nil, true, nil), ghIssue, secret)
+
+	// Then its requests are made with the token of the Secret
+	_, _, detailsData := r.ClientFrame.InitDataStructs(ghIssue.Spec.Repo, ghIssue.Spec.Title, "")
+	if returnErr := WithCredentials(context.Background(), r.Client, ghIssue, nil, detailsData); returnErr.ErrorCode != nil || detailsData.Token != "secret" {
+		t.Errorf("Expected the token of the secret but got %q, %v", detailsData.Token, returnErr.ErrorCode)
+	}
+
+	// And the Secret is read through the API reader when the reconciler has one
+	r.APIReader = fake.NewClientBuilder().WithRuntimeObjects(&corev1.Secret{ObjectMeta: secret.ObjectMeta, Data: map[string][]byte{"token": []byte("uncached")}}).Build()
+	if returnErr := WithCredentials(context.Background(), secretReader(r.APIReader, r.Client), ghIssue, nil, detailsData); returnErr.ErrorCode != nil || detailsData.Token != "uncached" {
+		t.Errorf("Expected the token read by the API reader but got %q, %v", detailsData.Token, returnErr.ErrorCode)
+	}
+
+	// And a missing key fails the sync without touching the real issue
+	ghIssue.Spec.CredentialsRef.Key = "pat"
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r = newTestReconciler(fakeClient, ghIssue, secret)
+	if _, err := r.Reconcile(context.Background(), testRequest); err == nil {
+		t.Errorf("Expected the missing key to fail the reconcile")
+	}
+	if len(fakeClient.Calls()) != 0 {
+		t.Errorf("Expected no calls but got %v", fakeClient.Calls())
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("Expected Synced to be false but got %v", got.Status.Conditions)
+	}
+}
+
+// Repository tests
+func TestIssueRepositoryRef(t *testing.T) {
+	// Given a ghIssue leaving its repo and labels to a GitHubRepository of another namespace
+	ghIssue := newTestGitHubIssue("description")
+	ghIssue.Spec.Repo = ""
+	ghIssue.Spec.RepositoryRef = &examplev1alpha1.RepositoryReference{Name: "issues", Namespace: "platform"}
+	ghRepo := newTestGitHubRepository()
+	ghRepo.Spec.CredentialsRef = &examplev1alpha1.CredentialsReference{Name: "platform-token", Key: "token"}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "platform-token", Namespace: "platform"}, Data: map[string][]byte{"token": []byte("secret")}}
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r := newTestReconciler(fakeClient, ghIssue, ghRepo, secret)
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the real issue is created in the repo with the default labels, which aren't written in the spec
+	issues := fakeClient.Issues()
+	if len(issues) != 1 || !reflect.DeepEqual(issues[0].Labels, []string{"triage"}) {
+		t.Fatalf("Expected an issue labeled triage but got %+v", issues)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if got.Status.Repo != "arielireni/Issues-Example" || got.Spec.Repo != "" || got.Spec.Labels != nil {
+		t.Errorf("Expected the repo of the repository in the status only but got %+v, %+v", got.Spec, got.Status)
+	}
+
+	// And the issue is managed with the token of the repository
+	WithRepository(context.Background(), r.Client, &got)
+	_, _, detailsData := r.ClientFrame.InitDataStructs(got.Spec.Repo, "", "")
+	if returnErr := WithCredentials(context.Background(), r.Client, &got, ghRepo, detailsData); returnErr.ErrorCode != nil || detailsData.Token != "secret" {
+		t.Errorf("Expected the token of the repository but got %q, %v", detailsData.Token, returnErr.ErrorCode)
+	}
+
+	// But an issue of the repository setting another repo isn't managed with the token of the repository
+	got.Spec.Repo = "someone/else"
+	_, _, detailsData = r.ClientFrame.InitDataStructs(got.Spec.Repo, "", "")
+	if returnErr := WithCredentials(context.Background(), r.Client, &got, ghRepo, detailsData); returnErr.ErrorCode != nil || detailsData.Token == "secret" {
+		t.Errorf("Expected the token of the operator but got %q, %v", detailsData.Token, returnErr.ErrorCode)
+	}
+}
+
+func TestIssueRepositoryNotAllowed(t *testing.T) {
+	// Given a GitHubRepository not shared with the namespace of the ghIssue
+	ghIssue := newTestGitHubIssue("description")
+	ghIssue.Spec.RepositoryRef = &examplev1alpha1.RepositoryReference{Name: "issues", Namespace: "platform"}
+	ghRepo := newTestGitHubRepository()
+	ghRepo.Spec.AllowedNamespaces = []string{"team-*"}
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r := newTestReconciler(fakeClient, ghIssue, ghRepo)
+
+	// Then the sync fails without touching GitHub
+	if _, err := r.Reconcile(context.Background(), testRequest); err == nil || !strings.Contains(err.Error(), "doesn't allow namespace default") {
+		t.Errorf("Expected the namespace to be refused but got %v", err)
+	}
+	if len(fakeClient.Calls()) != 0 {
+		t.Errorf("Expected no calls but got %v", fakeClient.Calls())
+	}
+}
+
+func TestIssueMilestoneRef(t *testing.T) {
+	// Given a ghIssue of a GitHubMilestone whose milestone isn't created yet
+	ghIssue := newTestGitHubIssue("description")
+	ghIssue.Spec.MilestoneRef = &corev1.LocalObjectReference{Name: "v1-0"}
+	ghMilestone := newTestGitHubMilestone()
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r := newTestReconciler(fakeClient, ghIssue, ghMilestone)
+
+	// When reconciling, then the real issue is created without a milestone while the milestone isn't ready
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "MilestoneNotReady" {
+		t.Fatalf("Expected the milestone to wait but got %+v", got.Status)
+	}
+	if issues := fakeClient.Issues(); len(issues) != 1 || issues[0].Milestone != 0 {
+		t.Fatalf("Expected an issue without a milestone but got %+v", issues)
+	}
+
+	// When the milestone is created
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	milestone, _ := fakeClient.CreateMilestone(&clients.Milestone{Title: "v1.0"}, detailsData)
+	r.Client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "v1-0"}, ghMilestone)
+	ghMilestone.Status.Repo, ghMilestone.Status.Number = "arielireni/Issues-Example", milestone.Number
+	if err := r.Client.Status().Update(context.Background(), ghMilestone); err != nil {
+		t.Fatal(err)
+	}
+
+	// Then the real issue is moved to the milestone, and synced
+	if _, err := r.Reconcile(context.Background(), testRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if issues := fakeClient.Issues(); len(issues) != 1 || issues[0].Milestone != milestone.Number {
+		t.Errorf("Expected an issue in milestone #%d but got %+v", milestone.Number, issues)
+	}
+	got = examplev1alpha1.GitHubIssue{}
+	r.Client.Get(context.Background(), testRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced); condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("Expected the issue to be synced but got %+v", got.Status)
+	}
 }
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"time"
+)
+
+var _ = Describe("GitHubIssue controller", func() {
+	const (
+		timeout  = 10 * time.Second
+		interval = 250 * time.Millisecond
+		repo     = "arielireni/Issues-Example"
+	)
+
+	// findFakeIssue returns the issue the fake GitHub client holds for title, nil if there is none
+	findFakeIssue := func(title string) *clients.Issue {
+		for _, issue := range fakeClient.Issues() {
+			if issue.Title == title {
+				return &issue
+			}
+		}
+		return nil
+	}
+
+	newGitHubIssue := func(name, title, description string) *examplev1alpha1.GitHubIssue {
+		return &examplev1alpha1.GitHubIssue{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: examplev1alpha1.GitHubIssueSpec{
+				Repo:        repo,
+				Title:       title,
+				Description: description,
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	It("creates the real issue and reports its state", func() {
+		Expect(k8sClient.Create(ctx, newGitHubIssue("create", "envtest create", "body"))).To(Succeed())
+
+		By("creating the issue on GitHub")
+		Eventually(func() *clients.Issue {
+			return findFakeIssue("envtest create")
+		}, timeout, interval).ShouldNot(BeNil())
+
+		By("adding the finalizer and updating the status")
+		Eventually(func() bool {
+			ghIssue := examplev1alpha1.GitHubIssue{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "create", Namespace: "default"}, &ghIssue); err != nil {
+				return false
+			}
+			return containsString(ghIssue.Finalizers, finalizerName) &&
+				ghIssue.Status.State == "open" && ghIssue.Status.LastUpdateTimestamp != ""
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("edits the real issue when the description changes", func() {
+		ghIssue := newGitHubIssue("edit", "envtest edit", "old")
+		Expect(k8sClient.Create(ctx, ghIssue)).To(Succeed())
+		Eventually(func() *clients.Issue {
+			return findFakeIssue("envtest edit")
+		}, timeout, interval).ShouldNot(BeNil())
+
+		By("changing the description")
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "edit", Namespace: "default"}, ghIssue); err != nil {
+				return err
+			}
+			ghIssue.Spec.Description = "new"
+			return k8sClient.Update(ctx, ghIssue)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(func() string {
+			if issue := findFakeIssue("envtest edit"); issue != nil {
+				return issue.Description
+			}
+			return ""
+		}, timeout, interval).Should(Equal("new"))
+	})
+
+	It("closes the real issue before the object is deleted", func() {
+		ghIssue := newGitHubIssue("close", "envtest close", "body")
+		Expect(k8sClient.Create(ctx, ghIssue)).To(Succeed())
+		Eventually(func() bool {
+			got := examplev1alpha1.GitHubIssue{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "close", Namespace: "default"}, &got)
+			return err == nil && containsString(got.Finalizers, finalizerName)
+		}, timeout, interval).Should(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, ghIssue)).To(Succeed())
+
+		By("closing the issue on GitHub")
+		Eventually(func() string {
+			if issue := findFakeIssue("envtest close"); issue != nil {
+				return issue.State
+			}
+			return ""
+		}, timeout, interval).Should(Equal("closed"))
+
+		By("removing the finalizer")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "close", Namespace: "default"}, &examplev1alpha1.GitHubIssue{})
+			return errors.IsNotFound(err)
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("resyncs the status with changes made on GitHub", func() {
+		Expect(k8sClient.Create(ctx, newGitHubIssue("resync", "envtest resync", "body"))).To(Succeed())
+		Eventually(func() *clients.Issue {
+			return findFakeIssue("envtest resync")
+		}, timeout, interval).ShouldNot(BeNil())
+
+		By("closing the issue on GitHub, behind the operator's back")
+		_, _, detailsData := fakeClient.InitDataStructs(repo, "envtest resync", "body")
+		issue := findFakeIssue("envtest resync")
+		Expect(fakeClient.CloseIssue(issue, issue, detailsData).ErrorCode).NotTo(HaveOccurred())
+
+		Eventually(func() string {
+			ghIssue := examplev1alpha1.GitHubIssue{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "resync", Namespace: "default"}, &ghIssue); err != nil {
+				return ""
+			}
+			return ghIssue.Status.State
+		}, timeout+envtestSyncPeriod, interval).Should(Equal("closed"))
+	})
+})
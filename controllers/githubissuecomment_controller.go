@@ -0,0 +1,267 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Deletion policies of a GitHubIssueComment
+const (
+	CommentDeletionDelete   = "Delete"
+	CommentDeletionMinimize = "Minimize"
+)
+
+// GitHubIssueCommentReconciler reconciles a GitHubIssueComment object
+type GitHubIssueCommentReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	ClientFrame clients.ClientFrame
+	// APIReader reads the credentials Secrets, the Client if nil
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuecomments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuecomments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuecomments/finalizers,verbs=update
+
+// Reconcile posts the comment on the real issue of the referenced GitHubIssue, edits it when the body changes,
+// and deletes or minimizes it when the object is deleted
+func (r *GitHubIssueCommentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-issue-comment", req.NamespacedName)
+
+	ghComment := examplev1alpha1.GitHubIssueComment{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &ghComment); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !ghComment.DeletionTimestamp.IsZero() {
+		if !containsString(ghComment.GetFinalizers(), finalizerName) {
+			return ctrl.Result{}, nil
+		}
+		ghIssue, ghRepo, err := r.issueOf(ctx, &ghComment)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// In dry-run mode the real comment is left on GitHub
+		if returnErr := r.removeComment(ctx, &ghComment, ghIssue, ghRepo); returnErr.ErrorCode != nil && !clients.IsDryRun(returnErr) {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghComment, "GitHubError", returnErr)
+		}
+		controllerutil.RemoveFinalizer(&ghComment, finalizerName)
+		return ctrl.Result{}, r.Update(ctx, &ghComment)
+	}
+
+	// The comment waits for the real issue of its GitHubIssue
+	ghIssue := examplev1alpha1.GitHubIssue{}
+	key := types.NamespacedName{Namespace: ghComment.Namespace, Name: ghComment.Spec.IssueRef.Name}
+	if err := r.Client.Get(ctx, key, &ghIssue); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.waitForIssue(ctx, &ghComment, "IssueNotFound", "The GitHubIssue "+key.Name+" doesn't exist")
+	}
+	if ghIssue.Status.Number == 0 {
+		return ctrl.Result{}, r.waitForIssue(ctx, &ghComment, "IssueNotCreated", "The real issue of "+key.Name+" isn't created yet")
+	}
+
+	// The comment goes away with its GitHubIssue, and its real comment with it
+	if !hasOwnerReference(&ghComment, &ghIssue) || !containsString(ghComment.GetFinalizers(), finalizerName) {
+		if err := controllerutil.SetOwnerReference(&ghIssue, &ghComment, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		controllerutil.AddFinalizer(&ghComment, finalizerName)
+		if err := r.Update(ctx, &ghComment); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	ghRepo, returnErr := WithRepository(ctx, r.Client, &ghIssue)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghComment, "RepositoryError", returnErr)
+	}
+
+	patch := client.MergeFrom(ghComment.DeepCopy())
+	// A comment on another issue is removed, and posted again on the referenced one
+	if ghComment.Status.CommentID != 0 && (ghComment.Status.Repo != ghIssue.Spec.Repo || ghComment.Status.IssueNumber != ghIssue.Status.Number) {
+		// In dry-run mode the real comment is left on GitHub
+		if returnErr := r.removeComment(ctx, &ghComment, &ghIssue, ghRepo); returnErr.ErrorCode != nil && !clients.IsDryRun(returnErr) {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghComment, "GitHubError", returnErr)
+		}
+		ghComment.Status.CommentID, ghComment.Status.NodeID, ghComment.Status.URL = 0, "", ""
+	}
+
+	_, _, detailsData := r.ClientFrame.InitDataStructs(ghIssue.Spec.Repo, ghIssue.Spec.Title, "")
+	detailsData.Resource = req.NamespacedName.String()
+	if returnErr := WithCredentials(ctx, secretReader(r.APIReader, r.Client), &ghIssue, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghComment, "CredentialsError", returnErr)
+	}
+	commentData := &clients.Comment{Body: ghComment.Spec.Body}
+	comment, returnErr := r.syncComment(&ghComment, ghIssue.Status.Number, commentData, detailsData)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghComment, "GitHubError", returnErr)
+	}
+
+	ghComment.Status.CommentID = comment.ID
+	ghComment.Status.NodeID = comment.NodeID
+	ghComment.Status.URL = comment.HTMLURL
+	ghComment.Status.Repo = ghIssue.Spec.Repo
+	ghComment.Status.IssueNumber = ghIssue.Status.Number
+	ghComment.Status.LastUpdateTimestamp = comment.UpdatedAt
+	meta.SetStatusCondition(&ghComment.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            "The real comment matches the spec",
+		ObservedGeneration: ghComment.Generation,
+	})
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, &ghComment, patch)
+}
+
+// syncComment creates the real comment, or edits it if its body differs. A real comment deleted on GitHub is posted again
+func (r *GitHubIssueCommentReconciler) syncComment(ghComment *examplev1alpha1.GitHubIssueComment, number int, commentData *clients.Comment, detailsData *clients.Details) (*clients.Comment, *clients.Error) {
+	if ghComment.Status.CommentID != 0 {
+		comment, returnErr := r.ClientFrame.GetComment(ghComment.Status.CommentID, detailsData)
+		switch {
+		case returnErr.ErrorCode == nil:
+			if comment.Body != commentData.Body {
+				returnErr = r.ClientFrame.EditComment(commentData, comment, detailsData)
+			}
+			return comment, returnErr
+		case returnErr.StatusCode != 404:
+			return nil, returnErr
+		}
+	}
+	return r.ClientFrame.CreateComment(number, commentData, detailsData)
+}
+
+// removeComment deletes or minimizes the real comment recorded in the status, by the deletion policy. It uses the
+// credentials the GitHubIssue has in the repo of the comment, or the operator's if the issue is gone
+func (r *GitHubIssueCommentReconciler) removeComment(ctx context.Context, ghComment *examplev1alpha1.GitHubIssueComment, ghIssue *examplev1alpha1.GitHubIssue, ghRepo *examplev1alpha1.GitHubRepository) *clients.Error {
+	if ghComment.Status.CommentID == 0 {
+		return &clients.Error{}
+	}
+	_, _, detailsData := r.ClientFrame.InitDataStructs(ghComment.Status.Repo, "", "")
+	detailsData.Resource = types.NamespacedName{Namespace: ghComment.Namespace, Name: ghComment.Name}.String()
+	if ghIssue != nil {
+		returnErr := credentialsOf(ctx, secretReader(r.APIReader, r.Client), ghIssue.Namespace, ghIssue.Spec.CredentialsRef, ghComment.Status.Repo, ghRepo, detailsData)
+		if returnErr.ErrorCode != nil {
+			return returnErr
+		}
+	}
+	comment := &clients.Comment{ID: ghComment.Status.CommentID, NodeID: ghComment.Status.NodeID, Body: ghComment.Spec.Body}
+	if ghComment.Spec.DeletionPolicy == CommentDeletionMinimize {
+		returnErr := r.ClientFrame.MinimizeComment(comment, clients.MinimizeOutdated, detailsData)
+		if returnErr.StatusCode == 404 {
+			// The comment is gone already
+			return &clients.Error{}
+		}
+		return returnErr
+	}
+	return r.ClientFrame.DeleteComment(comment, detailsData)
+}
+
+// issueOf returns the GitHubIssue of a deleted comment with its GitHubRepository, nil if the issue is gone. A
+// repository that can't be used anymore is left out, so that it doesn't keep the comment from being deleted
+func (r *GitHubIssueCommentReconciler) issueOf(ctx context.Context, ghComment *examplev1alpha1.GitHubIssueComment) (*examplev1alpha1.GitHubIssue, *examplev1alpha1.GitHubRepository, error) {
+	ghIssue := examplev1alpha1.GitHubIssue{}
+	key := types.NamespacedName{Namespace: ghComment.Namespace, Name: ghComment.Spec.IssueRef.Name}
+	if err := r.Client.Get(ctx, key, &ghIssue); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	ghRepo, returnErr := WithRepository(ctx, r.Client, &ghIssue)
+	if returnErr.ErrorCode != nil {
+		r.Log.Info(returnErr.Message, "name-of-gh-issue-comment", types.NamespacedName{Namespace: ghComment.Namespace, Name: ghComment.Name})
+	}
+	return &ghIssue, ghRepo, nil
+}
+
+// waitForIssue reports why the comment can't be posted yet, it is reconciled again when its GitHubIssue changes
+func (r *GitHubIssueCommentReconciler) waitForIssue(ctx context.Context, ghComment *examplev1alpha1.GitHubIssueComment, reason, message string) error {
+	patch := client.MergeFrom(ghComment.DeepCopy())
+	meta.SetStatusCondition(&ghComment.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: ghComment.Generation,
+	})
+	return r.Client.Status().Patch(ctx, ghComment, patch)
+}
+
+// syncFailed reports the GitHub error in the Synced condition, and returns it for a retry
+func (r *GitHubIssueCommentReconciler) syncFailed(ctx context.Context, ghComment *examplev1alpha1.GitHubIssueComment, reason string, returnErr *clients.Error) error {
+	return syncFailedCondition(ctx, r.Client, r.Log, ghComment, &ghComment.Status.Conditions, reason, returnErr)
+}
+
+// commentsOf returns the GitHubIssueComment objects referencing a GitHubIssue, so they are posted once it's created
+func (r *GitHubIssueCommentReconciler) commentsOf(obj client.Object) []reconcile.Request {
+	ghComments := examplev1alpha1.GitHubIssueCommentList{}
+	if err := r.Client.List(context.Background(), &ghComments, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Info("failed to list the comments", "error", err.Error())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, ghComment := range ghComments.Items {
+		if ghComment.Spec.IssueRef.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ghComment.Namespace, Name: ghComment.Name}})
+		}
+	}
+	return requests
+}
+
+func hasOwnerReference(object, owner metav1.Object) bool {
+	for _, ref := range object.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubIssueCommentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubIssueComment{}).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubIssue{}}, handler.EnqueueRequestsFromMapFunc(r.commentsOf)).
+		Complete(r)
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestCommentWaitsForIssue(t *testing.T) {
+	// Given a comment on a GitHubIssue whose real issue isn't created yet
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r, k8sClient := newTestCommentReconciler(fakeClient, newTestGitHubIssue("body"), newTestGitHubIssueComment("hello"))
+
+	// When reconciling it
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then nothing is posted, and the status tells why
+	if fakeClient.CallsTo("CreateComment") != 0 {
+		t.Errorf("Expected no comment to be created")
+	}
+	ghComment := examplev1alpha1.GitHubIssueComment{}
+	getTestObject(t, k8sClient, testCommentRequest.NamespacedName, &ghComment)
+	synced := meta.FindStatusCondition(ghComment.Status.Conditions, ConditionSynced)
+	if synced == nil || synced.Status != metav1.ConditionFalse || synced.Reason != "IssueNotCreated" {
+		t.Errorf("Expected a false Synced condition for the missing issue but got %+v", synced)
+	}
+}
+
+func TestCommentCreateAndEdit(t *testing.T) {
+	// Given a comment on a created issue
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Number: 1}}, true, nil)
+	r, k8sClient := newTestCommentReconciler(fakeClient, newTestCommentedIssue(), newTestGitHubIssueComment("hello"))
+
+	// When reconciling it, then the comment is posted and tracked in the status
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	comments := fakeClient.Comments()
+	if len(comments) != 1 || comments[0].Number != 1 || comments[0].Body != "hello" {
+		t.Fatalf("Expected the comment on issue 1 but got %+v", comments)
+	}
+	ghComment := examplev1alpha1.GitHubIssueComment{}
+	getTestObject(t, k8sClient, testCommentRequest.NamespacedName, &ghComment)
+	if ghComment.Status.CommentID != comments[0].ID || ghComment.Status.IssueNumber != 1 || ghComment.Status.Repo != "arielireni/Issues-Example" {
+		t.Errorf("Expected the comment in the status but got %+v", ghComment.Status)
+	}
+	owners := ghComment.GetOwnerReferences()
+	if len(owners) != 1 || owners[0].UID != "issue1-uid" || owners[0].Kind != "GitHubIssue" {
+		t.Errorf("Expected the GitHubIssue as owner but got %+v", owners)
+	}
+	if !containsString(ghComment.GetFinalizers(), finalizerName) {
+		t.Errorf("Expected the finalizer")
+	}
+
+	// When the body changes, then the comment is edited instead of posted again
+	ghComment.Spec.Body = "hello again"
+	if err := k8sClient.Update(context.Background(), &ghComment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	comments = fakeClient.Comments()
+	if len(comments) != 1 || comments[0].Body != "hello again" || fakeClient.CallsTo("EditComment") != 1 {
+		t.Errorf("Expected the comment to be edited but got %+v", comments)
+	}
+
+	// When nothing changes, then the comment is left alone
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if fakeClient.CallsTo("EditComment") != 1 || fakeClient.CallsTo("CreateComment") != 1 {
+		t.Errorf("Expected no more mutations but got %+v", fakeClient.Calls())
+	}
+}
+
+func TestCommentDeletion(t *testing.T) {
+	for _, policy := range []string{CommentDeletionDelete, CommentDeletionMinimize} {
+		// Given a deleted comment whose real comment was posted
+		fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Number: 1}}, true, nil)
+		_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+		posted, _ := fakeClient.CreateComment(1, &clients.Comment{Body: "hello"}, detailsData)
+		ghComment := newTestGitHubIssueComment("hello")
+		ghComment.Spec.DeletionPolicy = policy
+		ghComment.Finalizers = []string{finalizerName}
+		now := metav1.Now()
+		ghComment.DeletionTimestamp = &now
+		ghComment.Status.CommentID = posted.ID
+		ghComment.Status.NodeID = posted.NodeID
+		ghComment.Status.Repo = "arielireni/Issues-Example"
+		r, _ := newTestCommentReconciler(fakeClient, ghComment)
+
+		// When reconciling it
+		if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+			t.Fatalf("%s: expected nil but got error: %v", policy, err)
+		}
+
+		// Then the real comment is deleted, or minimized and kept
+		comments := fakeClient.Comments()
+		switch policy {
+		case CommentDeletionDelete:
+			if len(comments) != 0 {
+				t.Errorf("Expected the comment to be deleted but got %+v", comments)
+			}
+		case CommentDeletionMinimize:
+			if len(comments) != 1 || !comments[0].IsMinimized {
+				t.Errorf("Expected the comment to be minimized but got %+v", comments)
+			}
+		}
+	}
+}
+
+// tokenRecorder records the tokens the real comments are deleted with
+type tokenRecorder struct {
+	*clients.FakeClient
+	tokens []string
+}
+
+func (f *tokenRecorder) DeleteComment(comment *clients.Comment, detailsData *clients.Details) *clients.Error {
+	f.tokens = append(f.tokens, detailsData.Token)
+	return f.FakeClient.DeleteComment(comment, detailsData)
+}
+
+func TestCommentDeletionCredentials(t *testing.T) {
+	// Given a deleted comment of a GitHubIssue whose credentials are in a Secret
+	fakeClient := &tokenRecorder{FakeClient: clients.NewFakeClient([]clients.Issue{{Title: "title1", Number: 1}}, true, nil)}
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	posted, _ := fakeClient.CreateComment(1, &clients.Comment{Body: "hello"}, detailsData)
+	ghIssue := newTestCommentedIssue()
+	ghIssue.Spec.CredentialsRef = &examplev1alpha1.CredentialsReference{Name: "team-token"}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "team-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("secret")}}
+	ghComment := newTestGitHubIssueComment("hello")
+	ghComment.Finalizers = []string{finalizerName}
+	now := metav1.Now()
+	ghComment.DeletionTimestamp = &now
+	ghComment.Status.CommentID, ghComment.Status.NodeID = posted.ID, posted.NodeID
+	ghComment.Status.Repo, ghComment.Status.IssueNumber = "arielireni/Issues-Example", 1
+	r, _ := newTestCommentReconciler(fakeClient, ghIssue, secret, ghComment)
+
+	// When reconciling it
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the real comment is deleted with the token of the issue
+	if len(fakeClient.Comments()) != 0 || len(fakeClient.tokens) != 1 || fakeClient.tokens[0] != "secret" {
+		t.Errorf("Expected the comment to be deleted with the secret token but got %+v, %v", fakeClient.Comments(), fakeClient.tokens)
+	}
+}
+
+func TestCommentRecreatedWhenDeletedOnGitHub(t *testing.T) {
+	// Given a comment whose real comment was deleted on GitHub
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Number: 1}}, true, nil)
+	ghComment := newTestGitHubIssueComment("hello")
+	ghComment.Status.CommentID = 42
+	ghComment.Status.Repo = "arielireni/Issues-Example"
+	ghComment.Status.IssueNumber = 1
+	r, k8sClient := newTestCommentReconciler(fakeClient, newTestCommentedIssue(), ghComment)
+
+	// When reconciling it, then it is posted again
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	comments := fakeClient.Comments()
+	got := examplev1alpha1.GitHubIssueComment{}
+	getTestObject(t, k8sClient, testCommentRequest.NamespacedName, &got)
+	if len(comments) != 1 || got.Status.CommentID != comments[0].ID {
+		t.Errorf("Expected the comment to be posted again but got %+v", comments)
+	}
+}
+
+func TestCommentDryRun(t *testing.T) {
+	// Given a comment on a created issue, and a comment being deleted, reconciled in dry-run mode
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "title1", Number: 1}}, true, nil)
+	r, k8sClient := newTestCommentReconciler(clients.NewDryRunClient(fakeClient), newTestCommentedIssue(), newTestGitHubIssueComment("hello"))
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	posted, _ := fakeClient.CreateComment(1, &clients.Comment{Body: "hello"}, detailsData)
+	deleted := newTestGitHubIssueComment("hello")
+	deleted.Finalizers = []string{finalizerName}
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	deleted.Status.CommentID = posted.ID
+	deleted.Status.Repo = "arielireni/Issues-Example"
+	rDeleted, deletedClient := newTestCommentReconciler(clients.NewDryRunClient(fakeClient), deleted)
+
+	// When reconciling them
+	if _, err := r.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if _, err := rDeleted.Reconcile(context.Background(), testCommentRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then nothing is posted or deleted, the status tells what would have been done, and the deleted comment is
+	// released with its real comment left on GitHub
+	if len(fakeClient.Comments()) != 1 {
+		t.Errorf("Expected only the posted comment but got %+v", fakeClient.Comments())
+	}
+	ghComment := examplev1alpha1.GitHubIssueComment{}
+	getTestObject(t, k8sClient, testCommentRequest.NamespacedName, &ghComment)
+	synced := meta.FindStatusCondition(ghComment.Status.Conditions, ConditionSynced)
+	if synced == nil || synced.Status != metav1.ConditionFalse || synced.Reason != "DryRun" {
+		t.Errorf("Expected a false Synced condition for the dry run but got %+v", synced)
+	}
+	released := examplev1alpha1.GitHubIssueComment{}
+	getTestObject(t, deletedClient, testCommentRequest.NamespacedName, &released)
+	if len(released.Finalizers) != 0 {
+		t.Errorf("Expected the finalizer to be removed but got %v", released.Finalizers)
+	}
+}
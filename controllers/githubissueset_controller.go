@@ -0,0 +1,359 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// IssueSetLabel is set on the GitHubIssue objects of a GitHubIssueSet to the UID of the set, a name may be too
+// long for a label value
+const IssueSetLabel = "example.training.redhat.com/issue-set"
+
+// IssueSetKeyAnnotation holds the key of the element a GitHubIssue was generated from
+const IssueSetKeyAnnotation = "example.training.redhat.com/issue-set-key"
+
+// IssueSetLabelsAnnotation holds the keys of the template labels set on a GitHubIssue, so the labels removed from
+// the template are removed from it too
+const IssueSetLabelsAnnotation = "example.training.redhat.com/issue-set-labels"
+
+// ConditionGenerated is true when the elements of a GitHubIssueSet were generated and rendered
+const ConditionGenerated = "Generated"
+
+// GitHubIssueSetReconciler reconciles a GitHubIssueSet object
+type GitHubIssueSetReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// issueSetElement is a generated element, as the template sees it
+type issueSetElement struct {
+	Key    string
+	Repo   string
+	Values map[string]string
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuesets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuesets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubissuesets/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Reconcile makes the GitHubIssue objects owned by a GitHubIssueSet match its generated elements,
+// and aggregates their status
+func (r *GitHubIssueSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-issue-set", req.NamespacedName)
+
+	issueSet := examplev1alpha1.GitHubIssueSet{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &issueSet); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !issueSet.DeletionTimestamp.IsZero() {
+		// The owned GitHubIssue objects are garbage collected
+		return ctrl.Result{}, nil
+	}
+
+	elements, err := r.generate(ctx, &issueSet)
+	if err != nil {
+		log.Info("failed to generate the elements", "error", err.Error())
+		return ctrl.Result{}, r.generateFailed(ctx, &issueSet, err)
+	}
+	desired := map[string]*examplev1alpha1.GitHubIssue{}
+	for _, element := range elements {
+		ghIssue, err := renderIssue(&issueSet, element)
+		if err != nil {
+			log.Info("failed to render the template", "key", element.Key, "error", err.Error())
+			return ctrl.Result{}, r.generateFailed(ctx, &issueSet, err)
+		}
+		desired[ghIssue.Name] = ghIssue
+	}
+
+	// Create or update the GitHubIssue of every element
+	for _, ghIssue := range desired {
+		child := &examplev1alpha1.GitHubIssue{ObjectMeta: metav1.ObjectMeta{Name: ghIssue.Name, Namespace: ghIssue.Namespace}}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, child, func() error {
+			if child.Labels == nil {
+				child.Labels = map[string]string{}
+			}
+			for _, key := range strings.Split(child.Annotations[IssueSetLabelsAnnotation], ",") {
+				if _, ok := ghIssue.Labels[key]; !ok {
+					delete(child.Labels, key)
+				}
+			}
+			for key, value := range ghIssue.Labels {
+				child.Labels[key] = value
+			}
+			if child.Annotations == nil {
+				child.Annotations = map[string]string{}
+			}
+			child.Annotations[IssueSetKeyAnnotation] = ghIssue.Annotations[IssueSetKeyAnnotation]
+			child.Annotations[IssueSetLabelsAnnotation] = ghIssue.Annotations[IssueSetLabelsAnnotation]
+			// Only the fields of the template are owned, the ones defaulted by the webhook and the CRD are kept
+			child.Spec.Repo = ghIssue.Spec.Repo
+			child.Spec.Title = ghIssue.Spec.Title
+			child.Spec.Description = ghIssue.Spec.Description
+			return controllerutil.SetControllerReference(&issueSet, child, r.Scheme)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Prune the GitHubIssue objects of removed elements, their finalizer closes the real issues
+	children, err := r.children(ctx, &issueSet)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var owned []examplev1alpha1.GitHubIssue
+	for _, child := range children {
+		if _, ok := desired[child.Name]; ok {
+			owned = append(owned, child)
+			continue
+		}
+		log.Info("pruning removed element", "gh-issue", child.Name)
+		if err := r.Client.Delete(ctx, &child); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	}
+
+	patch := client.MergeFrom(issueSet.DeepCopy())
+	issueSet.Status.Issues = len(owned)
+	issueSet.Status.Open, issueSet.Status.Closed, issueSet.Status.Synced, issueSet.Status.Failed = 0, 0, 0, 0
+	for _, child := range owned {
+		switch child.Status.State {
+		case "open":
+			issueSet.Status.Open++
+		case "closed":
+			issueSet.Status.Closed++
+		}
+		if condition := meta.FindStatusCondition(child.Status.Conditions, ConditionSynced); condition != nil {
+			if condition.Status == metav1.ConditionTrue {
+				issueSet.Status.Synced++
+			} else {
+				issueSet.Status.Failed++
+			}
+		}
+	}
+	meta.SetStatusCondition(&issueSet.Status.Conditions, metav1.Condition{
+		Type:               ConditionGenerated,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Generated",
+		Message:            fmt.Sprintf("%d elements generated", len(desired)),
+		ObservedGeneration: issueSet.Generation,
+	})
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, &issueSet, patch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubIssueSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubIssueSet{}).
+		Owns(&examplev1alpha1.GitHubIssue{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.setsSelecting)).
+		Complete(r)
+}
+
+// setsSelecting returns the GitHubIssueSets whose ConfigMap generator selects the given ConfigMap
+func (r *GitHubIssueSetReconciler) setsSelecting(configMap client.Object) []reconcile.Request {
+	issueSets := examplev1alpha1.GitHubIssueSetList{}
+	if err := r.Client.List(context.Background(), &issueSets, client.InNamespace(configMap.GetNamespace())); err != nil {
+		r.Log.Info("failed to list the issue sets", "error", err.Error())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, issueSet := range issueSets.Items {
+		if issueSet.Spec.Generator.ConfigMaps == nil {
+			continue
+		}
+		// A ConfigMap that stopped matching must be pruned too, so every set with a ConfigMap generator is enqueued
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: issueSet.Namespace, Name: issueSet.Name}})
+	}
+	return requests
+}
+
+// generate returns the elements of the set's generator
+func (r *GitHubIssueSetReconciler) generate(ctx context.Context, issueSet *examplev1alpha1.GitHubIssueSet) ([]issueSetElement, error) {
+	generator := issueSet.Spec.Generator
+	set := 0
+	if generator.List != nil {
+		set++
+	}
+	if generator.Repos != nil {
+		set++
+	}
+	if generator.ConfigMaps != nil {
+		set++
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of list, repos and configMaps must be set in the generator")
+	}
+
+	var elements []issueSetElement
+	switch {
+	case generator.List != nil:
+		for _, element := range generator.List {
+			elements = append(elements, issueSetElement{Key: element.Key, Repo: element.Repo, Values: element.Values})
+		}
+	case generator.Repos != nil:
+		for _, repo := range generator.Repos {
+			elements = append(elements, issueSetElement{Key: repo, Repo: repo})
+		}
+	default:
+		selector, err := metav1.LabelSelectorAsSelector(generator.ConfigMaps)
+		if err != nil {
+			return nil, err
+		}
+		configMaps := corev1.ConfigMapList{}
+		err = r.Client.List(ctx, &configMaps, client.InNamespace(issueSet.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, configMap := range configMaps.Items {
+			elements = append(elements, issueSetElement{Key: configMap.Name, Repo: configMap.Data["repo"], Values: configMap.Data})
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, element := range elements {
+		if element.Key == "" {
+			return nil, fmt.Errorf("generated an element without a key")
+		}
+		if keys[element.Key] {
+			return nil, fmt.Errorf("generated the key %q more than once", element.Key)
+		}
+		keys[element.Key] = true
+	}
+	return elements, nil
+}
+
+// children returns the GitHubIssue objects owned by the set
+func (r *GitHubIssueSetReconciler) children(ctx context.Context, issueSet *examplev1alpha1.GitHubIssueSet) ([]examplev1alpha1.GitHubIssue, error) {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	err := r.Client.List(ctx, &ghIssues, client.InNamespace(issueSet.Namespace), client.MatchingLabels{IssueSetLabel: string(issueSet.UID)})
+	if err != nil {
+		return nil, err
+	}
+	var children []examplev1alpha1.GitHubIssue
+	for _, ghIssue := range ghIssues.Items {
+		if metav1.IsControlledBy(&ghIssue, issueSet) {
+			children = append(children, ghIssue)
+		}
+	}
+	return children, nil
+}
+
+// generateFailed reports the error in the Generated condition, and returns it for a retry
+func (r *GitHubIssueSetReconciler) generateFailed(ctx context.Context, issueSet *examplev1alpha1.GitHubIssueSet, err error) error {
+	patch := client.MergeFrom(issueSet.DeepCopy())
+	meta.SetStatusCondition(&issueSet.Status.Conditions, metav1.Condition{
+		Type:               ConditionGenerated,
+		Status:             metav1.ConditionFalse,
+		Reason:             "GenerateError",
+		Message:            err.Error(),
+		ObservedGeneration: issueSet.Generation,
+	})
+	if patchErr := r.Client.Status().Patch(ctx, issueSet, patch); patchErr != nil {
+		r.Log.Info("failed to report the generate error in the status", "error", patchErr.Error())
+	}
+	return err
+}
+
+// renderIssue returns the GitHubIssue of an element, named after the set and a hash of the element's key. The
+// template can't override IssueSetLabel
+func renderIssue(issueSet *examplev1alpha1.GitHubIssueSet, element issueSetElement) (*examplev1alpha1.GitHubIssue, error) {
+	tmpl := issueSet.Spec.Template
+	if tmpl.Repo == "" {
+		tmpl.Repo = "{{ .Repo }}"
+	}
+	ghIssue := &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        issueSetChildName(issueSet.Name, element.Key),
+			Namespace:   issueSet.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{IssueSetKeyAnnotation: element.Key},
+		},
+	}
+	var keys []string
+	for key, value := range tmpl.Labels {
+		if key == IssueSetLabel {
+			continue
+		}
+		ghIssue.Labels[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ghIssue.Labels[IssueSetLabel] = string(issueSet.UID)
+	ghIssue.Annotations[IssueSetLabelsAnnotation] = strings.Join(keys, ",")
+	fields := []struct {
+		name  string
+		text  string
+		value *string
+	}{
+		{"repo", tmpl.Repo, &ghIssue.Spec.Repo},
+		{"title", tmpl.Title, &ghIssue.Spec.Title},
+		{"description", tmpl.Description, &ghIssue.Spec.Description},
+	}
+	for _, field := range fields {
+		t, err := template.New(field.name).Option("missingkey=error").Parse(field.text)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", field.name, err)
+		}
+		out := &bytes.Buffer{}
+		if err := t.Execute(out, element); err != nil {
+			return nil, fmt.Errorf("template %s of %q: %v", field.name, element.Key, err)
+		}
+		*field.value = out.String()
+	}
+	if ghIssue.Spec.Repo == "" || ghIssue.Spec.Title == "" {
+		return nil, fmt.Errorf("element %q has no repo or title", element.Key)
+	}
+	return ghIssue, nil
+}
+
+// issueSetChildName returns a valid object name for the element, stable as long as its key is
+func issueSetChildName(setName, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	prefix := setName
+	if len(prefix) > 240 {
+		prefix = prefix[:240]
+	}
+	return strings.TrimSuffix(prefix, "-") + "-" + hex.EncodeToString(sum[:])[:8]
+}
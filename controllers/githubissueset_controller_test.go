@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
+	"testing"
+)
+
+var testSetRequest = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "set1"}}
+
+func newTestGitHubIssueSet(generator examplev1alpha1.IssueSetGenerator) *examplev1alpha1.GitHubIssueSet {
+	return &examplev1alpha1.GitHubIssueSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "set1", Namespace: "default", UID: "set1-uid"},
+		Spec: examplev1alpha1.GitHubIssueSetSpec{
+			Template: examplev1alpha1.GitHubIssueTemplate{
+				Title:       "Upgrade {{ .Key }}",
+				Description: "Owner: {{ .Values.owner }}",
+			},
+			Generator: generator,
+		},
+	}
+}
+
+func newTestSetReconciler(objects ...runtime.Object) (*GitHubIssueSetReconciler, client.Client) {
+	s := scheme.Scheme
+	examplev1alpha1.AddToScheme(s)
+	fakeK8sClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+	return &GitHubIssueSetReconciler{Client: fakeK8sClient, Log: ctrl.Log, Scheme: s}, fakeK8sClient
+}
+
+func listSetChildren(t *testing.T, k8sClient client.Client) map[string]examplev1alpha1.GitHubIssue {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	if err := k8sClient.List(context.Background(), &ghIssues, client.MatchingLabels{IssueSetLabel: "set1-uid"}); err != nil {
+		t.Fatal(err)
+	}
+	children := map[string]examplev1alpha1.GitHubIssue{}
+	for _, ghIssue := range ghIssues.Items {
+		children[ghIssue.Annotations[IssueSetKeyAnnotation]] = ghIssue
+	}
+	return children
+}
+
+func TestIssueSetCreatesAndPrunes(t *testing.T) {
+	issueSet := newTestGitHubIssueSet(examplev1alpha1.IssueSetGenerator{
+		List: []examplev1alpha1.IssueSetElement{
+			{Key: "a", Repo: "owner/a", Values: map[string]string{"owner": "alice"}},
+			{Key: "b", Repo: "owner/b", Values: map[string]string{"owner": "bob"}},
+		},
+	})
+	r, k8sClient := newTestSetReconciler(issueSet)
+
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	children := listSetChildren(t, k8sClient)
+	if len(children) != 2 {
+		t.Fatalf("Expected 2 children but got %d", len(children))
+	}
+	a := children["a"]
+	if a.Spec.Repo != "owner/a" || a.Spec.Title != "Upgrade a" || a.Spec.Description != "Owner: alice" {
+		t.Errorf("Unexpected rendered spec: %+v", a.Spec)
+	}
+	if !metav1.IsControlledBy(&a, issueSet) {
+		t.Errorf("Expected the child to be controlled by the set")
+	}
+
+	// Removing an element prunes its GitHubIssue
+	got := examplev1alpha1.GitHubIssueSet{}
+	k8sClient.Get(context.Background(), testSetRequest.NamespacedName, &got)
+	got.Spec.Generator.List = got.Spec.Generator.List[:1]
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if children := listSetChildren(t, k8sClient); len(children) != 1 || children["a"].Name == "" {
+		t.Errorf("Expected only the child of a but got %v", children)
+	}
+}
+
+func TestIssueSetConfigMapGeneratorAndStatus(t *testing.T) {
+	issueSet := newTestGitHubIssueSet(examplev1alpha1.IssueSetGenerator{
+		ConfigMaps: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}},
+	})
+	selected := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default", Labels: map[string]string{"team": "infra"}},
+		Data:       map[string]string{"repo": "owner/svc", "owner": "carol"},
+	}
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		Data:       map[string]string{"repo": "owner/other", "owner": "dave"},
+	}
+	r, k8sClient := newTestSetReconciler(issueSet, selected, other)
+
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	children := listSetChildren(t, k8sClient)
+	if len(children) != 1 || children["svc"].Spec.Repo != "owner/svc" {
+		t.Fatalf("Expected the child of svc only but got %v", children)
+	}
+
+	// The child's status is aggregated into the set
+	child := children["svc"]
+	child.Status.State = "open"
+	meta.SetStatusCondition(&child.Status.Conditions, metav1.Condition{Type: ConditionSynced, Status: metav1.ConditionTrue, Reason: "Synced"})
+	if err := k8sClient.Status().Update(context.Background(), &child); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubIssueSet{}
+	k8sClient.Get(context.Background(), testSetRequest.NamespacedName, &got)
+	if got.Status.Issues != 1 || got.Status.Open != 1 || got.Status.Synced != 1 || got.Status.Failed != 0 {
+		t.Errorf("Unexpected status: %+v", got.Status)
+	}
+}
+
+func TestIssueSetInvalidGenerator(t *testing.T) {
+	issueSet := newTestGitHubIssueSet(examplev1alpha1.IssueSetGenerator{
+		Repos: []string{"owner/a"},
+		List:  []examplev1alpha1.IssueSetElement{{Key: "a", Repo: "owner/a"}},
+	})
+	r, k8sClient := newTestSetReconciler(issueSet)
+
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err == nil {
+		t.Errorf("Expected error but got nil")
+	}
+	got := examplev1alpha1.GitHubIssueSet{}
+	k8sClient.Get(context.Background(), testSetRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionGenerated); condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Errorf("Expected a false Generated condition but got %v", got.Status.Conditions)
+	}
+}
+
+func TestIssueSetTemplateLabels(t *testing.T) {
+	// Given a set with a long name whose template labels try to override the set label
+	issueSet := newTestGitHubIssueSet(examplev1alpha1.IssueSetGenerator{Repos: []string{"owner/a"}})
+	issueSet.Name = strings.Repeat("s", 100)
+	issueSet.Spec.Template.Description = "Upgrade {{ .Repo }}"
+	issueSet.Spec.Template.Labels = map[string]string{"team": "infra", "tier": "1", IssueSetLabel: "other"}
+	r, k8sClient := newTestSetReconciler(issueSet)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: issueSet.Name}}
+
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	child := listSetChildren(t, k8sClient)["owner/a"]
+	if child.Labels["team"] != "infra" || child.Labels["tier"] != "1" || child.Labels[IssueSetLabel] != "set1-uid" {
+		t.Fatalf("Expected the template labels and the set UID but got %v", child.Labels)
+	}
+
+	// When a label is removed from the template, then it is removed from the child but labels set by others are kept
+	child.Labels["owner"] = "alice"
+	if err := k8sClient.Update(context.Background(), &child); err != nil {
+		t.Fatal(err)
+	}
+	got := examplev1alpha1.GitHubIssueSet{}
+	k8sClient.Get(context.Background(), request.NamespacedName, &got)
+	delete(got.Spec.Template.Labels, "tier")
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), request); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	child = listSetChildren(t, k8sClient)["owner/a"]
+	if _, ok := child.Labels["tier"]; ok || child.Labels["team"] != "infra" || child.Labels["owner"] != "alice" {
+		t.Errorf("Expected the tier label to be pruned but got %v", child.Labels)
+	}
+}
+
+func TestIssueSetKeepsDefaults(t *testing.T) {
+	// Given a set whose child was defaulted by the webhook and the CRD
+	issueSet := newTestGitHubIssueSet(examplev1alpha1.IssueSetGenerator{
+		List: []examplev1alpha1.IssueSetElement{{Key: "a", Repo: "owner/a", Values: map[string]string{"owner": "alice"}}},
+	})
+	r, k8sClient := newTestSetReconciler(issueSet)
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	child := listSetChildren(t, k8sClient)["a"]
+	child.Spec.RepoChangePolicy = "Recreate"
+	child.Spec.Provider = "github"
+	child.Spec.Labels = []string{"team/infra"}
+	if err := k8sClient.Update(context.Background(), &child); err != nil {
+		t.Fatal(err)
+	}
+	child = listSetChildren(t, k8sClient)["a"]
+
+	// When reconciling the set again
+	if _, err := r.Reconcile(context.Background(), testSetRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the defaults are kept, and the child isn't updated
+	got := listSetChildren(t, k8sClient)["a"]
+	if got.Spec.RepoChangePolicy != "Recreate" || got.Spec.Provider != "github" || len(got.Spec.Labels) != 1 {
+		t.Errorf("Expected the defaults to be kept but got %+v", got.Spec)
+	}
+	if got.ResourceVersion != child.ResourceVersion {
+		t.Errorf("Expected no update but the resource version changed from %s to %s", child.ResourceVersion, got.ResourceVersion)
+	}
+}
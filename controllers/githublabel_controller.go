@@ -0,0 +1,298 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+)
+
+// Conflict and deletion policies of a GitHubLabel
+const (
+	LabelConflictReport    = "Report"
+	LabelConflictOverwrite = "Overwrite"
+	LabelDeletionOrphan    = "Orphan"
+	LabelDeletionDelete    = "Delete"
+)
+
+const (
+	// managedLabelIndex indexes GitHubLabel objects by the labelKey of the label they manage
+	managedLabelIndex = ".status.label"
+	// labelNameIndex indexes GitHubLabel objects by their lowercase name
+	labelNameIndex = ".spec.name"
+)
+
+// Reasons of the Synced condition of a GitHubLabel or a GitHubMilestone in conflict with another label or
+// milestone, they aren't retried until the spec or the conflicting object change
+const (
+	ReasonLabelExists     = "LabelExists"
+	ReasonMilestoneExists = "MilestoneExists"
+	ReasonManagedByOther  = "ManagedByOther"
+	ReasonNameTaken       = "NameTaken"
+)
+
+// GitHubLabelReconciler reconciles a GitHubLabel object
+type GitHubLabelReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	ClientFrame clients.ClientFrame
+	// APIReader reads the credentials Secrets, the Client if nil
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githublabels,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githublabels/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githublabels/finalizers,verbs=update
+
+// Reconcile creates the label in its repo, edits or renames it when the spec changes, and deletes it with the
+// object if its deletion policy says so. A label of the repo it didn't create is only taken over with the
+// Overwrite conflict policy, or if it already matches the spec
+func (r *GitHubLabelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-label", req.NamespacedName)
+
+	ghLabel := examplev1alpha1.GitHubLabel{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &ghLabel); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := withFinalizer(ctx, r.Client, &ghLabel, func() error {
+		if returnErr := r.removeLabel(ctx, &ghLabel); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return r.syncFailed(ctx, &ghLabel, "GitHubError", returnErr)
+		}
+		return nil
+	})
+	if deleted || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if ghLabel.Spec.Repo == "" && ghLabel.Spec.RepositoryRef == nil {
+		return ctrl.Result{}, r.setSynced(ctx, &ghLabel, metav1.ConditionFalse, "InvalidSpec", "Set the repo or the repositoryRef of the label")
+	}
+	repo, ghRepo, returnErr := repoOf(ctx, r.Client, ghLabel.Namespace, ghLabel.Spec.Repo, ghLabel.Spec.RepositoryRef)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghLabel, "RepositoryError", returnErr)
+	}
+
+	// The label of the previous repo is handled as if the object was deleted
+	if ghLabel.Status.Repo != "" && repoKey(ghLabel.Status.Repo) != repoKey(repo) {
+		if returnErr := r.removeLabel(ctx, &ghLabel); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghLabel, "GitHubError", returnErr)
+		}
+		patch := client.MergeFrom(ghLabel.DeepCopy())
+		ghLabel.Status.Repo, ghLabel.Status.Name = "", ""
+		if err := r.Client.Status().Patch(ctx, &ghLabel, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	managedBy, err := r.managedByOther(ctx, &ghLabel, repo)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if managedBy != "" {
+		return ctrl.Result{}, r.setSynced(ctx, &ghLabel, metav1.ConditionFalse, ReasonManagedByOther,
+			fmt.Sprintf("Label %s of %s is managed by the GitHubLabel %s", ghLabel.Spec.Name, repoName(repo), managedBy))
+	}
+
+	_, _, detailsData := r.ClientFrame.InitDataStructs(repo, "", "")
+	detailsData.Resource = req.NamespacedName.String()
+	if returnErr := credentialsOf(ctx, secretReader(r.APIReader, r.Client), ghLabel.Namespace, ghLabel.Spec.CredentialsRef, repo, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghLabel, "CredentialsError", returnErr)
+	}
+	labelData := &clients.Label{
+		Name:        ghLabel.Spec.Name,
+		Color:       strings.ToLower(strings.TrimPrefix(ghLabel.Spec.Color, "#")),
+		Description: ghLabel.Spec.Description,
+	}
+	reason, message, returnErr := r.syncLabel(&ghLabel, labelData, detailsData)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghLabel, "GitHubError", returnErr)
+	}
+	if reason != "" {
+		log.Info(message)
+		return ctrl.Result{}, r.setSynced(ctx, &ghLabel, metav1.ConditionFalse, reason, message)
+	}
+
+	patch := client.MergeFrom(ghLabel.DeepCopy())
+	ghLabel.Status.Repo = repo
+	ghLabel.Status.Name = labelData.Name
+	meta.SetStatusCondition(&ghLabel.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            "The label matches the spec",
+		ObservedGeneration: ghLabel.Generation,
+	})
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, &ghLabel, patch)
+}
+
+// syncLabel creates the label, or edits the managed label recorded in the status if it differs from the spec.
+// It returns the reason and message of a conflict with another label of the repo, if there is one
+func (r *GitHubLabelReconciler) syncLabel(ghLabel *examplev1alpha1.GitHubLabel, labelData *clients.Label, detailsData *clients.Details) (string, string, *clients.Error) {
+	if ghLabel.Status.Name != "" {
+		label, returnErr := r.ClientFrame.GetLabel(ghLabel.Status.Name, detailsData)
+		switch {
+		case returnErr.ErrorCode == nil:
+			if clients.SameLabel(labelData, label) {
+				return "", "", returnErr
+			}
+			returnErr = r.ClientFrame.EditLabel(labelData, label, detailsData)
+			if returnErr.StatusCode == http.StatusUnprocessableEntity {
+				return ReasonNameTaken, fmt.Sprintf("Label %s can't be renamed to %s, another label of %s has that name", ghLabel.Status.Name, labelData.Name, repoName(ghLabel.Status.Repo)), &clients.Error{}
+			}
+			return "", "", returnErr
+		case returnErr.StatusCode != http.StatusNotFound:
+			return "", "", returnErr
+		}
+		// The managed label was deleted or renamed on GitHub, the spec wins again
+	}
+
+	label, returnErr := r.ClientFrame.GetLabel(labelData.Name, detailsData)
+	switch {
+	case returnErr.StatusCode == http.StatusNotFound:
+		_, returnErr = r.ClientFrame.CreateLabel(labelData, detailsData)
+		return "", "", returnErr
+	case returnErr.ErrorCode != nil:
+		return "", "", returnErr
+	case clients.SameLabel(labelData, label):
+		return "", "", returnErr
+	case ghLabel.Status.Name == "" && ghLabel.Spec.ConflictPolicy != LabelConflictOverwrite:
+		return ReasonLabelExists, fmt.Sprintf("Label %s already exists with color %s and description %q, set the conflictPolicy to %s to take it over",
+			label.Name, label.Color, label.Description, LabelConflictOverwrite), &clients.Error{}
+	}
+	return "", "", r.ClientFrame.EditLabel(labelData, label, detailsData)
+}
+
+// removeLabel deletes the managed label recorded in the status if the deletion policy says so
+func (r *GitHubLabelReconciler) removeLabel(ctx context.Context, ghLabel *examplev1alpha1.GitHubLabel) *clients.Error {
+	if ghLabel.Status.Name == "" || ghLabel.Spec.DeletionPolicy != LabelDeletionDelete {
+		return &clients.Error{}
+	}
+	_, _, detailsData := r.ClientFrame.InitDataStructs(ghLabel.Status.Repo, "", "")
+	detailsData.Resource = types.NamespacedName{Namespace: ghLabel.Namespace, Name: ghLabel.Name}.String()
+	var ghRepo *examplev1alpha1.GitHubRepository
+	if ghLabel.Spec.RepositoryRef != nil {
+		// The token of the operator is tried if the repository is gone already
+		ghRepo, _ = repositoryOf(ctx, r.Client, ghLabel.Namespace, ghLabel.Spec.RepositoryRef)
+	}
+	if returnErr := credentialsOf(ctx, secretReader(r.APIReader, r.Client), ghLabel.Namespace, ghLabel.Spec.CredentialsRef, ghLabel.Status.Repo, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	return r.ClientFrame.DeleteLabel(&clients.Label{Name: ghLabel.Status.Name}, detailsData)
+}
+
+// managedByOther returns the namespace/name of another GitHubLabel managing the label of the repo, the first to
+// create or take over a label keeps it
+func (r *GitHubLabelReconciler) managedByOther(ctx context.Context, ghLabel *examplev1alpha1.GitHubLabel, repo string) (string, error) {
+	return managedByOther(ctx, r.Client, &examplev1alpha1.GitHubLabelList{}, managedLabelIndex, indexManagedLabel, ghLabel, labelKey(repo, ghLabel.Spec.Name))
+}
+
+// setSynced reports why the label isn't synced, it is reconciled again when its spec or the conflicting label change
+func (r *GitHubLabelReconciler) setSynced(ctx context.Context, ghLabel *examplev1alpha1.GitHubLabel, status metav1.ConditionStatus, reason, message string) error {
+	return setSyncedCondition(ctx, r.Client, ghLabel, &ghLabel.Status.Conditions, status, reason, message)
+}
+
+// syncFailed reports the error in the Synced condition, and returns it for a retry
+func (r *GitHubLabelReconciler) syncFailed(ctx context.Context, ghLabel *examplev1alpha1.GitHubLabel, reason string, returnErr *clients.Error) error {
+	return syncFailedCondition(ctx, r.Client, r.Log, ghLabel, &ghLabel.Status.Conditions, reason, returnErr)
+}
+
+// labelsNamed returns the other GitHubLabel objects with the name of a GitHubLabel, so the ones in conflict with
+// it take the label over once it's deleted or renamed
+func (r *GitHubLabelReconciler) labelsNamed(obj client.Object) []reconcile.Request {
+	ghLabel := obj.(*examplev1alpha1.GitHubLabel)
+	names := []string{strings.ToLower(ghLabel.Spec.Name)}
+	if ghLabel.Status.Name != "" && !strings.EqualFold(ghLabel.Status.Name, ghLabel.Spec.Name) {
+		names = append(names, strings.ToLower(ghLabel.Status.Name))
+	}
+	named := &examplev1alpha1.GitHubLabelList{}
+	for _, name := range names {
+		ghLabels := examplev1alpha1.GitHubLabelList{}
+		if err := r.Client.List(context.Background(), &ghLabels, client.MatchingFields{labelNameIndex: name}); err != nil {
+			r.Log.Info("failed to list the labels", "error", err.Error())
+			return nil
+		}
+		for _, other := range ghLabels.Items {
+			if strings.EqualFold(other.Spec.Name, name) {
+				named.Items = append(named.Items, other)
+			}
+		}
+	}
+	return requestsOf(named, obj)
+}
+
+// labelKey returns the key of the label of the repo with the name, equal for all the references to the repo and
+// the cases of the name
+func labelKey(repo, name string) string {
+	return repoKey(repo) + "/" + strings.ToLower(name)
+}
+
+// indexManagedLabel returns the labelKey of the label a GitHubLabel manages, for managedLabelIndex
+func indexManagedLabel(obj client.Object) []string {
+	ghLabel := obj.(*examplev1alpha1.GitHubLabel)
+	if ghLabel.Status.Name == "" {
+		return nil
+	}
+	return []string{labelKey(ghLabel.Status.Repo, ghLabel.Status.Name)}
+}
+
+// indexLabelName returns the lowercase name of a GitHubLabel, for labelNameIndex
+func indexLabelName(obj client.Object) []string {
+	return []string{strings.ToLower(obj.(*examplev1alpha1.GitHubLabel).Spec.Name)}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubLabelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubLabel{}, repositoryRefIndex, indexRepositoryRef); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubLabel{}, managedLabelIndex, indexManagedLabel); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubLabel{}, labelNameIndex, indexLabelName); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubLabel{}).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubRepository{}}, handler.EnqueueRequestsFromMapFunc(objectsOfRepository(r.Client, r.Log, &examplev1alpha1.GitHubLabelList{}))).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubLabel{}}, handler.EnqueueRequestsFromMapFunc(r.labelsNamed)).
+		Complete(r)
+}
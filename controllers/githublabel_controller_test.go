@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"testing"
+)
+
+func TestLabelCreateAndRename(t *testing.T) {
+	// Given a GitHubLabel of a repo without labels
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r, k8sClient := newTestLabelReconciler(fakeClient, newTestGitHubRepository(), newTestGitHubLabel())
+
+	// When reconciling, then the label is created in the repo of the repository
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	labels := fakeClient.Labels()
+	if len(labels) != 1 || labels[0].Name != "bug" || labels[0].Color != "d73a4a" {
+		t.Fatalf("Expected the bug label but got %+v", labels)
+	}
+	got := examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, testLabelRequest.NamespacedName, &got)
+	if got.Status.Name != "bug" || got.Status.Repo != "arielireni/Issues-Example" || !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionSynced) {
+		t.Errorf("Expected the label to be synced but got %+v", got.Status)
+	}
+
+	// When renaming it, then the label is edited rather than created again
+	got.Spec.Name = "kind/bug"
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if labels = fakeClient.Labels(); len(labels) != 1 || labels[0].Name != "kind/bug" {
+		t.Errorf("Expected the label to be renamed but got %+v", labels)
+	}
+	if calls := fakeClient.CallsTo("CreateLabel"); calls != 1 {
+		t.Errorf("Expected a single create but got %d", calls)
+	}
+}
+
+func TestLabelConflict(t *testing.T) {
+	// Given a bug label created by hand with another color
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	fakeClient.CreateLabel(&clients.Label{Name: "Bug", Color: "ff0000"}, detailsData)
+	r, k8sClient := newTestLabelReconciler(fakeClient, newTestGitHubRepository(), newTestGitHubLabel())
+
+	// When reconciling, then the conflict is reported and the label is left alone
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, testLabelRequest.NamespacedName, &got)
+	condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != ReasonLabelExists || got.Status.Name != "" {
+		t.Errorf("Expected a LabelExists conflict but got %+v", got.Status)
+	}
+	if labels := fakeClient.Labels(); labels[0].Color != "ff0000" {
+		t.Errorf("Expected the label to be kept but got %+v", labels)
+	}
+
+	// When overwriting it, then the label is taken over
+	got.Spec.ConflictPolicy = LabelConflictOverwrite
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if labels := fakeClient.Labels(); labels[0].Color != "d73a4a" || labels[0].Name != "bug" {
+		t.Errorf("Expected the label to be taken over but got %+v", labels)
+	}
+
+	// When another GitHubLabel of the same label is reconciled, then it is managed by the first one only
+	other := newTestGitHubLabel()
+	other.Name, other.Namespace, other.UID = "bug", "default2", "other"
+	other.Spec.Color = "000000"
+	if err := k8sClient.Create(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	otherKey := types.NamespacedName{Namespace: "default2", Name: "bug"}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: otherKey}); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got = examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, otherKey, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced); condition == nil || condition.Reason != ReasonManagedByOther {
+		t.Errorf("Expected a ManagedByOther conflict but got %+v", got.Status)
+	}
+	if labels := fakeClient.Labels(); labels[0].Color != "d73a4a" {
+		t.Errorf("Expected the label to be kept but got %+v", labels)
+	}
+
+	// And the first one is indexed by the key of the label in any form of the repo and case of the name
+	first := examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, testLabelRequest.NamespacedName, &first)
+	if keys := indexManagedLabel(&first); !reflect.DeepEqual(keys, []string{labelKey("https://github.com/arielireni/issues-example", "BUG")}) {
+		t.Errorf("Expected the label to be indexed by its key but got %v", keys)
+	}
+}
+
+func TestLabelDeletionPolicy(t *testing.T) {
+	for policy, expected := range map[string]int{LabelDeletionOrphan: 1, LabelDeletionDelete: 0} {
+		// Given a deleted GitHubLabel whose label was created
+		fakeClient := clients.NewFakeClient(nil, true, nil)
+		_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+		fakeClient.CreateLabel(&clients.Label{Name: "bug", Color: "d73a4a"}, detailsData)
+		ghLabel := newTestGitHubLabel()
+		ghLabel.Spec.DeletionPolicy = policy
+		ghLabel.Finalizers = []string{finalizerName}
+		now := metav1.Now()
+		ghLabel.DeletionTimestamp = &now
+		ghLabel.Status.Name = "bug"
+		ghLabel.Status.Repo = "arielireni/Issues-Example"
+		r, _ := newTestLabelReconciler(fakeClient, newTestGitHubRepository(), ghLabel)
+
+		// When reconciling it, then the label is deleted by the Delete policy only
+		if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+			t.Fatalf("%s: expected nil but got error: %v", policy, err)
+		}
+		if labels := fakeClient.Labels(); len(labels) != expected {
+			t.Errorf("Expected %d labels with the %s policy but got %+v", expected, policy, labels)
+		}
+	}
+}
+
+func TestLabelDryRun(t *testing.T) {
+	// Given a GitHubLabel reconciled in dry-run mode
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r, k8sClient := newTestLabelReconciler(clients.NewDryRunClient(fakeClient), newTestGitHubRepository(), newTestGitHubLabel())
+
+	// When reconciling, then the label isn't created and the status tells what would be done
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if labels := fakeClient.Labels(); len(labels) != 0 {
+		t.Errorf("Expected no labels but got %+v", labels)
+	}
+	got := examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, testLabelRequest.NamespacedName, &got)
+	if synced := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced); synced == nil || synced.Reason != "DryRun" || synced.Message != "Dry run, would create label bug" {
+		t.Errorf("Expected the planned creation in the status but got %+v", synced)
+	}
+
+	// When the object of a created label is deleted with the Delete policy, then the label is left in the repo
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	fakeClient.CreateLabel(&clients.Label{Name: "bug", Color: "d73a4a"}, detailsData)
+	got.Spec.DeletionPolicy = LabelDeletionDelete
+	now := metav1.Now()
+	got.DeletionTimestamp = &now
+	got.Status.Name, got.Status.Repo = "bug", "arielireni/Issues-Example"
+	r, k8sClient = newTestLabelReconciler(clients.NewDryRunClient(fakeClient), newTestGitHubRepository(), &got)
+	if _, err := r.Reconcile(context.Background(), testLabelRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if labels := fakeClient.Labels(); len(labels) != 1 {
+		t.Errorf("Expected the label to be left but got %+v", labels)
+	}
+	got = examplev1alpha1.GitHubLabel{}
+	getTestObject(t, k8sClient, testLabelRequest.NamespacedName, &got)
+	if len(got.Finalizers) != 0 {
+		t.Errorf("Expected the object to be let go but got the finalizers %v", got.Finalizers)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"net/http"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Conflict and deletion policies of a GitHubMilestone
+const (
+	MilestoneConflictReport    = "Report"
+	MilestoneConflictOverwrite = "Overwrite"
+	MilestoneDeletionOrphan    = "Orphan"
+	MilestoneDeletionDelete    = "Delete"
+)
+
+// managedMilestoneIndex indexes GitHubMilestone objects by the milestoneKey of the milestone they manage
+const managedMilestoneIndex = ".status.milestone"
+
+// GitHubMilestoneReconciler reconciles a GitHubMilestone object
+type GitHubMilestoneReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	ClientFrame clients.ClientFrame
+	// APIReader reads the credentials Secrets, the Client if nil
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubmilestones,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubmilestones/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubmilestones/finalizers,verbs=update
+
+// Reconcile creates the milestone in its repo, or takes over the milestone with its title, and edits it when the
+// spec changes. The number of its issues is reported in the status, refreshed every sync period
+func (r *GitHubMilestoneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-milestone", req.NamespacedName)
+
+	ghMilestone := examplev1alpha1.GitHubMilestone{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &ghMilestone); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	deleted, err := withFinalizer(ctx, r.Client, &ghMilestone, func() error {
+		if returnErr := r.removeMilestone(ctx, &ghMilestone); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return r.syncFailed(ctx, &ghMilestone, "GitHubError", returnErr)
+		}
+		return nil
+	})
+	if deleted || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if ghMilestone.Spec.Repo == "" && ghMilestone.Spec.RepositoryRef == nil {
+		return ctrl.Result{}, r.setSynced(ctx, &ghMilestone, "InvalidSpec", "Set the repo or the repositoryRef of the milestone")
+	}
+	repo, ghRepo, returnErr := repoOf(ctx, r.Client, ghMilestone.Namespace, ghMilestone.Spec.Repo, ghMilestone.Spec.RepositoryRef)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghMilestone, "RepositoryError", returnErr)
+	}
+
+	// The milestone of the previous repo is handled as if the object was deleted
+	if ghMilestone.Status.Repo != "" && repoKey(ghMilestone.Status.Repo) != repoKey(repo) {
+		if returnErr := r.removeMilestone(ctx, &ghMilestone); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.syncFailed(ctx, &ghMilestone, "GitHubError", returnErr)
+		}
+		patch := client.MergeFrom(ghMilestone.DeepCopy())
+		ghMilestone.Status = examplev1alpha1.GitHubMilestoneStatus{Conditions: ghMilestone.Status.Conditions}
+		if err := r.Client.Status().Patch(ctx, &ghMilestone, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if ghMilestone.Status.Number == 0 {
+		managedBy, err := r.managedByOther(ctx, &ghMilestone, repo)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if managedBy != "" {
+			return ctrl.Result{}, r.setSynced(ctx, &ghMilestone, ReasonManagedByOther,
+				fmt.Sprintf("Milestone %s of %s is managed by the GitHubMilestone %s", ghMilestone.Spec.Title, repoName(repo), managedBy))
+		}
+	}
+
+	_, _, detailsData := r.ClientFrame.InitDataStructs(repo, "", "")
+	detailsData.Resource = req.NamespacedName.String()
+	if returnErr := credentialsOf(ctx, secretReader(r.APIReader, r.Client), ghMilestone.Namespace, ghMilestone.Spec.CredentialsRef, repo, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghMilestone, "CredentialsError", returnErr)
+	}
+	milestoneData := &clients.Milestone{
+		Title:       ghMilestone.Spec.Title,
+		Description: ghMilestone.Spec.Description,
+		DueOn:       ghMilestone.Spec.DueOn,
+		State:       ghMilestone.Spec.State,
+	}
+	if milestoneData.State == "" {
+		milestoneData.State = "open"
+	}
+	milestone, reason, message, returnErr := r.syncMilestone(&ghMilestone, milestoneData, detailsData)
+	if reason != "" {
+		log.Info(message)
+		return ctrl.Result{}, r.setSynced(ctx, &ghMilestone, reason, message)
+	}
+	if returnErr.StatusCode == http.StatusUnprocessableEntity && milestone != nil && milestone.Title != milestoneData.Title {
+		message := fmt.Sprintf("Milestone #%d can't be renamed to %s, another milestone of %s has that title", milestone.Number, milestoneData.Title, repoName(repo))
+		log.Info(message)
+		return ctrl.Result{}, r.setSynced(ctx, &ghMilestone, ReasonNameTaken, message)
+	}
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		return ctrl.Result{}, r.syncFailed(ctx, &ghMilestone, "GitHubError", returnErr)
+	}
+
+	patch := client.MergeFrom(ghMilestone.DeepCopy())
+	ghMilestone.Status.Repo = repo
+	ghMilestone.Status.Number = milestone.Number
+	ghMilestone.Status.URL = milestone.HTMLURL
+	ghMilestone.Status.OpenIssues = milestone.OpenIssues
+	ghMilestone.Status.ClosedIssues = milestone.ClosedIssues
+	meta.SetStatusCondition(&ghMilestone.Status.Conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            "The milestone matches the spec",
+		ObservedGeneration: ghMilestone.Generation,
+	})
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, &ghMilestone, patch)
+}
+
+// syncMilestone returns the milestone recorded in the status, or the one with the title, created if there is none,
+// edited to match milestoneData. A milestone deleted on GitHub is created again. It returns the reason and message
+// of a conflict with a milestone of the repo it didn't create, if there is one
+func (r *GitHubMilestoneReconciler) syncMilestone(ghMilestone *examplev1alpha1.GitHubMilestone, milestoneData *clients.Milestone, detailsData *clients.Details) (*clients.Milestone, string, string, *clients.Error) {
+	var milestone *clients.Milestone
+	returnErr := &clients.Error{}
+	if ghMilestone.Status.Number != 0 {
+		milestone, returnErr = r.ClientFrame.GetMilestone(ghMilestone.Status.Number, detailsData)
+		if returnErr.StatusCode == http.StatusNotFound {
+			milestone = nil
+		} else if returnErr.ErrorCode != nil {
+			return nil, "", "", returnErr
+		}
+	}
+	if milestone == nil {
+		if milestone, returnErr = r.ClientFrame.FindMilestone(milestoneData.Title, detailsData); returnErr.ErrorCode != nil {
+			return nil, "", "", returnErr
+		}
+		if milestone == nil {
+			milestone, returnErr = r.ClientFrame.CreateMilestone(milestoneData, detailsData)
+			return milestone, "", "", returnErr
+		}
+		if ghMilestone.Status.Number == 0 && !clients.SameMilestone(milestoneData, milestone) && ghMilestone.Spec.ConflictPolicy != MilestoneConflictOverwrite {
+			return nil, ReasonMilestoneExists, fmt.Sprintf("Milestone %s already exists as #%d with description %q, due on %q and %s, set the conflictPolicy to %s to take it over",
+				milestone.Title, milestone.Number, milestone.Description, milestone.DueOn, milestone.State, MilestoneConflictOverwrite), &clients.Error{}
+		}
+	}
+	if clients.SameMilestone(milestoneData, milestone) {
+		return milestone, "", "", &clients.Error{}
+	}
+	return milestone, "", "", r.ClientFrame.EditMilestone(milestoneData, milestone, detailsData)
+}
+
+// removeMilestone deletes the milestone recorded in the status if the deletion policy says so
+func (r *GitHubMilestoneReconciler) removeMilestone(ctx context.Context, ghMilestone *examplev1alpha1.GitHubMilestone) *clients.Error {
+	if ghMilestone.Status.Number == 0 || ghMilestone.Spec.DeletionPolicy != MilestoneDeletionDelete {
+		return &clients.Error{}
+	}
+	_, _, detailsData := r.ClientFrame.InitDataStructs(ghMilestone.Status.Repo, "", "")
+	detailsData.Resource = types.NamespacedName{Namespace: ghMilestone.Namespace, Name: ghMilestone.Name}.String()
+	var ghRepo *examplev1alpha1.GitHubRepository
+	if ghMilestone.Spec.RepositoryRef != nil {
+		// The token of the operator is tried if the repository is gone already
+		ghRepo, _ = repositoryOf(ctx, r.Client, ghMilestone.Namespace, ghMilestone.Spec.RepositoryRef)
+	}
+	if returnErr := credentialsOf(ctx, secretReader(r.APIReader, r.Client), ghMilestone.Namespace, ghMilestone.Spec.CredentialsRef, ghMilestone.Status.Repo, ghRepo, detailsData); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	return r.ClientFrame.DeleteMilestone(&clients.Milestone{Number: ghMilestone.Status.Number, Title: ghMilestone.Spec.Title}, detailsData)
+}
+
+// managedByOther returns the namespace/name of another GitHubMilestone managing a milestone of the repo with the
+// title, the first to create or take over a milestone keeps it
+func (r *GitHubMilestoneReconciler) managedByOther(ctx context.Context, ghMilestone *examplev1alpha1.GitHubMilestone, repo string) (string, error) {
+	return managedByOther(ctx, r.Client, &examplev1alpha1.GitHubMilestoneList{}, managedMilestoneIndex, indexManagedMilestone, ghMilestone, milestoneKey(repo, ghMilestone.Spec.Title))
+}
+
+// setSynced reports why the milestone isn't synced, it is reconciled again when its spec or the conflicting
+// milestone change
+func (r *GitHubMilestoneReconciler) setSynced(ctx context.Context, ghMilestone *examplev1alpha1.GitHubMilestone, reason, message string) error {
+	return setSyncedCondition(ctx, r.Client, ghMilestone, &ghMilestone.Status.Conditions, metav1.ConditionFalse, reason, message)
+}
+
+// syncFailed reports the error in the Synced condition, and returns it for a retry
+func (r *GitHubMilestoneReconciler) syncFailed(ctx context.Context, ghMilestone *examplev1alpha1.GitHubMilestone, reason string, returnErr *clients.Error) error {
+	return syncFailedCondition(ctx, r.Client, r.Log, ghMilestone, &ghMilestone.Status.Conditions, reason, returnErr)
+}
+
+// milestoneKey returns the key of the milestone of the repo with the title, equal for all the references to the repo
+func milestoneKey(repo, title string) string {
+	return repoKey(repo) + "/" + title
+}
+
+// indexManagedMilestone returns the milestoneKey of the milestone a GitHubMilestone manages, for
+// managedMilestoneIndex
+func indexManagedMilestone(obj client.Object) []string {
+	ghMilestone := obj.(*examplev1alpha1.GitHubMilestone)
+	if ghMilestone.Status.Number == 0 {
+		return nil
+	}
+	return []string{milestoneKey(ghMilestone.Status.Repo, ghMilestone.Spec.Title)}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubMilestoneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubMilestone{}, repositoryRefIndex, indexRepositoryRef); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &examplev1alpha1.GitHubMilestone{}, managedMilestoneIndex, indexManagedMilestone); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubMilestone{}).
+		Watches(&source.Kind{Type: &examplev1alpha1.GitHubRepository{}}, handler.EnqueueRequestsFromMapFunc(objectsOfRepository(r.Client, r.Log, &examplev1alpha1.GitHubMilestoneList{}))).
+		Complete(r)
+}
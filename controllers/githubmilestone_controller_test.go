@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"testing"
+)
+
+func TestMilestoneCreateAndEdit(t *testing.T) {
+	// Given a GitHubMilestone of a repo without milestones
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	r, k8sClient := newTestMilestoneReconciler(fakeClient, newTestGitHubRepository(), newTestGitHubMilestone())
+
+	// When reconciling, then the milestone is created in the repo of the repository
+	if _, err := r.Reconcile(context.Background(), testMilestoneRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	milestones := fakeClient.Milestones()
+	if len(milestones) != 1 || milestones[0].Title != "v1.0" || milestones[0].DueOn != "2026-12-01" || milestones[0].State != "open" {
+		t.Fatalf("Expected the v1.0 milestone but got %+v", milestones)
+	}
+	got := examplev1alpha1.GitHubMilestone{}
+	getTestObject(t, k8sClient, testMilestoneRequest.NamespacedName, &got)
+	if got.Status.Number != milestones[0].Number || got.Status.Repo != "arielireni/Issues-Example" || !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionSynced) {
+		t.Errorf("Expected the milestone to be synced but got %+v", got.Status)
+	}
+
+	// When closing and renaming it, then the milestone is edited rather than created again
+	got.Spec.Title = "v1.0.0"
+	got.Spec.State = "closed"
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testMilestoneRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if milestones = fakeClient.Milestones(); len(milestones) != 1 || milestones[0].Title != "v1.0.0" || milestones[0].State != "closed" {
+		t.Errorf("Expected the milestone to be edited but got %+v", milestones)
+	}
+	if calls := fakeClient.CallsTo("CreateMilestone"); calls != 1 {
+		t.Errorf("Expected a single create but got %d", calls)
+	}
+}
+
+func TestMilestoneConflict(t *testing.T) {
+	// Given a v1.0 milestone created by hand without a description
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	fakeClient.CreateMilestone(&clients.Milestone{Title: "other"}, detailsData)
+	existing, _ := fakeClient.CreateMilestone(&clients.Milestone{Title: "v1.0"}, detailsData)
+	r, k8sClient := newTestMilestoneReconciler(fakeClient, newTestGitHubRepository(), newTestGitHubMilestone())
+
+	// When reconciling, then the conflict is reported and the milestone is left alone
+	if _, err := r.Reconcile(context.Background(), testMilestoneRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubMilestone{}
+	getTestObject(t, k8sClient, testMilestoneRequest.NamespacedName, &got)
+	condition := meta.FindStatusCondition(got.Status.Conditions, ConditionSynced)
+	if condition == nil || condition.Reason != ReasonMilestoneExists || got.Status.Number != 0 {
+		t.Errorf("Expected a MilestoneExists conflict but got %+v", got.Status)
+	}
+	if milestones := fakeClient.Milestones(); milestones[1].Description != "" || fakeClient.CallsTo("CreateMilestone") != 2 {
+		t.Errorf("Expected the milestone to be kept but got %+v", milestones)
+	}
+
+	// When overwriting it, then the milestone is taken over and edited to match the spec
+	got.Spec.ConflictPolicy = MilestoneConflictOverwrite
+	if err := k8sClient.Update(context.Background(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(context.Background(), testMilestoneRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got = examplev1alpha1.GitHubMilestone{}
+	getTestObject(t, k8sClient, testMilestoneRequest.NamespacedName, &got)
+	if got.Status.Number != existing.Number {
+		t.Errorf("Expected milestone #%d but got %+v", existing.Number, got.Status)
+	}
+	if milestones := fakeClient.Milestones(); len(milestones) != 2 || milestones[1].Description != "The first release" {
+		t.Errorf("Expected the milestone to be edited but got %+v", milestones)
+	}
+
+	// When another GitHubMilestone of the same milestone is reconciled, then it is managed by the first one only
+	other := newTestGitHubMilestone()
+	other.Namespace, other.UID = "default2", "other"
+	other.Spec.Description = "Another release"
+	if err := k8sClient.Create(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	otherKey := types.NamespacedName{Namespace: "default2", Name: "v1-0"}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: otherKey}); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got = examplev1alpha1.GitHubMilestone{}
+	getTestObject(t, k8sClient, otherKey, &got)
+	condition = meta.FindStatusCondition(got.Status.Conditions, ConditionSynced)
+	if condition == nil || condition.Reason != ReasonManagedByOther || got.Status.Number != 0 {
+		t.Errorf("Expected a ManagedByOther conflict but got %+v", got.Status)
+	}
+	if milestones := fakeClient.Milestones(); milestones[1].Description != "The first release" {
+		t.Errorf("Expected the milestone to be kept but got %+v", milestones)
+	}
+}
+
+func TestMilestoneDeletionPolicy(t *testing.T) {
+	// Given a deleted GitHubMilestone whose milestone is deleted with it
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	_, _, detailsData := fakeClient.InitDataStructs("arielireni/Issues-Example", "", "")
+	milestone, _ := fakeClient.CreateMilestone(&clients.Milestone{Title: "v1.0"}, detailsData)
+	now := metav1.Now()
+	ghMilestone := newTestGitHubMilestone()
+	ghMilestone.DeletionTimestamp = &now
+	ghMilestone.Finalizers = []string{finalizerName}
+	ghMilestone.Spec.DeletionPolicy = MilestoneDeletionDelete
+	ghMilestone.Status = examplev1alpha1.GitHubMilestoneStatus{Repo: "arielireni/Issues-Example", Number: milestone.Number}
+	r, _ := newTestMilestoneReconciler(fakeClient, newTestGitHubRepository(), ghMilestone)
+
+	// When reconciling, then the milestone is deleted
+	if _, err := r.Reconcile(context.Background(), testMilestoneRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if milestones := fakeClient.Milestones(); len(milestones) != 0 {
+		t.Errorf("Expected the milestone to be deleted but got %+v", milestones)
+	}
+}
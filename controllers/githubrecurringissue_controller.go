@@ -0,0 +1,353 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// RecurringIssueLabel is set on the occurrences of a GitHubRecurringIssue to the name of the recurring issue
+const RecurringIssueLabel = "example.training.redhat.com/recurring-issue"
+
+// ScheduledTimeAnnotation holds the scheduled time of an occurrence, in RFC3339
+const ScheduledTimeAnnotation = "example.training.redhat.com/scheduled-at"
+
+// ConditionScheduled is true when the schedule of a GitHubRecurringIssue is valid and followed
+const ConditionScheduled = "Scheduled"
+
+// defaultClosedHistoryLimit is the number of closed occurrences kept when the limit is unset
+const defaultClosedHistoryLimit = 3
+
+// GitHubRecurringIssueReconciler reconciles a GitHubRecurringIssue object
+type GitHubRecurringIssueReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrecurringissues,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrecurringissues/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrecurringissues/finalizers,verbs=update
+
+// Reconcile creates the occurrences of a GitHubRecurringIssue on its schedule, the way a CronJob creates Jobs,
+// and requeues itself for the next one
+func (r *GitHubRecurringIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-recurring-issue", req.NamespacedName)
+
+	recurring := examplev1alpha1.GitHubRecurringIssue{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &recurring); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !recurring.DeletionTimestamp.IsZero() {
+		// The occurrences are garbage collected
+		return ctrl.Result{}, nil
+	}
+	patch := client.MergeFrom(recurring.DeepCopy())
+
+	sched, err := cron.ParseStandard(recurring.Spec.Schedule)
+	if err != nil {
+		// Retrying won't help until the spec changes
+		log.Info("invalid schedule", "schedule", recurring.Spec.Schedule, "error", err.Error())
+		meta.SetStatusCondition(&recurring.Status.Conditions, metav1.Condition{
+			Type:               ConditionScheduled,
+			Status:             metav1.ConditionFalse,
+			Reason:             "InvalidSchedule",
+			Message:            err.Error(),
+			ObservedGeneration: recurring.Generation,
+		})
+		return ctrl.Result{}, r.Client.Status().Patch(ctx, &recurring, patch)
+	}
+	now := r.now()
+	if err := distinctTitles(&recurring, sched, now); err != nil {
+		// Retrying won't help until the spec changes
+		log.Info("invalid template", "title", recurring.Spec.Template.Title, "error", err.Error())
+		meta.SetStatusCondition(&recurring.Status.Conditions, metav1.Condition{
+			Type:               ConditionScheduled,
+			Status:             metav1.ConditionFalse,
+			Reason:             "TemplateError",
+			Message:            err.Error(),
+			ObservedGeneration: recurring.Generation,
+		})
+		return ctrl.Result{}, r.Client.Status().Patch(ctx, &recurring, patch)
+	}
+
+	occurrences, err := r.occurrences(ctx, &recurring)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	for _, occurrence := range occurrences {
+		scheduled := scheduledTime(&occurrence)
+		if recurring.Status.LastScheduleTime == nil || scheduled.After(recurring.Status.LastScheduleTime.Time) {
+			recurring.Status.LastScheduleTime = &metav1.Time{Time: scheduled}
+		}
+	}
+
+	// Create the latest missed occurrence, if it isn't too late for it
+	missed, next := nextSchedule(&recurring, sched, now)
+	if !missed.IsZero() && !recurring.Spec.Suspend {
+		occurrence, err := renderOccurrence(&recurring, missed)
+		if err != nil {
+			log.Info("failed to render the template", "error", err.Error())
+			meta.SetStatusCondition(&recurring.Status.Conditions, metav1.Condition{
+				Type:               ConditionScheduled,
+				Status:             metav1.ConditionFalse,
+				Reason:             "TemplateError",
+				Message:            err.Error(),
+				ObservedGeneration: recurring.Generation,
+			})
+			return ctrl.Result{}, r.Client.Status().Patch(ctx, &recurring, patch)
+		}
+		if err := controllerutil.SetControllerReference(&recurring, occurrence, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Client.Create(ctx, occurrence); err != nil && !errors.IsAlreadyExists(err) {
+			return ctrl.Result{}, err
+		}
+		log.Info("created occurrence", "gh-issue", occurrence.Name, "scheduled-at", missed)
+
+		if recurring.Spec.ClosePrevious {
+			for i := range occurrences {
+				if err := r.closeOccurrence(ctx, &occurrences[i]); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+		}
+		occurrences = append(occurrences, *occurrence)
+		recurring.Status.LastScheduleTime = &metav1.Time{Time: missed}
+	}
+
+	if err := r.pruneHistory(ctx, &recurring, occurrences); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	recurring.Status.Active = nil
+	for _, occurrence := range occurrences {
+		if occurrence.DeletionTimestamp.IsZero() && !occurrenceClosed(&occurrence) {
+			recurring.Status.Active = append(recurring.Status.Active, occurrence.Name)
+		}
+	}
+	recurring.Status.NextScheduleTime = &metav1.Time{Time: next}
+	meta.SetStatusCondition(&recurring.Status.Conditions, metav1.Condition{
+		Type:               ConditionScheduled,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Scheduled",
+		Message:            "Next occurrence at " + next.Format(time.RFC3339),
+		ObservedGeneration: recurring.Generation,
+	})
+	if err := r.Client.Status().Patch(ctx, &recurring, patch); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubRecurringIssueReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubRecurringIssue{}).
+		Owns(&examplev1alpha1.GitHubIssue{}).
+		Complete(r)
+}
+
+func (r *GitHubRecurringIssueReconciler) now() time.Time {
+	if r.Now == nil {
+		return time.Now()
+	}
+	return r.Now()
+}
+
+// occurrences returns the GitHubIssue objects owned by the recurring issue, oldest first
+func (r *GitHubRecurringIssueReconciler) occurrences(ctx context.Context, recurring *examplev1alpha1.GitHubRecurringIssue) ([]examplev1alpha1.GitHubIssue, error) {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	err := r.Client.List(ctx, &ghIssues, client.InNamespace(recurring.Namespace), client.MatchingLabels{RecurringIssueLabel: recurring.Name})
+	if err != nil {
+		return nil, err
+	}
+	var occurrences []examplev1alpha1.GitHubIssue
+	for _, ghIssue := range ghIssues.Items {
+		if metav1.IsControlledBy(&ghIssue, recurring) {
+			occurrences = append(occurrences, ghIssue)
+		}
+	}
+	sortOccurrences(occurrences)
+	return occurrences, nil
+}
+
+// closeOccurrence sets the desired state of an open occurrence to closed
+func (r *GitHubRecurringIssueReconciler) closeOccurrence(ctx context.Context, occurrence *examplev1alpha1.GitHubIssue) error {
+	if occurrence.Spec.State == "closed" || !occurrence.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	patch := client.MergeFrom(occurrence.DeepCopy())
+	occurrence.Spec.State = "closed"
+	return r.Client.Patch(ctx, occurrence, patch)
+}
+
+// pruneHistory deletes the oldest occurrences beyond the history limits
+func (r *GitHubRecurringIssueReconciler) pruneHistory(ctx context.Context, recurring *examplev1alpha1.GitHubRecurringIssue, occurrences []examplev1alpha1.GitHubIssue) error {
+	closedLimit := int32(defaultClosedHistoryLimit)
+	if recurring.Spec.ClosedHistoryLimit != nil {
+		closedLimit = *recurring.Spec.ClosedHistoryLimit
+	}
+	var closed, open []*examplev1alpha1.GitHubIssue
+	for i := range occurrences {
+		if !occurrences[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		if occurrenceClosed(&occurrences[i]) {
+			closed = append(closed, &occurrences[i])
+		} else {
+			open = append(open, &occurrences[i])
+		}
+	}
+	var prune []*examplev1alpha1.GitHubIssue
+	if len(closed) > int(closedLimit) {
+		prune = append(prune, closed[:len(closed)-int(closedLimit)]...)
+	}
+	if limit := recurring.Spec.OpenHistoryLimit; limit != nil && len(open) > int(*limit) {
+		prune = append(prune, open[:len(open)-int(*limit)]...)
+	}
+	for _, occurrence := range prune {
+		if err := r.Client.Delete(ctx, occurrence); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		// Deleted occurrences are left out of the active list
+		now := metav1.Now()
+		occurrence.DeletionTimestamp = &now
+	}
+	return nil
+}
+
+// nextSchedule returns the latest scheduled time that was missed, zero if there is none,
+// and the next scheduled time after now
+func nextSchedule(recurring *examplev1alpha1.GitHubRecurringIssue, sched cron.Schedule, now time.Time) (time.Time, time.Time) {
+	earliest := recurring.CreationTimestamp.Time
+	if recurring.Status.LastScheduleTime != nil {
+		earliest = recurring.Status.LastScheduleTime.Time
+	}
+	if deadline := recurring.Spec.StartingDeadlineSeconds; deadline != nil {
+		if tooLate := now.Add(-time.Duration(*deadline) * time.Second); tooLate.After(earliest) {
+			earliest = tooLate
+		}
+	}
+	var missed time.Time
+	for t := sched.Next(earliest); !t.After(now); t = sched.Next(t) {
+		missed = t
+	}
+	return missed, sched.Next(now)
+}
+
+// renderOccurrence returns the GitHubIssue of the occurrence scheduled at the given time
+func renderOccurrence(recurring *examplev1alpha1.GitHubRecurringIssue, scheduled time.Time) (*examplev1alpha1.GitHubIssue, error) {
+	tmpl := recurring.Spec.Template
+	data := struct {
+		Date string
+		Time time.Time
+	}{scheduled.Format("2006-01-02"), scheduled}
+
+	occurrence := &examplev1alpha1.GitHubIssue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", recurring.Name, scheduled.Unix()/60),
+			Namespace:   recurring.Namespace,
+			Labels:      map[string]string{RecurringIssueLabel: recurring.Name},
+			Annotations: map[string]string{ScheduledTimeAnnotation: scheduled.Format(time.RFC3339)},
+		},
+		Spec: examplev1alpha1.GitHubIssueSpec{Repo: tmpl.Repo},
+	}
+	for key, value := range tmpl.Labels {
+		occurrence.Labels[key] = value
+	}
+	fields := []struct {
+		name  string
+		text  string
+		value *string
+	}{
+		{"title", tmpl.Title, &occurrence.Spec.Title},
+		{"description", tmpl.Description, &occurrence.Spec.Description},
+	}
+	for _, field := range fields {
+		t, err := template.New(field.name).Parse(field.text)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %v", field.name, err)
+		}
+		out := &bytes.Buffer{}
+		if err := t.Execute(out, data); err != nil {
+			return nil, fmt.Errorf("template %s: %v", field.name, err)
+		}
+		*field.value = out.String()
+	}
+	return occurrence, nil
+}
+
+// distinctTitles returns an error if the title template renders the same title for the next two occurrences. The
+// real issues are found by their title, so the second occurrence would find the issue of the first
+func distinctTitles(recurring *examplev1alpha1.GitHubRecurringIssue, sched cron.Schedule, now time.Time) error {
+	first := sched.Next(now)
+	second := sched.Next(first)
+	firstOccurrence, err := renderOccurrence(recurring, first)
+	if err != nil {
+		return err
+	}
+	secondOccurrence, err := renderOccurrence(recurring, second)
+	if err != nil {
+		return err
+	}
+	if firstOccurrence.Spec.Title == secondOccurrence.Spec.Title {
+		return fmt.Errorf("template title: the occurrences of %s and %s are both titled %q, use .Date or .Time to tell them apart",
+			first.Format(time.RFC3339), second.Format(time.RFC3339), firstOccurrence.Spec.Title)
+	}
+	return nil
+}
+
+// scheduledTime returns the scheduled time of an occurrence, its creation time if it isn't annotated
+func scheduledTime(occurrence *examplev1alpha1.GitHubIssue) time.Time {
+	scheduled, err := time.Parse(time.RFC3339, occurrence.Annotations[ScheduledTimeAnnotation])
+	if err != nil {
+		return occurrence.CreationTimestamp.Time
+	}
+	return scheduled
+}
+
+func sortOccurrences(occurrences []examplev1alpha1.GitHubIssue) {
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return scheduledTime(&occurrences[i]).Before(scheduledTime(&occurrences[j]))
+	})
+}
+
+// occurrenceClosed returns true if the real issue of an occurrence is closed
+func occurrenceClosed(occurrence *examplev1alpha1.GitHubIssue) bool {
+	return occurrence.Status.State == "closed"
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+var testRecurringRequest = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "weekly"}}
+
+// testMonday is a Monday at 09:00 UTC, when the weekly test schedule fires
+var testMonday = time.Date(2021, time.June, 7, 9, 0, 0, 0, time.UTC)
+
+func newTestGitHubRecurringIssue() *examplev1alpha1.GitHubRecurringIssue {
+	return &examplev1alpha1.GitHubRecurringIssue{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "weekly",
+			Namespace:         "default",
+			UID:               "weekly-uid",
+			CreationTimestamp: metav1.Time{Time: testMonday.Add(-time.Hour)},
+		},
+		Spec: examplev1alpha1.GitHubRecurringIssueSpec{
+			Schedule: "0 9 * * 1",
+			Template: examplev1alpha1.GitHubRecurringIssueTemplate{
+				Repo:  "arielireni/Issues-Example",
+				Title: "Dependency review {{ .Date }}",
+			},
+		},
+	}
+}
+
+func newTestRecurringReconciler(now *time.Time, objects ...runtime.Object) (*GitHubRecurringIssueReconciler, client.Client) {
+	s := scheme.Scheme
+	examplev1alpha1.AddToScheme(s)
+	fakeK8sClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+	return &GitHubRecurringIssueReconciler{
+		Client: fakeK8sClient,
+		Log:    ctrl.Log,
+		Scheme: s,
+		Now:    func() time.Time { return *now },
+	}, fakeK8sClient
+}
+
+func listOccurrences(t *testing.T, k8sClient client.Client) []examplev1alpha1.GitHubIssue {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	if err := k8sClient.List(context.Background(), &ghIssues, client.MatchingLabels{RecurringIssueLabel: "weekly"}); err != nil {
+		t.Fatal(err)
+	}
+	sortOccurrences(ghIssues.Items)
+	return ghIssues.Items
+}
+
+func TestRecurringIssueSchedule(t *testing.T) {
+	// Given a weekly recurring issue created an hour before its first occurrence
+	now := testMonday.Add(-time.Minute)
+	r, k8sClient := newTestRecurringReconciler(&now, newTestGitHubRecurringIssue())
+
+	// Before the scheduled time nothing is created, and the reconciler waits for it
+	result, err := r.Reconcile(context.Background(), testRecurringRequest)
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if occurrences := listOccurrences(t, k8sClient); len(occurrences) != 0 {
+		t.Errorf("Expected no occurrences but got %d", len(occurrences))
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("Expected to requeue after a minute but got %v", result.RequeueAfter)
+	}
+
+	// At the scheduled time the occurrence is created with the date in its title
+	now = testMonday.Add(time.Second)
+	result, err = r.Reconcile(context.Background(), testRecurringRequest)
+	if err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	occurrences := listOccurrences(t, k8sClient)
+	if len(occurrences) != 1 || occurrences[0].Spec.Title != "Dependency review 2021-06-07" {
+		t.Fatalf("Expected a single occurrence for 2021-06-07 but got %v", occurrences)
+	}
+	if result.RequeueAfter != 7*24*time.Hour-time.Second {
+		t.Errorf("Expected to requeue for the next week but got %v", result.RequeueAfter)
+	}
+
+	// Reconciling again doesn't create the same occurrence twice
+	if _, err := r.Reconcile(context.Background(), testRecurringRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	if occurrences := listOccurrences(t, k8sClient); len(occurrences) != 1 {
+		t.Errorf("Expected a single occurrence but got %d", len(occurrences))
+	}
+	got := examplev1alpha1.GitHubRecurringIssue{}
+	k8sClient.Get(context.Background(), testRecurringRequest.NamespacedName, &got)
+	if got.Status.LastScheduleTime == nil || !got.Status.LastScheduleTime.Time.Equal(testMonday) || len(got.Status.Active) != 1 {
+		t.Errorf("Unexpected status: %+v", got.Status)
+	}
+}
+
+func TestRecurringIssueClosesPreviousAndPrunes(t *testing.T) {
+	recurring := newTestGitHubRecurringIssue()
+	recurring.Spec.ClosePrevious = true
+	limit := int32(1)
+	recurring.Spec.ClosedHistoryLimit = &limit
+	now := testMonday
+	r, k8sClient := newTestRecurringReconciler(&now, recurring)
+
+	for week := 0; week < 3; week++ {
+		now = testMonday.Add(time.Duration(week) * 7 * 24 * time.Hour)
+		if _, err := r.Reconcile(context.Background(), testRecurringRequest); err != nil {
+			t.Fatalf("Expected nil but got error: %v", err)
+		}
+		// The GitHubIssue reconciler reports the closed occurrences
+		for _, occurrence := range listOccurrences(t, k8sClient) {
+			if occurrence.Spec.State == "closed" && occurrence.Status.State != "closed" {
+				occurrence.Status.State = "closed"
+				if err := k8sClient.Status().Update(context.Background(), &occurrence); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	// The status change of an occurrence triggers a reconciliation, which prunes the history
+	if _, err := r.Reconcile(context.Background(), testRecurringRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// The first week was pruned, the second is closed and the third is open
+	occurrences := listOccurrences(t, k8sClient)
+	if len(occurrences) != 2 {
+		t.Fatalf("Expected 2 occurrences but got %d", len(occurrences))
+	}
+	if occurrences[0].Spec.Title != "Dependency review 2021-06-14" || occurrences[0].Spec.State != "closed" {
+		t.Errorf("Expected the closed occurrence of 2021-06-14 but got %+v", occurrences[0].Spec)
+	}
+	if occurrences[1].Spec.Title != "Dependency review 2021-06-21" || occurrences[1].Spec.State != "" {
+		t.Errorf("Expected the open occurrence of 2021-06-21 but got %+v", occurrences[1].Spec)
+	}
+}
+
+func TestRecurringIssueTitleWithoutDate(t *testing.T) {
+	// Given a daily recurring issue whose title only has the month, the same for most of its occurrences
+	recurring := newTestGitHubRecurringIssue()
+	recurring.Spec.Schedule = "0 9 * * *"
+	recurring.Spec.Template.Title = `Standup {{ .Time.Format "January" }}`
+	now := testMonday.Add(time.Second)
+	r, k8sClient := newTestRecurringReconciler(&now, recurring)
+
+	// When reconciling it at a scheduled time
+	if _, err := r.Reconcile(context.Background(), testRecurringRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the template is rejected and no occurrence is created
+	if occurrences := listOccurrences(t, k8sClient); len(occurrences) != 0 {
+		t.Errorf("Expected no occurrences but got %d", len(occurrences))
+	}
+	got := examplev1alpha1.GitHubRecurringIssue{}
+	k8sClient.Get(context.Background(), testRecurringRequest.NamespacedName, &got)
+	if scheduled := meta.FindStatusCondition(got.Status.Conditions, ConditionScheduled); scheduled == nil || scheduled.Status != metav1.ConditionFalse || scheduled.Reason != "TemplateError" {
+		t.Errorf("Expected the template to be rejected but got %+v", got.Status.Conditions)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"net/http"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConditionReady is true while the token of a GitHubRepository can reach its repo and the repo has issues enabled
+const ConditionReady = "Ready"
+
+// GitHubRepositoryReconciler reconciles a GitHubRepository object
+type GitHubRepositoryReconciler struct {
+	client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	ClientFrame clients.ClientFrame
+	// APIReader reads the credentials Secrets, the Client if nil
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrepositories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrepositories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=example.training.redhat.com,resources=githubrepositories/finalizers,verbs=update
+
+// Reconcile checks the access of the token to the repo, and reports its permission and open issues. It's checked
+// again every sync period, as the token or its permission may change on GitHub
+func (r *GitHubRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name-of-gh-repository", req.NamespacedName)
+
+	ghRepo := examplev1alpha1.GitHubRepository{}
+	if err := r.Client.Get(ctx, req.NamespacedName, &ghRepo); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	_, _, detailsData := r.ClientFrame.InitDataStructs(repositoryRepo(&ghRepo), "", "")
+	detailsData.Resource = req.NamespacedName.String()
+	if ghRepo.Spec.CredentialsRef != nil {
+		if returnErr := readToken(ctx, secretReader(r.APIReader, r.Client), ghRepo.Namespace, ghRepo.Spec.CredentialsRef, detailsData); returnErr.ErrorCode != nil {
+			log.Info(returnErr.Message)
+			return ctrl.Result{}, r.notReady(ctx, &ghRepo, "CredentialsError", returnErr)
+		}
+	}
+
+	repository, returnErr := r.ClientFrame.GetRepository(detailsData)
+	if returnErr.ErrorCode != nil {
+		log.Info(returnErr.Message)
+		switch returnErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			// Retrying won't help until the token or its access changes, the repo is checked again every sync period
+			r.notReady(ctx, &ghRepo, "AccessDenied", returnErr)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.notReady(ctx, &ghRepo, "GitHubError", returnErr)
+	}
+
+	patch := client.MergeFrom(ghRepo.DeepCopy())
+	ghRepo.Status.FullName = repository.FullName
+	ghRepo.Status.Permission = repository.Permission
+	ghRepo.Status.OpenIssues = repository.OpenIssues
+	condition := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Accessible",
+		Message:            "The token has " + repository.Permission + " access to " + repository.FullName,
+		ObservedGeneration: ghRepo.Generation,
+	}
+	if !repository.HasIssues {
+		condition.Status, condition.Reason, condition.Message = metav1.ConditionFalse, "IssuesDisabled", "The issues of "+repository.FullName+" are disabled"
+	}
+	meta.SetStatusCondition(&ghRepo.Status.Conditions, condition)
+	return ctrl.Result{}, r.Client.Status().Patch(ctx, &ghRepo, patch)
+}
+
+// notReady reports why the repo can't be used in the Ready condition, and returns the error for a retry
+func (r *GitHubRepositoryReconciler) notReady(ctx context.Context, ghRepo *examplev1alpha1.GitHubRepository, reason string, returnErr *clients.Error) error {
+	message := returnErr.Message
+	if len(message) > maxConditionMessage {
+		message = message[:maxConditionMessage]
+	}
+	patch := client.MergeFrom(ghRepo.DeepCopy())
+	meta.SetStatusCondition(&ghRepo.Status.Conditions, metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: ghRepo.Generation,
+	})
+	if err := r.Client.Status().Patch(ctx, ghRepo, patch); err != nil {
+		r.Log.Info("failed to report the error in the status", "error", err.Error())
+	}
+	return returnErr.ErrorCode
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitHubRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&examplev1alpha1.GitHubRepository{}).
+		Complete(r)
+}
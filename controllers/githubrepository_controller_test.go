@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"testing"
+)
+
+func TestRepositoryStatus(t *testing.T) {
+	// Given a repo with an open and a closed issue, on which the token can triage
+	fakeClient := clients.NewFakeClient([]clients.Issue{{Title: "open", State: "open"}, {Title: "closed", State: "closed"}}, true, nil)
+	fakeClient.Repository = &clients.Repository{HasIssues: true, Permission: "triage"}
+	r, k8sClient := newTestRepositoryReconciler(fakeClient, newTestGitHubRepository())
+
+	// When reconciling
+	if _, err := r.Reconcile(context.Background(), testRepositoryRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+
+	// Then the status reports the permission and the open issues, and the repository is ready
+	got := examplev1alpha1.GitHubRepository{}
+	getTestObject(t, k8sClient, testRepositoryRequest.NamespacedName, &got)
+	if got.Status.Permission != "triage" || got.Status.OpenIssues != 1 || got.Status.FullName != "arielireni/issues-example" {
+		t.Errorf("Expected triage on a repo with 1 open issue but got %+v", got.Status)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionReady) {
+		t.Errorf("Expected Ready to be true but got %v", got.Status.Conditions)
+	}
+}
+
+func TestRepositoryNotReady(t *testing.T) {
+	// Given a repo whose issues are disabled
+	fakeClient := clients.NewFakeClient(nil, true, nil)
+	fakeClient.Repository = &clients.Repository{Permission: "admin"}
+	r, k8sClient := newTestRepositoryReconciler(fakeClient, newTestGitHubRepository())
+	if _, err := r.Reconcile(context.Background(), testRepositoryRequest); err != nil {
+		t.Fatalf("Expected nil but got error: %v", err)
+	}
+	got := examplev1alpha1.GitHubRepository{}
+	getTestObject(t, k8sClient, testRepositoryRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionReady); condition == nil || condition.Reason != "IssuesDisabled" {
+		t.Errorf("Expected the repository not to be ready as its issues are disabled but got %v", condition)
+	}
+
+	// And when GitHub fails, the error is reported and retried
+	fakeClient.FailOn("GetRepository", errors.New("boom"))
+	if _, err := r.Reconcile(context.Background(), testRepositoryRequest); err == nil {
+		t.Errorf("Expected the error to be returned for a retry")
+	}
+	got = examplev1alpha1.GitHubRepository{}
+	getTestObject(t, k8sClient, testRepositoryRequest.NamespacedName, &got)
+	if condition := meta.FindStatusCondition(got.Status.Conditions, ConditionReady); condition == nil || condition.Reason != "GitHubError" {
+		t.Errorf("Expected the GitHub error in the Ready condition but got %v", condition)
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// The helpers of the controllers of objects managing a single thing of a repo, such as a label or a milestone
+
+// setSyncedCondition sets the Synced condition in the conditions of the status of obj, and patches the status
+func setSyncedCondition(ctx context.Context, c client.Client, obj client.Object, conditions *[]metav1.Condition, status metav1.ConditionStatus, reason, message string) error {
+	if len(message) > maxConditionMessage {
+		message = message[:maxConditionMessage]
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: obj.GetGeneration(),
+	})
+	return c.Status().Patch(ctx, obj, patch)
+}
+
+// syncFailedCondition reports the error in the Synced condition of obj, and returns it for a retry. A mutation
+// refused in dry-run mode is only reported, the object is planned again at the next resync
+func syncFailedCondition(ctx context.Context, c client.Client, log logr.Logger, obj client.Object, conditions *[]metav1.Condition, reason string, returnErr *clients.Error) error {
+	if clients.IsDryRun(returnErr) {
+		return setSyncedCondition(ctx, c, obj, conditions, metav1.ConditionFalse, "DryRun", returnErr.Message)
+	}
+	if err := setSyncedCondition(ctx, c, obj, conditions, metav1.ConditionFalse, reason, returnErr.Message); err != nil {
+		log.Info("failed to report the sync error in the status", "error", err.Error())
+	}
+	return returnErr.ErrorCode
+}
+
+// withFinalizer adds the finalizer to a live object. The finalizer of an object being deleted is removed once
+// remove succeeds. It returns true if the object is being deleted, which ends its reconciliation
+func withFinalizer(ctx context.Context, c client.Client, obj client.Object, remove func() error) (bool, error) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		if !containsString(obj.GetFinalizers(), finalizerName) {
+			return true, nil
+		}
+		if err := remove(); err != nil {
+			return true, err
+		}
+		controllerutil.RemoveFinalizer(obj, finalizerName)
+		return true, c.Update(ctx, obj)
+	}
+	if !containsString(obj.GetFinalizers(), finalizerName) {
+		controllerutil.AddFinalizer(obj, finalizerName)
+		if err := c.Update(ctx, obj); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// managedByOther returns the namespace/name of another object of list managing the thing of the key of index, the
+// first to create or take over a thing keeps it. The objects listed are checked again with indexer
+func managedByOther(ctx context.Context, c client.Client, list client.ObjectList, index string, indexer client.IndexerFunc, obj client.Object, key string) (string, error) {
+	if err := c.List(ctx, list, client.MatchingFields{index: key}); err != nil {
+		return "", err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items {
+		other := item.(client.Object)
+		if other.GetUID() == obj.GetUID() || !other.GetDeletionTimestamp().IsZero() || !containsString(indexer(other), key) {
+			continue
+		}
+		return types.NamespacedName{Namespace: other.GetNamespace(), Name: other.GetName()}.String(), nil
+	}
+	return "", nil
+}
+
+// requestsOf returns the requests of the objects of list, but except
+func requestsOf(list client.ObjectList, except client.Object) []reconcile.Request {
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, item := range items {
+		obj := item.(client.Object)
+		if obj.GetUID() != except.GetUID() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}})
+		}
+	}
+	return requests
+}
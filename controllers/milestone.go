@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// milestoneRefIndex indexes GitHubIssue objects by the namespace/name of their GitHubMilestone
+const milestoneRefIndex = ".spec.milestoneRef"
+
+// milestoneNumber returns the number of the milestone of the GitHubMilestone of a GitHubIssue, or why the milestone
+// of the issue has to wait: until its milestone is created, in the repo of the issue
+func milestoneNumber(ctx context.Context, c client.Reader, ghIssue *examplev1alpha1.GitHubIssue) (int, string, error) {
+	key := types.NamespacedName{Namespace: ghIssue.Namespace, Name: ghIssue.Spec.MilestoneRef.Name}
+	ghMilestone := examplev1alpha1.GitHubMilestone{}
+	if err := c.Get(ctx, key, &ghMilestone); err != nil {
+		if errors.IsNotFound(err) {
+			return 0, "The GitHubMilestone " + key.Name + " doesn't exist", nil
+		}
+		return 0, "", err
+	}
+	if ghMilestone.Status.Number == 0 {
+		return 0, "The milestone of " + key.Name + " isn't created yet", nil
+	}
+	if repoKey(ghMilestone.Status.Repo) != repoKey(ghIssue.Spec.Repo) {
+		return 0, fmt.Sprintf("The milestone of %s is in %s, not in %s", key.Name, repoName(ghMilestone.Status.Repo), repoName(ghIssue.Spec.Repo)), nil
+	}
+	return ghMilestone.Status.Number, "", nil
+}
+
+// syncedCondition returns the Synced condition of a synced GitHubIssue. It is false with the message milestoneWait
+// while the milestone of the issue waits for its GitHubMilestone, the issue is reconciled again when it changes
+func syncedCondition(ghIssue *examplev1alpha1.GitHubIssue, milestoneWait string) metav1.Condition {
+	if milestoneWait != "" {
+		return metav1.Condition{
+			Type:               ConditionSynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             "MilestoneNotReady",
+			Message:            milestoneWait,
+			ObservedGeneration: ghIssue.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               ConditionSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Synced",
+		Message:            "The real issue matches the spec",
+		ObservedGeneration: ghIssue.Generation,
+	}
+}
+
+// indexMilestoneRef returns the namespace/name of the GitHubMilestone of a GitHubIssue, for milestoneRefIndex
+func indexMilestoneRef(obj client.Object) []string {
+	ghIssue := obj.(*examplev1alpha1.GitHubIssue)
+	if ghIssue.Spec.MilestoneRef == nil {
+		return nil
+	}
+	return []string{types.NamespacedName{Namespace: ghIssue.Namespace, Name: ghIssue.Spec.MilestoneRef.Name}.String()}
+}
+
+// issuesOfMilestone returns the GitHubIssue objects referencing a GitHubMilestone, so they are synced once its
+// milestone is created
+func (r *GitHubIssueReconciler) issuesOfMilestone(obj client.Object) []reconcile.Request {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+	if err := r.Client.List(context.Background(), &ghIssues, client.MatchingFields{milestoneRefIndex: key}); err != nil {
+		r.Log.Info("failed to list the issues of the milestone", "error", err.Error())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, ghIssue := range ghIssues.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ghIssue.Namespace, Name: ghIssue.Name}})
+	}
+	return requests
+}
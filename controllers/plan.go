@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Planned actions - the operations the reconciler may perform on a real issue
+const (
+	PlanCreate = "create"
+	PlanEdit   = "edit"
+	PlanClose  = "close"
+	PlanReopen = "reopen"
+	PlanNoop   = "no-op"
+)
+
+// dryRunAnnotation marks a single GitHubIssue for dry-run reconciliation
+const dryRunAnnotation = "example.training.redhat.com/dry-run"
+
+// isDryRun returns true if the reconciler should only plan for the given object
+func (r *GitHubIssueReconciler) isDryRun(ghIssue *examplev1alpha1.GitHubIssue) bool {
+	return r.DryRun || ghIssue.GetAnnotations()[dryRunAnnotation] == "true"
+}
+
+// DesiredIssue fills issueData like the reconciler does before computing its plan: with the labels, assignees and
+// milestone of the spec, and the links to the blockers and the task list of the children in the body. It's exported
+// for ghissuectl to diff a real issue against the issue the operator syncs it to
+func DesiredIssue(ctx context.Context, c client.Reader, ghIssue *examplev1alpha1.GitHubIssue, issueData *clients.Issue) error {
+	issueData.Labels = ghIssue.Spec.Labels
+	issueData.Assignees = ghIssue.Spec.Assignees
+	if ghIssue.Spec.MilestoneRef != nil {
+		number, _, err := milestoneNumber(ctx, c, ghIssue)
+		if err != nil {
+			return err
+		}
+		issueData.Milestone = number
+	}
+	blockers, err := blockersOf(ctx, c, ghIssue)
+	if err != nil {
+		return err
+	}
+	children, err := childrenOf(ctx, c, ghIssue)
+	if err != nil {
+		return err
+	}
+	issueData.Description = withTaskList(withBlockedBy(issueData.Description, blockers), children)
+	return nil
+}
+
+// ComputePlan returns the action the reconciler takes for the desired issue (issueData)
+// given the real issue found in the repo (issue, nil if it doesn't exist)
+func ComputePlan(ghIssue *examplev1alpha1.GitHubIssue, issueData *clients.Issue, issue *clients.Issue) *examplev1alpha1.IssuePlan {
+	if !ghIssue.ObjectMeta.DeletionTimestamp.IsZero() {
+		if issue == nil || issue.State == "closed" {
+			return &examplev1alpha1.IssuePlan{Action: PlanNoop}
+		}
+		return &examplev1alpha1.IssuePlan{
+			Action:  PlanClose,
+			Changes: []examplev1alpha1.FieldChange{{Field: "state", From: issue.State, To: "closed"}},
+		}
+	}
+	expired := isExpired(ghIssue, time.Now())
+	if issue == nil {
+		if expired {
+			// No point in creating an issue only to close it
+			return &examplev1alpha1.IssuePlan{Action: PlanNoop}
+		}
+		return &examplev1alpha1.IssuePlan{
+			Action: PlanCreate,
+			Changes: []examplev1alpha1.FieldChange{
+				{Field: "title", To: issueData.Title},
+				{Field: "body", To: issueData.Description},
+			},
+		}
+	}
+	if (ghIssue.Spec.State == "closed" || expired) && issue.State != "closed" {
+		return &examplev1alpha1.IssuePlan{
+			Action:  PlanClose,
+			Changes: []examplev1alpha1.FieldChange{{Field: "state", From: issue.State, To: "closed"}},
+		}
+	}
+	if changes := editChanges(issueData, issue); len(changes) > 0 && issue.State != "closed" {
+		return &examplev1alpha1.IssuePlan{Action: PlanEdit, Changes: changes}
+	}
+	return &examplev1alpha1.IssuePlan{Action: PlanNoop}
+}
+
+// editChanges returns the differences of the real issue from the desired one, the labels, assignees and
+// milestone only if they are managed
+func editChanges(issueData *clients.Issue, issue *clients.Issue) []examplev1alpha1.FieldChange {
+	var changes []examplev1alpha1.FieldChange
+	if issueData.Description != issue.Description {
+		changes = append(changes, examplev1alpha1.FieldChange{Field: "body", From: issue.Description, To: issueData.Description})
+	}
+	if added, removed := stringsChanges(issue.Labels, issueData.Labels); issueData.Labels != nil && len(added)+len(removed) > 0 {
+		changes = append(changes, examplev1alpha1.FieldChange{Field: "labels", From: strings.Join(issue.Labels, ", "), To: strings.Join(issueData.Labels, ", ")})
+	}
+	if added, removed := stringsChanges(issue.Assignees, issueData.Assignees); issueData.Assignees != nil && len(added)+len(removed) > 0 {
+		changes = append(changes, examplev1alpha1.FieldChange{Field: "assignees", From: strings.Join(issue.Assignees, ", "), To: strings.Join(issueData.Assignees, ", ")})
+	}
+	if issueData.Milestone != 0 && issueData.Milestone != issue.Milestone {
+		changes = append(changes, examplev1alpha1.FieldChange{Field: "milestone", From: milestoneString(issue.Milestone), To: milestoneString(issueData.Milestone)})
+	}
+	return changes
+}
+
+// milestoneString returns the number of a milestone as #3, empty for none
+func milestoneString(number int) string {
+	if number == 0 {
+		return ""
+	}
+	return "#" + strconv.Itoa(number)
+}
+
+// planMessage formats a plan as a human readable event message
+func planMessage(plan *examplev1alpha1.IssuePlan) string {
+	if plan.Action == PlanNoop {
+		return "dry-run: issue is up to date"
+	}
+	changes := make([]string, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		changes = append(changes, fmt.Sprintf("%s: %q -> %q", change.Field, change.From, change.To))
+	}
+	return "dry-run: would " + plan.Action + " issue (" + strings.Join(changes, ", ") + ")"
+}
+
+// ApplyPlan performs the planned action on the real issue, and returns the issue as it is afterwards
+func ApplyPlan(clientFrame clients.ClientFrame, plan *examplev1alpha1.IssuePlan, issueData *clients.Issue, issue *clients.Issue, detailsData *clients.Details) (*clients.Issue, *clients.Error) {
+	switch plan.Action {
+	case PlanCreate:
+		return clientFrame.CreateIssue(issueData, detailsData)
+	case PlanEdit:
+		return issue, clientFrame.EditIssue(issueData, issue, detailsData)
+	case PlanClose:
+		return issue, clientFrame.CloseIssue(issueData, issue, detailsData)
+	case PlanReopen:
+		reopened := *issueData
+		reopened.State = "open"
+		reopened.StateReason = "reopened"
+		return issue, clientFrame.EditIssue(&reopened, issue, detailsData)
+	}
+	return issue, &clients.Error{}
+}
+
+// FindRealIssue returns the real issue of a GitHubIssue, by the adopted issue number if there is one, or by its title
+func FindRealIssue(clientFrame clients.ClientFrame, ghIssue *examplev1alpha1.GitHubIssue, repoData *clients.Repo, issueData *clients.Issue, detailsData *clients.Details) (*clients.Issue, *clients.Error) {
+	adopted, ok := ghIssue.GetAnnotations()[AdoptAnnotation]
+	if !ok {
+		return clientFrame.FindIssue(repoData, issueData, detailsData)
+	}
+	number, err := strconv.Atoi(adopted)
+	if err != nil || number <= 0 {
+		return nil, &clients.Error{ErrorCode: fmt.Errorf("invalid issue number %q", adopted), Message: "Annotation " + AdoptAnnotation + " must be a positive issue number"}
+	}
+	return clientFrame.GetIssue(repoData, number, detailsData)
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+)
+
+// syncProject adds the real issue to the project of spec.project and sets its field values,
+// and returns the id of its item on the project, empty if it has no project
+func (r *GitHubIssueReconciler) syncProject(ghIssue *examplev1alpha1.GitHubIssue, issue *clients.Issue, detailsData *clients.Details) (string, *clients.Error) {
+	spec := ghIssue.Spec.Project
+	if spec == nil || issue == nil {
+		return "", &clients.Error{}
+	}
+	project := &clients.Project{
+		ID:        spec.ID,
+		Owner:     spec.Owner,
+		Number:    spec.Number,
+		Status:    spec.Status,
+		Iteration: spec.Iteration,
+		Fields:    spec.Fields,
+	}
+	item, returnErr := r.ClientFrame.SyncProjectItem(issue, project, detailsData)
+	if returnErr.ErrorCode != nil {
+		return "", returnErr
+	}
+	return item.ID, returnErr
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/arielireni/example-operator/pkg/reporef"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+)
+
+// Repo change policies - what happens to the real issue when spec.repo changes
+const (
+	RepoChangeTransfer = "Transfer"
+	RepoChangeRecreate = "Recreate"
+)
+
+// repoChanged returns true if spec.repo moved away from the repo of the real issue recorded in the status
+func repoChanged(ghIssue *examplev1alpha1.GitHubIssue) bool {
+	if ghIssue.Status.Repo == "" || ghIssue.Status.Number == 0 {
+		return false
+	}
+	previous, err := reporef.Parse(ghIssue.Status.Repo)
+	if err != nil {
+		return !strings.EqualFold(ghIssue.Status.Repo, ghIssue.Spec.Repo)
+	}
+	current, err := reporef.Parse(ghIssue.Spec.Repo)
+	return err != nil || previous.Key() != current.Key()
+}
+
+// previousIssue returns the real issue in the repo recorded in the status with its details, nil if it's gone.
+// It's managed with the credentials of the issue in the new repo
+func (r *GitHubIssueReconciler) previousIssue(ghIssue *examplev1alpha1.GitHubIssue, currentDetails *clients.Details) (*clients.Issue, *clients.Details, *clients.Error) {
+	repoData, _, detailsData := r.ClientFrame.InitDataStructs(ghIssue.Status.Repo, ghIssue.Spec.Title, "")
+	detailsData.Resource = currentDetails.Resource
+	detailsData.Token = currentDetails.Token
+	issue, returnErr := r.ClientFrame.GetIssue(repoData, ghIssue.Status.Number, detailsData)
+	if returnErr.StatusCode == http.StatusNotFound {
+		return nil, detailsData, &clients.Error{}
+	}
+	return issue, detailsData, returnErr
+}
+
+// rehome prepares the move of the real issue to the new repo of detailsData: Transfer moves it there,
+// Recreate leaves it to closeMovedIssue once the new one exists. An adopted number is kept pointing at the issue
+func (r *GitHubIssueReconciler) rehome(ctx context.Context, ghIssue *examplev1alpha1.GitHubIssue, detailsData *clients.Details) *clients.Error {
+	number := 0
+	if ghIssue.Spec.RepoChangePolicy == RepoChangeTransfer {
+		previous, previousDetails, returnErr := r.previousIssue(ghIssue, detailsData)
+		if returnErr.ErrorCode != nil || previous == nil {
+			return returnErr
+		}
+		if returnErr := r.ClientFrame.TransferIssue(previous, previousDetails, detailsData); returnErr.ErrorCode != nil {
+			return returnErr
+		}
+		number = previous.Number
+		if r.Recorder != nil {
+			r.Recorder.Event(ghIssue, "Normal", "Transferred", fmt.Sprintf("Transferred %s#%d to %s#%d", repoName(ghIssue.Status.Repo), ghIssue.Status.Number, repoName(ghIssue.Spec.Repo), number))
+		}
+	}
+	if _, ok := ghIssue.GetAnnotations()[AdoptAnnotation]; !ok {
+		return &clients.Error{}
+	}
+	// The adopted number of the previous repo means nothing in the new one
+	patch := client.MergeFrom(ghIssue.DeepCopy())
+	if number == 0 {
+		delete(ghIssue.Annotations, AdoptAnnotation)
+	} else {
+		ghIssue.Annotations[AdoptAnnotation] = strconv.Itoa(number)
+	}
+	if err := r.Client.Patch(ctx, ghIssue, patch); err != nil {
+		return &clients.Error{ErrorCode: err, Message: "Updating the adopted issue number failed: " + err.Error()}
+	}
+	return &clients.Error{}
+}
+
+// closeMovedIssue closes the real issue left in the previous repo as not planned, with a link to its replacement.
+// It's closed before the link is posted, so a retry never posts the link twice
+func (r *GitHubIssueReconciler) closeMovedIssue(ghIssue *examplev1alpha1.GitHubIssue, issue *clients.Issue, detailsData *clients.Details) *clients.Error {
+	previous, previousDetails, returnErr := r.previousIssue(ghIssue, detailsData)
+	if returnErr.ErrorCode != nil || previous == nil || previous.State == "closed" {
+		return returnErr
+	}
+	if returnErr := r.ClientFrame.CloseIssue(&clients.Issue{StateReason: "not_planned"}, previous, previousDetails); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	link := fmt.Sprintf("%s#%d", repoName(ghIssue.Spec.Repo), issue.Number)
+	if _, returnErr := r.ClientFrame.CreateComment(previous.Number, &clients.Comment{Body: "Moved to " + link + "."}, previousDetails); returnErr.ErrorCode != nil {
+		return returnErr
+	}
+	if r.Recorder != nil {
+		r.Recorder.Event(ghIssue, "Normal", "Recreated", fmt.Sprintf("Closed %s#%d, moved to %s", repoName(ghIssue.Status.Repo), ghIssue.Status.Number, link))
+	}
+	return &clients.Error{}
+}
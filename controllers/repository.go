@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	"github.com/arielireni/example-operator/pkg/reporef"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// repositoryRefIndex indexes GitHubIssue objects by the namespace/name of their GitHubRepository
+const repositoryRefIndex = ".spec.repositoryRef"
+
+// repositoryKey returns the namespace/name of the GitHubRepository of a GitHubIssue
+func repositoryKey(ghIssue *examplev1alpha1.GitHubIssue) types.NamespacedName {
+	return repositoryRefKey(ghIssue.Namespace, ghIssue.Spec.RepositoryRef)
+}
+
+// repositoryRefKey returns the namespace/name of a GitHubRepository referenced from an object of the namespace
+func repositoryRefKey(namespace string, ref *examplev1alpha1.RepositoryReference) types.NamespacedName {
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if key.Namespace == "" {
+		key.Namespace = namespace
+	}
+	return key
+}
+
+// WithRepository fills the repo and labels a GitHubIssue doesn't set with the defaults of its GitHubRepository,
+// and returns the repository, nil if the issue has none. The spec is only changed in memory, the defaults follow
+// the changes of the repository. It's exported for ghissuectl to find the real issues the way the operator does
+func WithRepository(ctx context.Context, c client.Reader, ghIssue *examplev1alpha1.GitHubIssue) (*examplev1alpha1.GitHubRepository, *clients.Error) {
+	if ghIssue.Spec.RepositoryRef == nil {
+		return nil, &clients.Error{}
+	}
+	ghRepo, returnErr := repositoryOf(ctx, c, ghIssue.Namespace, ghIssue.Spec.RepositoryRef)
+	if returnErr.ErrorCode != nil {
+		return nil, returnErr
+	}
+	if ghIssue.Spec.Repo == "" {
+		ghIssue.Spec.Repo = repositoryRepo(ghRepo)
+	}
+	if ghIssue.Spec.Labels == nil {
+		ghIssue.Spec.Labels = ghRepo.Spec.DefaultLabels
+	}
+	return ghRepo, &clients.Error{}
+}
+
+// repositoryOf returns the GitHubRepository referenced from an object of the namespace, if it allows the namespace
+func repositoryOf(ctx context.Context, c client.Reader, namespace string, ref *examplev1alpha1.RepositoryReference) (*examplev1alpha1.GitHubRepository, *clients.Error) {
+	key := repositoryRefKey(namespace, ref)
+	ghRepo := examplev1alpha1.GitHubRepository{}
+	if err := c.Get(ctx, key, &ghRepo); err != nil {
+		if errors.IsNotFound(err) {
+			err = fmt.Errorf("the GitHubRepository %s doesn't exist", key)
+		}
+		return nil, &clients.Error{ErrorCode: err, Message: "Getting the repository failed: " + err.Error()}
+	}
+	if !namespaceAllowed(&ghRepo, namespace) {
+		err := fmt.Errorf("the GitHubRepository %s doesn't allow namespace %s", key, namespace)
+		return nil, &clients.Error{ErrorCode: err, Message: "Getting the repository failed: " + err.Error()}
+	}
+	return &ghRepo, &clients.Error{}
+}
+
+// repoOf returns the repo of an object of the namespace that sets it or references a GitHubRepository, with the
+// repository it references if any. The repo set by the object wins over the repository's
+func repoOf(ctx context.Context, c client.Client, namespace, repo string, ref *examplev1alpha1.RepositoryReference) (string, *examplev1alpha1.GitHubRepository, *clients.Error) {
+	if ref == nil {
+		return repo, nil, &clients.Error{}
+	}
+	ghRepo, returnErr := repositoryOf(ctx, c, namespace, ref)
+	if returnErr.ErrorCode != nil {
+		return "", nil, returnErr
+	}
+	if repo == "" {
+		repo = repositoryRepo(ghRepo)
+	}
+	return repo, ghRepo, &clients.Error{}
+}
+
+// namespaceAllowed returns true if GitHubIssue objects of the namespace may reference the repository
+func namespaceAllowed(ghRepo *examplev1alpha1.GitHubRepository, namespace string) bool {
+	if namespace == ghRepo.Namespace {
+		return true
+	}
+	for _, pattern := range ghRepo.Spec.AllowedNamespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// repositoryRepo returns the repo of a GitHubRepository, on its enterprise host if it has one
+func repositoryRepo(ghRepo *examplev1alpha1.GitHubRepository) string {
+	ref, err := reporef.Parse(ghRepo.Spec.Repo)
+	if err != nil || ghRepo.Spec.Host == "" || ref.Host != "" {
+		return ghRepo.Spec.Repo
+	}
+	return ghRepo.Spec.Host + "/" + ref.String()
+}
+
+// repoKey returns a key equal for all the references to the same repo, the reference itself if it's malformed
+func repoKey(repo string) string {
+	if ref, err := reporef.Parse(repo); err == nil {
+		return ref.Key()
+	}
+	return repo
+}
+
+// indexRepositoryRef returns the namespace/name of the GitHubRepository of a GitHubIssue, a GitHubLabel or a
+// GitHubMilestone, for repositoryRefIndex
+func indexRepositoryRef(obj client.Object) []string {
+	var ref *examplev1alpha1.RepositoryReference
+	switch o := obj.(type) {
+	case *examplev1alpha1.GitHubIssue:
+		ref = o.Spec.RepositoryRef
+	case *examplev1alpha1.GitHubLabel:
+		ref = o.Spec.RepositoryRef
+	case *examplev1alpha1.GitHubMilestone:
+		ref = o.Spec.RepositoryRef
+	}
+	if ref == nil {
+		return nil
+	}
+	return []string{repositoryRefKey(obj.GetNamespace(), ref).String()}
+}
+
+// objectsOfRepository maps a GitHubRepository to the objects of the kind of list referencing it, so they follow
+// its changes
+func objectsOfRepository(c client.Client, log logr.Logger, list client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		objects := list.DeepCopyObject().(client.ObjectList)
+		key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+		if err := c.List(context.Background(), objects, client.MatchingFields{repositoryRefIndex: key}); err != nil {
+			log.Info("failed to list the objects of the repository", "error", err.Error())
+			return nil
+		}
+		return requestsOf(objects, obj)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2021.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	examplev1beta1 "github.com/arielireni/example-operator/api/v1beta1"
+	"github.com/arielireni/example-operator/controllers/clients"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"net"
+	"os"
+	"path/filepath"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"strconv"
+	"testing"
+	"time"
+	//+kubebuilder:scaffold:imports
+)
+
+// These tests use Ginkgo (BDD-style Go testing framework). Refer to
+// http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
+
+var cfg *rest.Config
+var k8sClient client.Client
+var testEnv *envtest.Environment
+var fakeClient *clients.FakeClient
+var cancelManager context.CancelFunc
+
+// envtestSyncPeriod is the resync period of the manager under test
+const envtestSyncPeriod = 2 * time.Second
+
+func TestAPIs(t *testing.T) {
+	// The suite needs etcd and kube-apiserver binaries, see "make test"
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		if _, err := os.Stat("/usr/local/kubebuilder/bin/kube-apiserver"); err != nil {
+			t.Skip("envtest binaries not found, set KUBEBUILDER_ASSETS to run the controller suite")
+		}
+	}
+
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Controller Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
+
+var _ = BeforeSuite(func() {
+	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	var err error
+	cfg, err = testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	err = examplev1alpha1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+	err = examplev1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	//+kubebuilder:scaffold:scheme
+
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(k8sClient).NotTo(BeNil())
+
+	// GitHubIssue is stored as v1beta1, so the v1alpha1 objects of the suite are converted by the manager, serving
+	// with the certificates of the test environment, which installs no webhook configurations of its own
+	By("pointing the GitHubIssue CRD at the conversion webhook of the manager")
+	webhookOptions := &testEnv.WebhookInstallOptions
+	webhookAddress := net.JoinHostPort(webhookOptions.LocalServingHost, strconv.Itoa(webhookOptions.LocalServingPort))
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+	crd.SetName("githubissues.example.training.redhat.com")
+	conversion := fmt.Sprintf(`{"spec":{"conversion":{"strategy":"Webhook","webhook":{"clientConfig":{"url":"https://%s/convert","caBundle":%q},"conversionReviewVersions":["v1beta1"]}}}}`,
+		webhookAddress, base64.StdEncoding.EncodeToString(webhookOptions.LocalServingCAData))
+	err = k8sClient.Patch(context.Background(), crd, client.RawPatch(types.MergePatchType, []byte(conversion)))
+	Expect(err).NotTo(HaveOccurred())
+
+	By("starting the manager with a fake GitHub client")
+	syncPeriod := envtestSyncPeriod
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+		SyncPeriod:         &syncPeriod,
+		Host:               webhookOptions.LocalServingHost,
+		Port:               webhookOptions.LocalServingPort,
+		CertDir:            webhookOptions.LocalServingCertDir,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	err = (&examplev1alpha1.GitHubIssue{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	fakeClient = clients.NewFakeClient([]clients.Issue{}, true, nil)
+	err = (&GitHubIssueReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("GitHubIssue"),
+		Scheme:      mgr.GetScheme(),
+		ClientFrame: fakeClient,
+		Recorder:    mgr.GetEventRecorderFor("githubissue-controller"),
+	}).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	var ctx context.Context
+	ctx, cancelManager = context.WithCancel(context.Background())
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(ctx)).To(Succeed())
+	}()
+
+	// The API server can't write a GitHubIssue before the conversion webhook serves
+	Eventually(func() error {
+		conn, err := tls.Dial("tcp", webhookAddress, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}, 10*time.Second).Should(Succeed())
+}, 60)
+
+var _ = AfterSuite(func() {
+	By("tearing down the test environment")
+	if cancelManager != nil {
+		cancelManager()
+	}
+	err := testEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+/* Helpers of the reconciler unit tests, which run against a fake k8s client without the test environment */
+
+// newTestK8sClient returns a fake k8s client holding objects, and the scheme it's built with
+func newTestK8sClient(objects ...runtime.Object) (client.Client, *runtime.Scheme) {
+	s := scheme.Scheme
+	examplev1alpha1.AddToScheme(s)
+	return fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build(), s
+}
+
+// testRequestOf returns the request reconciling obj
+func testRequestOf(obj client.Object) ctrl.Request {
+	return ctrl.Request{NamespacedName: client.ObjectKeyFromObject(obj)}
+}
+
+// getTestObject reads the object of key into obj, failing the test if it can't be read
+func getTestObject(t *testing.T, k8sClient client.Client, key types.NamespacedName, obj client.Object) {
+	if err := k8sClient.Get(context.Background(), key, obj); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var testCommentRequest = testRequestOf(newTestGitHubIssueComment(""))
+
+func newTestGitHubIssueComment(body string) *examplev1alpha1.GitHubIssueComment {
+	return &examplev1alpha1.GitHubIssueComment{
+		ObjectMeta: metav1.ObjectMeta{Name: "comment1", Namespace: "default"},
+		Spec: examplev1alpha1.GitHubIssueCommentSpec{
+			IssueRef: corev1.LocalObjectReference{Name: "issue1"},
+			Body:     body,
+		},
+	}
+}
+
+// newTestCommentedIssue returns the GitHubIssue of the test comments, with its real issue created as number 1
+func newTestCommentedIssue() *examplev1alpha1.GitHubIssue {
+	ghIssue := newTestGitHubIssue("body")
+	ghIssue.UID = "issue1-uid"
+	ghIssue.Status.Number = 1
+	return ghIssue
+}
+
+func newTestCommentReconciler(fakeClient clients.ClientFrame, objects ...runtime.Object) (*GitHubIssueCommentReconciler, client.Client) {
+	k8sClient, s := newTestK8sClient(objects...)
+	return &GitHubIssueCommentReconciler{Client: k8sClient, Log: ctrl.Log, Scheme: s, ClientFrame: fakeClient}, k8sClient
+}
+
+var testRepositoryRequest = testRequestOf(newTestGitHubRepository())
+
+// newTestGitHubRepository returns the GitHubRepository of arielireni/Issues-Example, shared with the default namespace
+func newTestGitHubRepository() *examplev1alpha1.GitHubRepository {
+	return &examplev1alpha1.GitHubRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "issues", Namespace: "platform"},
+		Spec: examplev1alpha1.GitHubRepositorySpec{
+			Repo:              "arielireni/Issues-Example",
+			DefaultLabels:     []string{"triage"},
+			AllowedNamespaces: []string{"def*"},
+		},
+	}
+}
+
+func newTestRepositoryReconciler(fakeClient clients.ClientFrame, objects ...runtime.Object) (*GitHubRepositoryReconciler, client.Client) {
+	k8sClient, s := newTestK8sClient(objects...)
+	return &GitHubRepositoryReconciler{Client: k8sClient, Log: ctrl.Log, Scheme: s, ClientFrame: fakeClient}, k8sClient
+}
+
+var testLabelRequest = testRequestOf(newTestGitHubLabel())
+
+// newTestGitHubLabel returns the bug label of the test GitHubRepository
+func newTestGitHubLabel() *examplev1alpha1.GitHubLabel {
+	return &examplev1alpha1.GitHubLabel{
+		ObjectMeta: metav1.ObjectMeta{Name: "bug", Namespace: "default", UID: "bug"},
+		Spec: examplev1alpha1.GitHubLabelSpec{
+			RepositoryRef: &examplev1alpha1.RepositoryReference{Name: "issues", Namespace: "platform"},
+			Name:          "bug",
+			Color:         "#D73A4A",
+			Description:   "Something isn't working",
+		},
+	}
+}
+
+func newTestLabelReconciler(fakeClient clients.ClientFrame, objects ...runtime.Object) (*GitHubLabelReconciler, client.Client) {
+	k8sClient, s := newTestK8sClient(objects...)
+	return &GitHubLabelReconciler{Client: k8sClient, Log: ctrl.Log, Scheme: s, ClientFrame: fakeClient}, k8sClient
+}
+
+var testMilestoneRequest = testRequestOf(newTestGitHubMilestone())
+
+// newTestGitHubMilestone returns the v1.0 milestone of the test GitHubRepository
+func newTestGitHubMilestone() *examplev1alpha1.GitHubMilestone {
+	return &examplev1alpha1.GitHubMilestone{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1-0", Namespace: "default", UID: "v1-0"},
+		Spec: examplev1alpha1.GitHubMilestoneSpec{
+			RepositoryRef: &examplev1alpha1.RepositoryReference{Name: "issues", Namespace: "platform"},
+			Title:         "v1.0",
+			Description:   "The first release",
+			DueOn:         "2026-12-01",
+		},
+	}
+}
+
+func newTestMilestoneReconciler(fakeClient clients.ClientFrame, objects ...runtime.Object) (*GitHubMilestoneReconciler, client.Client) {
+	k8sClient, s := newTestK8sClient(objects...)
+	return &GitHubMilestoneReconciler{Client: k8sClient, Log: ctrl.Log, Scheme: s, ClientFrame: fakeClient}, k8sClient
+}
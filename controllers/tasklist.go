@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+// taskListMarker starts the generated task list of the children in the body of a parent
+const taskListMarker = "<!-- generated: task-list -->"
+
+// parentRefIndex indexes GitHubIssue objects by the namespace/name of their parent
+const parentRefIndex = ".spec.parentRef"
+
+// children returns the GitHubIssue objects whose parentRef is the given issue, sorted by namespace/name
+func (r *GitHubIssueReconciler) children(ctx context.Context, parent *examplev1alpha1.GitHubIssue) ([]examplev1alpha1.GitHubIssue, error) {
+	key := types.NamespacedName{Namespace: parent.Namespace, Name: parent.Name}.String()
+	return childrenOf(ctx, r.Client, parent, client.MatchingFields{parentRefIndex: key})
+}
+
+// childrenOf returns the children of the parent among the GitHubIssue objects listed with the options, all of them
+// without a field index
+func childrenOf(ctx context.Context, c client.Reader, parent *examplev1alpha1.GitHubIssue, opts ...client.ListOption) ([]examplev1alpha1.GitHubIssue, error) {
+	ghIssues := examplev1alpha1.GitHubIssueList{}
+	if err := c.List(ctx, &ghIssues, opts...); err != nil {
+		return nil, err
+	}
+	var children []examplev1alpha1.GitHubIssue
+	for _, ghIssue := range ghIssues.Items {
+		if ghIssue.Spec.ParentRef != nil && ghIssue.DeletionTimestamp.IsZero() &&
+			referenceKey(&ghIssue, *ghIssue.Spec.ParentRef) == (types.NamespacedName{Namespace: parent.Namespace, Name: parent.Name}) {
+			children = append(children, ghIssue)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Namespace != children[j].Namespace {
+			return children[i].Namespace < children[j].Namespace
+		}
+		return children[i].Name < children[j].Name
+	})
+	return children, nil
+}
+
+// indexParentRef returns the namespace/name of the parent of a GitHubIssue, for parentRefIndex
+func indexParentRef(obj client.Object) []string {
+	ghIssue := obj.(*examplev1alpha1.GitHubIssue)
+	if ghIssue.Spec.ParentRef == nil {
+		return nil
+	}
+	return []string{referenceKey(ghIssue, *ghIssue.Spec.ParentRef).String()}
+}
+
+// withTaskList returns the body with a task list of the children appended, ticking the closed ones
+func withTaskList(body string, children []examplev1alpha1.GitHubIssue) string {
+	if len(children) == 0 {
+		return body
+	}
+	lines := []string{taskListMarker, "**Tasks:**"}
+	for _, child := range children {
+		check := " "
+		if child.Status.State == "closed" {
+			check = "x"
+		}
+		if child.Status.Number == 0 {
+			lines = append(lines, fmt.Sprintf("- [%s] %s (not created yet)", check, child.Spec.Title))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %s#%d", check, repoName(issueRepo(&child)), child.Status.Number))
+	}
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + strings.Join(lines, "\n")
+}
+
+// setCompletion reports the number of children and the percentage of closed ones in the status of a parent
+func setCompletion(parent *examplev1alpha1.GitHubIssue, children []examplev1alpha1.GitHubIssue) {
+	parent.Status.Children = len(children)
+	if len(children) == 0 {
+		parent.Status.Completion = nil
+		return
+	}
+	closed := 0
+	for _, child := range children {
+		if child.Status.State == "closed" {
+			closed++
+		}
+	}
+	completion := int32(closed * 100 / len(children))
+	parent.Status.Completion = &completion
+}
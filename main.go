@@ -20,7 +20,10 @@ import (
 	"flag"
 	"github.com/arielireni/example-operator/controllers"
 	"github.com/arielireni/example-operator/controllers/clients"
+	"io/ioutil"
 	"os"
+	"strings"
+	"text/template"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -34,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	examplev1alpha1 "github.com/arielireni/example-operator/api/v1alpha1"
+	examplev1beta1 "github.com/arielireni/example-operator/api/v1beta1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -46,6 +50,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(examplev1alpha1.AddToScheme(scheme))
+	utilruntime.Must(examplev1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -53,11 +58,31 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var dryRun bool
+	var auditLogFile string
+	var auditWebhookURL string
+	var githubAPI string
+	var editCommentTemplate string
+	var enterpriseHosts string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Only plan the changes on GitHub, without creating, editing, closing or deleting issues, comments, labels or milestones.")
+	flag.StringVar(&auditLogFile, "audit-log-file", "",
+		"Append a JSON line for every mutation performed on GitHub to this file.")
+	flag.StringVar(&auditWebhookURL, "audit-webhook-url", "",
+		"Post a JSON record of every mutation performed on GitHub to this URL.")
+	flag.StringVar(&githubAPI, "github-api", "rest",
+		"The GitHub API the issues are managed through, rest or graphql. "+
+			"With graphql all issues of a repo are fetched in one query, shared by the objects of the repo.")
+	flag.StringVar(&editCommentTemplate, "edit-comment-template", "",
+		"A Go template file rendering the comments of spec.commentOnEdit, instead of the default one.")
+	flag.StringVar(&enterpriseHosts, "enterprise-hosts", "",
+		"A comma separated list of the GitHub Enterprise Server hosts (host or host:port) the repos may be on. "+
+			"Their repos are managed with the credentialsRef of the objects, never with the token of the operator.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -82,15 +107,141 @@ func main() {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
+
+	var hosts []string
+	for _, host := range strings.Split(enterpriseHosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	var clientFrame clients.ClientFrame
+	switch githubAPI {
+	case "rest":
+		githubClient := clients.NewGithubClient()
+		githubClient.EnterpriseHosts = hosts
+		clientFrame = githubClient
+	case "graphql":
+		graphqlClient := clients.NewGraphQLClient()
+		graphqlClient.EnterpriseHosts = hosts
+		clientFrame = graphqlClient
+	default:
+		setupLog.Error(nil, "unknown GitHub API, expected rest or graphql", "github-api", githubAPI)
+		os.Exit(1)
+	}
+
+	// The default edit comment template is used if none is configured
+	var editTemplate *template.Template
+	if editCommentTemplate != "" {
+		text, err := ioutil.ReadFile(editCommentTemplate)
+		if err != nil {
+			setupLog.Error(err, "unable to read the edit comment template", "path", editCommentTemplate)
+			os.Exit(1)
+		}
+		if editTemplate, err = controllers.ParseEditCommentTemplate(string(text)); err != nil {
+			setupLog.Error(err, "unable to parse the edit comment template", "path", editCommentTemplate)
+			os.Exit(1)
+		}
+	}
+
+	// Record every mutation performed on GitHub in the configured audit sinks
+	var auditSinks clients.MultiAuditSink
+	if auditLogFile != "" {
+		fileSink, err := clients.NewFileAuditSink(auditLogFile)
+		if err != nil {
+			setupLog.Error(err, "unable to open audit log file", "path", auditLogFile)
+			os.Exit(1)
+		}
+		defer fileSink.Close()
+		auditSinks = append(auditSinks, fileSink)
+	}
+	if auditWebhookURL != "" {
+		auditSinks = append(auditSinks, clients.NewWebhookAuditSink(auditWebhookURL))
+	}
+	if len(auditSinks) > 0 {
+		auditClient := clients.NewAuditClient(clientFrame, auditSinks)
+		auditClient.Log = ctrl.Log.WithName("audit")
+		clientFrame = auditClient
+	}
+	// The issues are planned by their reconciler, every other mutation is refused and reported in the status
+	if dryRun {
+		clientFrame = clients.NewDryRunClient(clientFrame)
+	}
+
 	if err = (&controllers.GitHubIssueReconciler{
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("GitHubIssue"),
+		Scheme:              mgr.GetScheme(),
+		ClientFrame:         clientFrame,
+		APIReader:           mgr.GetAPIReader(),
+		Recorder:            mgr.GetEventRecorderFor("githubissue-controller"),
+		DryRun:              dryRun,
+		EditCommentTemplate: editTemplate,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubIssue")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubIssueSetReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("GitHubIssueSet"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubIssueSet")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubRecurringIssueReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("GitHubRecurringIssue"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubRecurringIssue")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubIssueCommentReconciler{
 		Client:      mgr.GetClient(),
-		Log:         ctrl.Log.WithName("controllers").WithName("GitHubIssue"),
+		Log:         ctrl.Log.WithName("controllers").WithName("GitHubIssueComment"),
 		Scheme:      mgr.GetScheme(),
-		ClientFrame: clients.NewGithubClient(),
+		ClientFrame: clientFrame,
+		APIReader:   mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "GitHubIssue")
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubIssueComment")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubRepositoryReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("GitHubRepository"),
+		Scheme:      mgr.GetScheme(),
+		ClientFrame: clientFrame,
+		APIReader:   mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubRepository")
 		os.Exit(1)
 	}
+	if err = (&controllers.GitHubLabelReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("GitHubLabel"),
+		Scheme:      mgr.GetScheme(),
+		ClientFrame: clientFrame,
+		APIReader:   mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubLabel")
+		os.Exit(1)
+	}
+	if err = (&controllers.GitHubMilestoneReconciler{
+		Client:      mgr.GetClient(),
+		Log:         ctrl.Log.WithName("controllers").WithName("GitHubMilestone"),
+		Scheme:      mgr.GetScheme(),
+		ClientFrame: clientFrame,
+		APIReader:   mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitHubMilestone")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&examplev1alpha1.GitHubIssue{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GitHubIssue")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
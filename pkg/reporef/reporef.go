@@ -0,0 +1,86 @@
+// Package reporef parses the references to GitHub repos, shared by the API types and the GitHub clients
+package reporef
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Ref is a parsed reference to a repo, on github.com if Host is empty. The owner and repo keep their case for
+// display, GitHub ignores it so they are compared and requested in lowercase. The host keeps its port, and Scheme
+// is http for a server given by an http url, https otherwise
+type Ref struct {
+	Scheme string
+	Host   string
+	Owner  string
+	Repo   string
+}
+
+// repoNameRegexp matches the name of an owner or a repo
+var repoNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// Parse parses a repo given as owner/repo, host/owner/repo, an http(s) or ssh url, or an scp-like ssh remote
+// such as git@github.com:owner/repo.git
+func Parse(ref string) (Ref, error) {
+	path := strings.TrimSpace(ref)
+	host, scheme := "", ""
+	switch {
+	case strings.Contains(path, "://"):
+		u, err := url.Parse(path)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http" && u.Scheme != "ssh" && u.Scheme != "git") {
+			return Ref{}, fmt.Errorf("invalid repository reference %q, expected owner/repo or its url", ref)
+		}
+		// The port of an ssh or git url is the one of git, not of the API
+		host, path = u.Hostname(), u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			host = u.Host
+		}
+		if u.Scheme == "http" {
+			scheme = "http"
+		}
+	case strings.Contains(path, "@") && strings.Contains(path, ":"):
+		// An scp-like remote, user@host:owner/repo
+		userHost := path[:strings.Index(path, ":")]
+		host, path = userHost[strings.Index(userHost, "@")+1:], path[strings.Index(path, ":")+1:]
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/")
+	if host == "" && len(parts) == 3 && strings.ContainsAny(parts[0], ".:") {
+		host, parts = parts[0], parts[1:]
+	}
+	if len(parts) != 2 || !validRepoName(parts[0]) || !validRepoName(parts[1]) {
+		return Ref{}, fmt.Errorf("invalid repository reference %q, expected owner/repo or its url", ref)
+	}
+	host = strings.ToLower(host)
+	if host == "github.com" || host == "www.github.com" {
+		host, scheme = "", ""
+	}
+	return Ref{Scheme: scheme, Host: host, Owner: parts[0], Repo: parts[1]}, nil
+}
+
+func validRepoName(name string) bool {
+	return repoNameRegexp.MatchString(name) && name != "." && name != ".."
+}
+
+// String returns the repo as owner/repo, the way GitHub links issues of other repos
+func (r Ref) String() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// Key returns the repo in lowercase with its host, equal for all the references to the same repo
+func (r Ref) Key() string {
+	return strings.ToLower(r.Host + "/" + r.Owner + "/" + r.Repo)
+}
+
+// IssuesURL returns the REST url of the issues of the repo, under baseURL or the API of its enterprise host
+func (r Ref) IssuesURL(baseURL string) string {
+	if r.Host != "" {
+		scheme := r.Scheme
+		if scheme == "" {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host + "/api/v3"
+	}
+	return baseURL + "/repos/" + strings.ToLower(r.Owner+"/"+r.Repo) + "/issues"
+}
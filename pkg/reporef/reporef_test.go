@@ -0,0 +1,36 @@
+package reporef
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]Ref{
+		"arielireni/Issues-Example":                          {Owner: "arielireni", Repo: "Issues-Example"},
+		"https://github.com/ArieliReni/Issues-Example":       {Owner: "ArieliReni", Repo: "Issues-Example"},
+		"https://github.com/arielireni/Issues-Example.git/":  {Owner: "arielireni", Repo: "Issues-Example"},
+		"git@github.com:arielireni/Issues-Example.git":       {Owner: "arielireni", Repo: "Issues-Example"},
+		"ssh://git@github.com/arielireni/Issues-Example.git": {Owner: "arielireni", Repo: "Issues-Example"},
+		"github.example.com/arielireni/Issues-Example":       {Host: "github.example.com", Owner: "arielireni", Repo: "Issues-Example"},
+		"https://GitHub.example.com/arielireni/repo.js":      {Host: "github.example.com", Owner: "arielireni", Repo: "repo.js"},
+		"ssh://git@github.example.com:2222/arielireni/repo":  {Host: "github.example.com", Owner: "arielireni", Repo: "repo"},
+		"http://github.example.com:8080/arielireni/repo":     {Scheme: "http", Host: "github.example.com:8080", Owner: "arielireni", Repo: "repo"},
+		"github.example.com:8443/arielireni/repo":            {Host: "github.example.com:8443", Owner: "arielireni", Repo: "repo"},
+	}
+	for ref, expected := range valid {
+		if parsed, err := Parse(ref); err != nil || parsed != expected {
+			t.Errorf("Expected %q to be parsed as %+v but got %+v, %v", ref, expected, parsed, err)
+		}
+	}
+	// References differing by their form and case are the same repo
+	first, _ := Parse("https://github.com/ArieliReni/Issues-Example")
+	second, _ := Parse("git@github.com:arielireni/issues-example.git")
+	if first.Key() != second.Key() {
+		t.Errorf("Expected the same repo but got %s and %s", first.Key(), second.Key())
+	}
+	for _, ref := range []string{"", "arielireni", "arielireni/", "a/b/c", "ftp://github.com/a/b", "https://github.com/a", "a/b c", "../b"} {
+		if parsed, err := Parse(ref); err == nil {
+			t.Errorf("Expected %q to be rejected but got %+v", ref, parsed)
+		}
+	}
+}